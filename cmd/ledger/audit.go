@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// runAudit handles "audit verify", "audit checkpoint" and "audit export" against the audit
+// records in MongoDB.
+func runAudit(ctx context.Context, cfg config.Config, logger *logging.Logger, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("audit: expected verify, checkpoint or export")
+	}
+
+	mongoDB, err := db.NewMongoDB(cfg)
+	if err != nil {
+		return fmt.Errorf("audit: connect to MongoDB: %w", err)
+	}
+	defer mongoDB.Close()
+
+	ledger := mongoDB.Client.Database("ledger")
+	checkpoints, err := audit.LoadCheckpoints(ledger.Collection("transactions"), ledger.Collection("audit_checkpoints"), cfg.AuditKey)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	switch args[0] {
+	case "verify":
+		summary, err := checkpoints.Verify(ctx, func(b audit.Break) {
+			fmt.Println(b)
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("verified %d chains and %d records (%d unchained): %d breaks\n",
+			summary.Chains, summary.Records, summary.Unchained, summary.Breaks)
+		logger.Info("audit chains verified", "chains", summary.Chains, "records", summary.Records,
+			"unchained", summary.Unchained, "breaks", summary.Breaks)
+
+		if summary.Breaks > 0 {
+			return errDiscrepancies
+		}
+
+	case "checkpoint":
+		cp, err := checkpoints.Create(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("checkpoint %s signed %d chains, root %s\n", cp.ID, len(cp.Heads), cp.Root)
+
+	case "export":
+		fs := flag.NewFlagSet("audit export", flag.ExitOnError)
+		since := fs.String("since", "", "only export checkpoints created on or after this UTC day (YYYY-MM-DD)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		var from time.Time
+		if *since != "" {
+			if from, err = time.Parse(time.DateOnly, *since); err != nil {
+				return fmt.Errorf("audit export: invalid since: %w", err)
+			}
+		}
+
+		list, err := checkpoints.List(ctx, from)
+		if err != nil {
+			return err
+		}
+
+		// One checkpoint per line, ready to hand to a notary
+		encoder := json.NewEncoder(os.Stdout)
+		for _, cp := range list {
+			if err := encoder.Encode(cp); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("audit: unknown command %q", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/period"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// runClose handles "close". Without flags it closes yesterday, which suits a daily cron entry just
+// after midnight UTC; -month closes the last day of a month, and with it the whole month.
+func runClose(ctx context.Context, cfg config.Config, logger *logging.Logger, database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("close", flag.ExitOnError)
+	date := fs.String("date", time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly), "UTC business date to close (YYYY-MM-DD)")
+	month := fs.String("month", "", "month to close through its last day (YYYY-MM), instead of -date")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	day, err := time.Parse(time.DateOnly, *date)
+	if err != nil {
+		return fmt.Errorf("close: invalid date: %w", err)
+	}
+	if *month != "" {
+		start, err := time.Parse("2006-01", *month)
+		if err != nil {
+			return fmt.Errorf("close: invalid month: %w", err)
+		}
+		day = start.AddDate(0, 1, -1)
+	}
+
+	closed, err := period.NewService(cfg, logger, database).Close(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("closed %s with closing balances for %d accounts\n", closed.Date, closed.Accounts)
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/interest"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// runInterest handles "interest accrue" and "interest post". Without flags, accrual covers
+// yesterday and posting covers the previous month, which suits a daily cron entry.
+func runInterest(ctx context.Context, cfg config.Config, logger *logging.Logger, database *db.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("interest: expected accrue or post")
+	}
+
+	service := interest.NewService(cfg, logger, database)
+	now := time.Now().UTC()
+
+	switch args[0] {
+	case "accrue":
+		fs := flag.NewFlagSet("interest accrue", flag.ExitOnError)
+		date := fs.String("date", now.AddDate(0, 0, -1).Format(time.DateOnly), "UTC day to accrue (YYYY-MM-DD)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		day, err := time.Parse(time.DateOnly, *date)
+		if err != nil {
+			return fmt.Errorf("interest accrue: invalid date: %w", err)
+		}
+
+		count, err := service.AccrueDaily(ctx, day)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("accrued interest for %d accounts on %s\n", count, day.Format(time.DateOnly))
+
+	case "post":
+		fs := flag.NewFlagSet("interest post", flag.ExitOnError)
+		previous := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+		month := fs.String("month", previous.Format("2006-01"), "month to post (YYYY-MM)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		start, err := time.Parse("2006-01", *month)
+		if err != nil {
+			return fmt.Errorf("interest post: invalid month: %w", err)
+		}
+
+		count, err := service.PostMonthly(ctx, start)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("posted interest for %d accounts for %s\n", count, start.Format("2006-01"))
+
+	default:
+		return fmt.Errorf("interest: unknown subcommand %q", args[0])
+	}
+
+	return nil
+}
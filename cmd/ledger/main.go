@@ -0,0 +1,97 @@
+// Command ledger runs operational jobs against the banking ledger.
+//
+// Global flags (see config.Load) come before the command name, command flags after it:
+//
+//	ledger -dsn=postgres://... interest accrue -date=2026-06-30
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+const usage = `usage: ledger [global flags] <command> [command flags]
+
+commands:
+  interest accrue   accrue one day of interest on accounts with a rate plan
+  interest post     post a month of accrued interest as deposits
+  report            print the trial-balance or daily-volume report as CSV or JSON
+  close             close a business date or month and snapshot closing balances
+  verify            check balances and entries for consistency; exits 3 on discrepancies
+  rebuild           replay transactions to recompute balances, -apply to correct them; exits 3 on differences
+  reconcile         compare the ledger with the audit store, -backfill to repair; exits 3 on findings
+  audit verify      check the audit hash chains and checkpoints; exits 3 on breaks
+  audit checkpoint  sign a checkpoint of the audit chain heads
+  audit export      print the signed checkpoints as JSON lines for notarization
+`
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("failed to load config", "err", err)
+		os.Exit(1)
+	}
+
+	if len(cfg.Args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger, err := logging.NewLogger(cfg.LoggerConfig)
+	if err != nil {
+		slog.Error("failed to initialize logger", "err", err)
+		os.Exit(1)
+	}
+
+	database, err := db.NewDB(cfg.PostgresDSN, logger)
+	if err != nil {
+		logger.Error("failed to connect to database", "err", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	command, args := cfg.Args[0], cfg.Args[1:]
+	switch command {
+	case "interest":
+		err = runInterest(ctx, cfg, logger, database, args)
+	case "report":
+		err = runReport(ctx, cfg, logger, database, args)
+	case "close":
+		err = runClose(ctx, cfg, logger, database, args)
+	case "verify":
+		err = runVerify(ctx, logger, database, args)
+	case "rebuild":
+		err = runRebuild(ctx, logger, database, args)
+	case "reconcile":
+		err = runReconcile(ctx, cfg, logger, database, args)
+	case "audit":
+		err = runAudit(ctx, cfg, logger, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	if errors.Is(err, errDiscrepancies) {
+		stop()
+		database.Close()
+		os.Exit(3)
+	}
+	if err != nil {
+		logger.Error("command failed", "command", command, "err", err)
+		stop()
+		database.Close()
+		os.Exit(1)
+	}
+}
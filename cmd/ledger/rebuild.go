@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/rebuild"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// runRebuild handles "rebuild". It is a dry run unless -apply is given; each account whose
+// balance differs is printed on its own line, followed by a summary.
+func runRebuild(ctx context.Context, logger *logging.Logger, database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	accountID := fs.String("account", "", "account to rebuild; empty rebuilds every account")
+	apply := fs.Bool("apply", false, "correct the balances instead of only showing the differences")
+	reason := fs.String("reason", "", "explanation journalled with each correction, required with -apply")
+	batch := fs.Int("batch", 500, "number of accounts read per query")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rebuilder := rebuild.NewRebuilder(database, *batch)
+	summary, err := rebuilder.Run(ctx, rebuild.Options{AccountID: *accountID, Apply: *apply, Reason: *reason}, func(d rebuild.Diff) {
+		action := "would adjust"
+		if d.Corrected {
+			action = "adjusted"
+		}
+		fmt.Printf("account=%s currency=%s stored=%s replayed=%s entries=%d through_sequence=%d: %s by %s\n",
+			d.AccountID, d.Currency, d.Stored, d.Replayed, d.Entries, d.Through, action, d.Adjustment())
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("replayed %d accounts and %d transactions: %d differences, %d corrected\n",
+		summary.Accounts, summary.Entries, summary.Diffs, summary.Corrected)
+	logger.Info("balances rebuilt", "accounts", summary.Accounts, "transactions", summary.Entries,
+		"differences", summary.Diffs, "corrected", summary.Corrected, "apply", *apply)
+
+	if summary.Diffs > summary.Corrected {
+		return errDiscrepancies
+	}
+	return nil
+}
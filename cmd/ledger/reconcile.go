@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/reconcile"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// runReconcile handles "reconcile". Without flags it covers yesterday. Each finding is printed on
+// its own line as it is found, followed by a summary.
+func runReconcile(ctx context.Context, cfg config.Config, logger *logging.Logger, database *db.DB, args []string) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	from := fs.String("from", today.AddDate(0, 0, -1).Format(time.DateOnly), "first UTC day to reconcile (YYYY-MM-DD)")
+	to := fs.String("to", today.AddDate(0, 0, -1).Format(time.DateOnly), "last UTC day to reconcile (YYYY-MM-DD)")
+	backfill := fs.Bool("backfill", false, "create missing audit records from the ledger entries")
+	batch := fs.Int("batch", 500, "number of transactions compared per query")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	start, err := time.Parse(time.DateOnly, *from)
+	if err != nil {
+		return fmt.Errorf("reconcile: invalid from: %w", err)
+	}
+	end, err := time.Parse(time.DateOnly, *to)
+	if err != nil {
+		return fmt.Errorf("reconcile: invalid to: %w", err)
+	}
+	if end.Before(start) {
+		return fmt.Errorf("reconcile: to precedes from")
+	}
+
+	mongoDB, err := db.NewMongoDB(cfg)
+	if err != nil {
+		return fmt.Errorf("reconcile: connect to MongoDB: %w", err)
+	}
+	defer mongoDB.Close()
+
+	records := mongoDB.Client.Database("ledger").Collection("transactions")
+	if err := reconcile.EnsureIndexes(ctx, records); err != nil {
+		return err
+	}
+
+	reconciler := reconcile.NewReconciler(reconcile.NewStore(database), records, audit.NewChain(records), *batch)
+	summary, err := reconciler.Run(ctx, reconcile.Window{From: start, To: end.AddDate(0, 0, 1)}, *backfill, func(f reconcile.Finding) {
+		fmt.Println(f)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("reconciled %d ledger entries and %d audit records: %d missing, %d mismatched, %d orphaned, %d backfilled\n",
+		summary.Entries, summary.Records, summary.Missing, summary.Mismatched, summary.Orphaned, summary.Backfilled)
+	logger.Info("ledger reconciled", "entries", summary.Entries, "records", summary.Records, "missing", summary.Missing,
+		"mismatched", summary.Mismatched, "orphaned", summary.Orphaned, "backfilled", summary.Backfilled)
+
+	// Backfilled records are repaired; anything else needs a look
+	if summary.Findings() > summary.Backfilled {
+		return errDiscrepancies
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/report"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// runReport handles "report trial-balance" and "report daily-volume", writing the report to
+// standard output as CSV or JSON.
+func runReport(ctx context.Context, cfg config.Config, logger *logging.Logger, database *db.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("report: expected trial-balance or daily-volume")
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	switch args[0] {
+	case "trial-balance":
+		fs := flag.NewFlagSet("report trial-balance", flag.ExitOnError)
+		asOf := fs.String("as-of", "", "business date to total the ledger at the end of (YYYY-MM-DD); empty for now")
+		currency := fs.String("currency", "", "only accounts in this currency")
+		format := fs.String("format", report.FormatCSV, "output format, csv or json")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *format != report.FormatCSV && *format != report.FormatJSON {
+			return fmt.Errorf("report: unsupported format %q", *format)
+		}
+
+		var date *time.Time
+		if *asOf != "" {
+			day, err := time.Parse(time.DateOnly, *asOf)
+			if err != nil {
+				return fmt.Errorf("report trial-balance: invalid as-of: %w", err)
+			}
+			date = &day
+		}
+
+		tb, err := report.NewService(logger, database, nil).TrialBalance(ctx, date, strings.ToUpper(*currency))
+		if err != nil {
+			return err
+		}
+		return writeReport(tb, *format)
+
+	case "daily-volume":
+		fs := flag.NewFlagSet("report daily-volume", flag.ExitOnError)
+		from := fs.String("from", today.AddDate(0, 0, 1-report.DefaultVolumeDays).Format(time.DateOnly), "first business date (YYYY-MM-DD)")
+		to := fs.String("to", today.Format(time.DateOnly), "last business date (YYYY-MM-DD)")
+		currency := fs.String("currency", "", "only this currency")
+		format := fs.String("format", report.FormatCSV, "output format, csv or json")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *format != report.FormatCSV && *format != report.FormatJSON {
+			return fmt.Errorf("report: unsupported format %q", *format)
+		}
+
+		start, err := time.Parse(time.DateOnly, *from)
+		if err != nil {
+			return fmt.Errorf("report daily-volume: invalid from: %w", err)
+		}
+		end, err := time.Parse(time.DateOnly, *to)
+		if err != nil {
+			return fmt.Errorf("report daily-volume: invalid to: %w", err)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("report daily-volume: to precedes from")
+		}
+
+		// Failed transactions are only recorded in the audit store
+		mongoDB, err := db.NewMongoDB(cfg)
+		if err != nil {
+			return fmt.Errorf("report daily-volume: connect to MongoDB: %w", err)
+		}
+		defer mongoDB.Close()
+
+		records := mongoDB.Client.Database("ledger").Collection("transactions")
+		dv, err := report.NewService(logger, database, records).DailyVolume(ctx, start, end, strings.ToUpper(*currency))
+		if err != nil {
+			return err
+		}
+		return writeReport(dv, *format)
+
+	default:
+		return fmt.Errorf("report: unknown report %q", args[0])
+	}
+}
+
+// writeReport prints a report as CSV or indented JSON.
+func writeReport(r interface{ WriteCSV(w io.Writer) error }, format string) error {
+	if format == report.FormatCSV {
+		return r.WriteCSV(os.Stdout)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
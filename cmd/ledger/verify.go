@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/verify"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// errDiscrepancies is returned when verify completes but finds problems, so a nightly job
+// can tell a broken ledger from a failed run by the exit code.
+var errDiscrepancies = errors.New("ledger has discrepancies")
+
+// runVerify handles "verify". Each discrepancy is printed on its own line as it is found,
+// followed by a summary.
+func runVerify(ctx context.Context, logger *logging.Logger, database *db.DB, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	batch := fs.Int("batch", 500, "number of accounts checked per snapshot")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	verifier := verify.NewVerifier(database, *batch)
+	summary, err := verifier.Run(ctx, func(d verify.Discrepancy) {
+		fmt.Println(d)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("verified %d accounts and %d transactions: %d discrepancies\n",
+		summary.Accounts, summary.Transactions, summary.Discrepancies)
+	logger.Info("ledger verified", "accounts", summary.Accounts, "transactions", summary.Transactions,
+		"discrepancies", summary.Discrepancies)
+
+	if summary.Discrepancies > 0 {
+		return errDiscrepancies
+	}
+	return nil
+}
@@ -1,14 +1,28 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/mdshahjahanmiah/banking-ledger/model"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/account"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/bankstatement"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/broker"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/chart"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/customer"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/fx"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/interest"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/period"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/reconcile"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/report"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/schedule"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/statement"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/suspense"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
 	"github.com/mdshahjahanmiah/explore-go/di"
 	eHttp "github.com/mdshahjahanmiah/explore-go/http"
@@ -98,6 +112,20 @@ func main() {
 		logger.Info("After migration", "version", version, "dirty", dirty)
 
 		logger.Info("database migration completed successfully")
+
+		// Create the system accounts the chart of accounts lists
+		accounts, err := chart.Load(conf.ChartOfAccounts)
+		if err != nil {
+			logger.Error("loading chart of accounts", "err", err)
+			return nil, err
+		}
+		created, err := chart.NewStore(database).Sync(context.Background(), accounts)
+		if err != nil {
+			logger.Error("syncing chart of accounts", "err", err)
+			return nil, err
+		}
+		logger.Info("chart of accounts synced", "accounts", len(accounts.Accounts), "created", created)
+
 		return database, nil
 	})
 
@@ -105,8 +133,46 @@ func main() {
 		return db.NewMongoDB(conf)
 	})
 
-	c.Provide(func(mongo *db.MongoDB) *repository.Repository[model.Transaction] {
-		return repository.NewMongoRepository[model.Transaction](mongo.Client, "ledger", "transactions")
+	c.Provide(func(logger *logging.Logger, mongo *db.MongoDB) (*repository.Repository[model.Transaction], error) {
+		repo := repository.NewMongoRepository[model.Transaction](mongo.Client, "ledger", "transactions")
+		if err := transaction.EnsureHistoryIndexes(context.Background(), repo.Collection); err != nil {
+			logger.Error("creating transaction history indexes", "err", err)
+			return nil, err
+		}
+		if err := audit.EnsureChainIndexes(context.Background(), repo.Collection); err != nil {
+			logger.Error("creating audit chain indexes", "err", err)
+			return nil, err
+		}
+		return repo, nil
+	})
+
+	c.Provide(func(conf config.Config, mongo *db.MongoDB, repo *repository.Repository[model.Transaction]) (*audit.Checkpoints, error) {
+		checkpoints := mongo.Client.Database("ledger").Collection("audit_checkpoints")
+		return audit.LoadCheckpoints(repo.Collection, checkpoints, conf.AuditKey)
+	})
+
+	// Reconciles the ledger against the audit store in the background
+	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB, repo *repository.Repository[model.Transaction]) (di.StartCloser, error) {
+		if err := reconcile.EnsureIndexes(context.Background(), repo.Collection); err != nil {
+			logger.Error("creating reconciliation indexes", "err", err)
+			return nil, err
+		}
+		reconciler := reconcile.NewReconciler(reconcile.NewStore(db), repo.Collection, audit.NewChain(repo.Collection), 500)
+		return reconcile.NewRunner(reconciler, logger, conf.Reconcile.Interval, conf.Reconcile.Window, conf.Reconcile.Backfill), nil
+	}, dig.Group("startclose"))
+
+	// Signs audit checkpoints in the background
+	c.Provide(func(conf config.Config, logger *logging.Logger, checkpoints *audit.Checkpoints) di.StartCloser {
+		return audit.NewCheckpointRunner(checkpoints, logger, conf.AuditInterval)
+	}, dig.Group("startclose"))
+
+	c.Provide(func(conf config.Config, logger *logging.Logger, mongo *db.MongoDB) (audit.Service, error) {
+		repo := repository.NewMongoRepository[model.AuditEvent](mongo.Client, "ledger", "transaction_events")
+		if err := audit.EnsureIndexes(context.Background(), repo.Collection); err != nil {
+			logger.Error("creating audit event indexes", "err", err)
+			return nil, err
+		}
+		return audit.NewService(logger, repo, conf.Instance), nil
 	})
 
 	c.Provide(func(config config.Config) *eHttp.ServerConfig {
@@ -115,8 +181,12 @@ func main() {
 		}
 	})
 
-	c.Provide(func(config config.Config, logger *logging.Logger, db *db.DB) (account.Service, error) {
-		service := account.NewService(config, logger, db)
+	c.Provide(func(config config.Config, logger *logging.Logger, db *db.DB, repo *repository.Repository[model.Transaction]) (account.Service, error) {
+		service, err := account.NewService(config, logger, db, repo)
+		if err != nil {
+			logger.Error("initializing account service", "err", err)
+			return nil, err
+		}
 		return service, nil
 	})
 
@@ -125,8 +195,12 @@ func main() {
 		return broker.NewKafkaProducer("kafka:9092", "transactions") // Replace with your Kafka broker address
 	})
 
-	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB, repo *repository.Repository[model.Transaction], producer broker.Producer) (transaction.Service, error) {
-		service, err := transaction.NewService(conf, logger, db, repo, producer)
+	c.Provide(func(conf config.Config) (fx.RateProvider, error) {
+		return fx.LoadStaticProvider(conf.FXRates)
+	})
+
+	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB, repo *repository.Repository[model.Transaction], producer broker.Producer, rates fx.RateProvider) (transaction.Service, error) {
+		service, err := transaction.NewService(conf, logger, db, repo, producer, rates)
 		if err != nil {
 			logger.Error("initializing transaction service", "err", err)
 			return nil, err
@@ -134,12 +208,93 @@ func main() {
 		return service, nil
 	})
 
+	c.Provide(func(config config.Config, logger *logging.Logger, db *db.DB) interest.Service {
+		return interest.NewService(config, logger, db)
+	})
+
+	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB, repo *repository.Repository[model.Transaction], provider fx.RateProvider) (fx.Service, error) {
+		service, err := fx.NewService(conf, logger, db, repo, provider)
+		if err != nil {
+			logger.Error("initializing fx service", "err", err)
+			return nil, err
+		}
+		return service, nil
+	})
+
+	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB, transactions transaction.Service) schedule.Service {
+		return schedule.NewService(conf, logger, db, transactions)
+	})
+
+	// Queues due standing orders in the background
+	c.Provide(func(conf config.Config, logger *logging.Logger, service schedule.Service) di.StartCloser {
+		return schedule.NewRunner(service, logger, conf.ScheduleTick)
+	}, dig.Group("startclose"))
+
+	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB) statement.Service {
+		return statement.NewService(conf, logger, db)
+	})
+
+	c.Provide(func(logger *logging.Logger, db *db.DB) customer.Service {
+		return customer.NewService(logger, db)
+	})
+
+	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB) bankstatement.Service {
+		return bankstatement.NewService(conf, logger, db)
+	})
+
+	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB) suspense.Service {
+		return suspense.NewService(conf, logger, db)
+	})
+
+	c.Provide(func(conf config.Config, logger *logging.Logger, db *db.DB) period.Service {
+		return period.NewService(conf, logger, db)
+	})
+
+	c.Provide(func(logger *logging.Logger, db *db.DB, repo *repository.Repository[model.Transaction]) report.Service {
+		return report.NewService(logger, db, repo.Collection)
+	})
+
 	c.ProvideMonitoringEndpoints("endpoint")
 
+	// Runtime and reconciliation metrics
+	c.Provide(func() eHttp.Endpoint {
+		return eHttp.Endpoint{Pattern: "/debug/vars", Handler: expvar.Handler()}
+	}, dig.Group("endpoint"))
+
 	c.Provide(account.MakeHandler, dig.Group("endpoint"))
 
+	c.Provide(account.MakeAccountHandler, dig.Group("endpoint"))
+
+	c.Provide(account.MakeOwnersHandler, dig.Group("endpoint"))
+
+	c.Provide(customer.MakeHandler, dig.Group("endpoint"))
+
 	c.Provide(transaction.MakeHandler, dig.Group("endpoint"))
 
+	c.Provide(transaction.MakeBatchHandler, dig.Group("endpoint"))
+
+	c.Provide(transaction.MakeBatchStatusHandler, dig.Group("endpoint"))
+
+	c.Provide(audit.MakeHandler, dig.Group("endpoint"))
+
+	c.Provide(interest.MakeHandler, dig.Group("endpoint"))
+
+	c.Provide(fx.MakeHandler, dig.Group("endpoint"))
+
+	c.Provide(schedule.MakeHandler, dig.Group("endpoint"))
+
+	c.Provide(statement.MakeHandler, dig.Group("endpoint"))
+
+	c.Provide(statement.MakeBalanceHandler, dig.Group("endpoint"))
+
+	c.Provide(bankstatement.MakeHandler, dig.Group("endpoint"))
+
+	c.Provide(suspense.MakeHandler, dig.Group("endpoint"))
+
+	c.Provide(period.MakeHandler, dig.Group("endpoint"))
+
+	c.Provide(report.MakeHandler, dig.Group("endpoint"))
+
 	c.Invoke(func(in struct {
 		dig.In
 		Conf         config.Config
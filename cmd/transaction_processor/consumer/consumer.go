@@ -3,7 +3,8 @@
 // It handles the consumption of messages from the "transactions" topic,
 // delegates transaction processing to the service layer, applies retry logic,
 // and forwards failed messages to a Dead Letter Queue (DLQ). It also
-// persists all processed transactions to an audit repository for traceability.
+// persists all processed transactions to a hash-chained audit log for traceability,
+// and records every state transition of a transaction in its audit timeline.
 //
 // This package is intended to be resilient and observant, ensuring durable
 // and recoverable transaction ingestion in a distributed system.
@@ -16,28 +17,42 @@ import (
 	"time"
 
 	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
 	"github.com/mdshahjahanmiah/explore-go/logging"
-	"github.com/mdshahjahanmiah/explore-go/repository"
 	"github.com/segmentio/kafka-go"
 )
 
+// DeadLetterWriter writes the messages that exhausted their retries to the dead letter topic.
+type DeadLetterWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// AuditLog saves the final state of each processed transaction, like audit.Chain.
+type AuditLog interface {
+	Append(ctx context.Context, txn model.Transaction) error
+}
+
 type Consumer struct {
 	Logger             *logging.Logger
 	Reader             *kafka.Reader
-	DLTWriter          *kafka.Writer
+	DLTWriter          DeadLetterWriter
 	TransactionService transaction.Service
-	AuditRepo          *repository.Repository[model.Transaction]
+	Audit              AuditLog
+	Events             audit.Service
 	Config             config.Config
+	RetryBackoff       time.Duration // Wait after the first failed attempt, growing with each attempt
 }
 
-func NewConsumer(cfg config.Config, logger *logging.Logger, service transaction.Service, auditRepo *repository.Repository[model.Transaction]) *Consumer {
+func NewConsumer(cfg config.Config, logger *logging.Logger, service transaction.Service, chain *audit.Chain, events audit.Service) *Consumer {
 	return &Consumer{
 		Logger:             logger,
 		Config:             cfg,
 		TransactionService: service,
-		AuditRepo:          auditRepo,
+		Audit:              chain,
+		Events:             events,
+		RetryBackoff:       time.Second,
 		DLTWriter: &kafka.Writer{
 			Addr:     kafka.TCP(cfg.KafkaBrokerURL),
 			Topic:    "transactions-dlq",
@@ -89,7 +104,9 @@ func (c *Consumer) Start(errorChan chan error, doneChan chan struct{}) {
 }
 
 // handleMessage processes a single Kafka message. It unmarshal the payload into a Transaction model,
-// attempts to process the transaction with retries, writes to DLQ on failure, and audits the result.
+// attempts to process the transaction with retries, writes to DLQ on failure, and audits the result,
+// except for a duplicate, whose first delivery was audited. Each step is recorded as an event in the
+// transaction's audit timeline.
 func (c *Consumer) handleMessage(msg kafka.Message) {
 	var txn model.Transaction
 	if err := json.Unmarshal(msg.Value, &txn); err != nil {
@@ -97,30 +114,55 @@ func (c *Consumer) handleMessage(msg kafka.Message) {
 		return
 	}
 
+	received := model.AuditEventReceived
+	if seen, err := c.Events.Received(context.Background(), txn.ID); err != nil {
+		c.Logger.Error("Failed to check audit timeline", "id", txn.ID, "error", err)
+	} else if seen {
+		received = model.AuditEventReplayed
+	}
+	c.recordEvent(txn, received, 0, nil)
+
 	const maxRetries = 3
 	var attempt int
 	var lastErr error
 
 	for attempt = 1; attempt <= maxRetries; attempt++ {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		lastErr = c.TransactionService.ProcessTransaction(ctx, txn)
+		var processed model.Transaction
+		processed, lastErr = c.TransactionService.ProcessTransaction(ctx, txn)
 		cancel()
 
 		if lastErr == nil {
+			txn = processed
 			txn.Status = transaction.TransactionStatusCompleted
+			c.recordEvent(txn, model.AuditEventCompleted, attempt, nil)
+			if txn.SuspenseItemID != "" {
+				c.Logger.Warn("Deposit held in suspense", "id", txn.ID, "suspense_item_id", txn.SuspenseItemID)
+			}
 			break
 		}
 
-		if errors.Is(lastErr, transaction.ErrDuplicateTransaction) ||
-			errors.Is(lastErr, transaction.ErrAccountNotFound) {
+		if errors.Is(lastErr, transaction.ErrDuplicateTransaction) {
+			// The first delivery already audited the transaction, so only the timeline records this one
+			c.Logger.Warn("Duplicate transaction, skipping", "id", txn.ID, "error", lastErr)
+			c.recordEvent(txn, model.AuditEventDuplicate, attempt, lastErr)
+			return
+		}
+
+		if errors.Is(lastErr, transaction.ErrAccountNotFound) ||
+			errors.Is(lastErr, transaction.ErrTargetAccountNotFound) ||
+			errors.Is(lastErr, transaction.ErrCurrencyMismatch) ||
+			errors.Is(lastErr, transaction.ErrPeriodClosed) {
 			c.Logger.Warn("Permanent transaction failure, skipping retry", "id", txn.ID, "error", lastErr)
 			txn.Status = transaction.TransactionStatusFailed
+			c.recordEvent(txn, model.AuditEventFailed, attempt, lastErr)
 			lastErr = nil // clear error to avoid DLQ
 			break
 		}
 
 		c.Logger.Warn("Retryable transaction failure", "id", txn.ID, "attempt", attempt, "error", lastErr)
-		time.Sleep(time.Second * time.Duration(attempt)) // simple backoff
+		c.recordEvent(txn, model.AuditEventAttemptFailed, attempt, lastErr)
+		time.Sleep(c.RetryBackoff * time.Duration(attempt)) // simple backoff
 	}
 
 	if lastErr != nil && txn.Status != transaction.TransactionStatusCompleted {
@@ -137,19 +179,44 @@ func (c *Consumer) handleMessage(msg kafka.Message) {
 		})
 		if err != nil {
 			c.Logger.Error("Failed to write to DLT", "id", txn.ID, "error", err)
+			c.recordEvent(txn, model.AuditEventFailed, maxRetries, errors.Join(lastErr, err))
 		} else {
 			c.Logger.Info("Message sent to DLT", "id", txn.ID)
+			c.recordEvent(txn, model.AuditEventDeadLettered, maxRetries, lastErr)
 		}
 	}
 
 	c.Logger.Info("Transaction processed", "id", txn.ID, "status", txn.Status, "amount", txn.Amount)
-	if err := c.AuditRepo.Save(txn); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Audit.Append(ctx, txn); err != nil {
 		c.Logger.Error("Audit failed (non-critical)", "id", txn.ID, "error", err)
 	}
 
 	c.Logger.Info("Transaction processed", "id", txn.ID, "status", txn.Status, "duration", time.Since(txn.CreatedAt))
 }
 
+// recordEvent appends an event to the transaction's audit timeline. Like the audit document, the
+// timeline is not critical to processing, so failures are only logged.
+func (c *Consumer) recordEvent(txn model.Transaction, eventType model.AuditEventType, attempt int, cause error) {
+	event := model.AuditEvent{
+		TransactionID: txn.ID,
+		AccountID:     txn.AccountID,
+		Type:          eventType,
+		Attempt:       attempt,
+	}
+	if cause != nil {
+		event.Error = cause.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Events.Record(ctx, event); err != nil {
+		c.Logger.Error("Audit event failed (non-critical)", "id", txn.ID, "event", eventType, "error", err)
+	}
+}
+
 // ping verifies Kafka broker availability by checking partition metadata.
 func (c *Consumer) ping() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -0,0 +1,188 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/segmentio/kafka-go"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ledger fails the first processing attempts with errs, a nil error succeeding.
+type ledger struct {
+	transaction.Service
+	errs  []error
+	calls int
+}
+
+func (l *ledger) ProcessTransaction(_ context.Context, txn model.Transaction) (model.Transaction, error) {
+	l.calls++
+	if len(l.errs) > 0 {
+		err := l.errs[0]
+		l.errs = l.errs[1:]
+		if err != nil {
+			return model.Transaction{}, err
+		}
+	}
+	return txn, nil
+}
+
+type timeline struct {
+	audit.Service
+	seen   bool
+	events []model.AuditEvent
+}
+
+func (t *timeline) Record(_ context.Context, event model.AuditEvent) error {
+	t.events = append(t.events, event)
+	return nil
+}
+
+func (t *timeline) Received(context.Context, string) (bool, error) {
+	return t.seen, nil
+}
+
+type deadLetters struct {
+	err  error
+	msgs []kafka.Message
+}
+
+func (d *deadLetters) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.msgs = append(d.msgs, msgs...)
+	return nil
+}
+
+type auditLog struct {
+	txns []model.Transaction
+}
+
+func (a *auditLog) Append(_ context.Context, txn model.Transaction) error {
+	a.txns = append(a.txns, txn)
+	return nil
+}
+
+// step is an event of the timeline with the attempt it belongs to.
+type step struct {
+	Type    model.AuditEventType
+	Attempt int
+}
+
+func TestConsumer_HandleMessage(t *testing.T) {
+	unavailable := errors.New("database unavailable")
+
+	tests := []struct {
+		name       string
+		seen       bool
+		errs       []error
+		dltErr     error
+		want       []step
+		status     string // Status of the audit record, none when empty
+		deadLetter bool
+	}{
+		{
+			name:   "completed",
+			want:   []step{{model.AuditEventReceived, 0}, {model.AuditEventCompleted, 1}},
+			status: transaction.TransactionStatusCompleted,
+		},
+		{
+			name:   "replayed",
+			seen:   true,
+			want:   []step{{model.AuditEventReplayed, 0}, {model.AuditEventCompleted, 1}},
+			status: transaction.TransactionStatusCompleted,
+		},
+		{
+			name:   "completed on retry",
+			errs:   []error{unavailable, nil},
+			want:   []step{{model.AuditEventReceived, 0}, {model.AuditEventAttemptFailed, 1}, {model.AuditEventCompleted, 2}},
+			status: transaction.TransactionStatusCompleted,
+		},
+		{
+			name:   "permanent failure",
+			errs:   []error{transaction.ErrAccountNotFound},
+			want:   []step{{model.AuditEventReceived, 0}, {model.AuditEventFailed, 1}},
+			status: transaction.TransactionStatusFailed,
+		},
+		{
+			name: "duplicate",
+			seen: true,
+			errs: []error{transaction.ErrDuplicateTransaction},
+			want: []step{{model.AuditEventReplayed, 0}, {model.AuditEventDuplicate, 1}},
+		},
+		{
+			name: "dead lettered",
+			errs: []error{unavailable, unavailable, unavailable},
+			want: []step{{model.AuditEventReceived, 0}, {model.AuditEventAttemptFailed, 1}, {model.AuditEventAttemptFailed, 2},
+				{model.AuditEventAttemptFailed, 3}, {model.AuditEventDeadLettered, 3}},
+			status:     transaction.TransactionStatusFailed,
+			deadLetter: true,
+		},
+		{
+			name:   "dead letter write failed",
+			errs:   []error{unavailable, unavailable, unavailable},
+			dltErr: errors.New("broker unavailable"),
+			want: []step{{model.AuditEventReceived, 0}, {model.AuditEventAttemptFailed, 1}, {model.AuditEventAttemptFailed, 2},
+				{model.AuditEventAttemptFailed, 3}, {model.AuditEventFailed, 3}},
+			status: transaction.TransactionStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &timeline{seen: tt.seen}
+			dlt := &deadLetters{err: tt.dltErr}
+			records := &auditLog{}
+			c := &Consumer{
+				Logger:             &logging.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+				DLTWriter:          dlt,
+				TransactionService: &ledger{errs: tt.errs},
+				Audit:              records,
+				Events:             events,
+			}
+
+			txn := model.Transaction{ID: "txn1", AccountID: "acc1", Type: transaction.TransactionTypeDeposit,
+				Amount: model.Decimal{Decimal: decimal.NewFromInt(25)}, Currency: "USD", ReferenceID: "ref1"}
+			value, err := json.Marshal(txn)
+			require.NoError(t, err)
+
+			c.handleMessage(kafka.Message{Value: value})
+
+			var got []step
+			for _, e := range events.events {
+				assert.Equal(t, "txn1", e.TransactionID)
+				assert.Equal(t, "acc1", e.AccountID)
+				if e.Type == model.AuditEventAttemptFailed || e.Type == model.AuditEventDeadLettered {
+					assert.Equal(t, unavailable.Error(), e.Error)
+				}
+				got = append(got, step{e.Type, e.Attempt})
+			}
+			assert.Equal(t, tt.want, got)
+
+			if tt.status == "" {
+				assert.Empty(t, records.txns)
+			} else {
+				require.Len(t, records.txns, 1)
+				assert.Equal(t, tt.status, records.txns[0].Status)
+			}
+
+			if tt.deadLetter {
+				require.Len(t, dlt.msgs, 1)
+				assert.Equal(t, "txn1", string(dlt.msgs[0].Key))
+			} else {
+				assert.Empty(t, dlt.msgs)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"github.com/mdshahjahanmiah/banking-ledger/cmd/transaction_processor/consumer"
 	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/broker"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/fx"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
 	"github.com/mdshahjahanmiah/explore-go/logging"
 	"github.com/mdshahjahanmiah/explore-go/repository"
@@ -53,15 +56,36 @@ func main() {
 		return
 	}
 
+	// Rates for accounts that convert transactions in other currencies
+	rates, err := fx.LoadStaticProvider(cfg.FXRates)
+	if err != nil {
+		logger.Error("failed to load exchange rates", "err", err)
+		return
+	}
+
 	// Prepare transaction store
 	auditRepo := repository.NewMongoRepository[model.Transaction](mongoDB.Client, "ledger", "transactions")
-	txnService, err := transaction.NewService(cfg, logger, database, auditRepo, producer)
+	txnService, err := transaction.NewService(cfg, logger, database, auditRepo, producer, rates)
 	if err != nil {
 		logger.Error("failed to initialize service", "err", err)
 		return
 	}
 
-	processor := consumer.NewConsumer(cfg, logger, txnService, auditRepo)
+	// Timeline of every transaction's state transitions
+	eventRepo := repository.NewMongoRepository[model.AuditEvent](mongoDB.Client, "ledger", "transaction_events")
+	if err := audit.EnsureIndexes(context.Background(), eventRepo.Collection); err != nil {
+		logger.Error("failed to create audit event indexes", "err", err)
+		return
+	}
+	events := audit.NewService(logger, eventRepo, cfg.Instance)
+
+	// Audit records are hash-chained per account
+	if err := audit.EnsureChainIndexes(context.Background(), auditRepo.Collection); err != nil {
+		logger.Error("failed to create audit chain indexes", "err", err)
+		return
+	}
+
+	processor := consumer.NewConsumer(cfg, logger, txnService, audit.NewChain(auditRepo.Collection), events)
 
 	errorChan := make(chan error)
 	doneChan := make(chan struct{})
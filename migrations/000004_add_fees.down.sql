@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_transactions_parent_id;
+
+DELETE FROM transactions WHERE type = 'fee';
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_type_check;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_type_check CHECK (type IN ('deposit', 'withdrawal'));
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS parent_id;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS tier;
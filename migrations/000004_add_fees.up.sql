@@ -0,0 +1,13 @@
+ALTER TABLE accounts
+    ADD COLUMN tier VARCHAR(50) NOT NULL DEFAULT 'standard';
+
+ALTER TABLE transactions
+    ADD COLUMN parent_id UUID REFERENCES transactions(id);
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_type_check;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_type_check CHECK (type IN ('deposit', 'withdrawal', 'fee'));
+
+CREATE INDEX idx_transactions_parent_id ON transactions (parent_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS interest_accruals;
+DROP TABLE IF EXISTS account_interest_plans;
+DROP TABLE IF EXISTS interest_rate_plans;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS interest_rate_plans (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    currency VARCHAR(3) NOT NULL,
+    annual_rate NUMERIC NOT NULL CHECK (annual_rate >= 0),
+    day_count INTEGER NOT NULL CHECK (day_count IN (360, 365)),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS account_interest_plans (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    plan_id UUID NOT NULL REFERENCES interest_rate_plans(id),
+    assigned_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    accrual_date DATE NOT NULL,
+    plan_id UUID NOT NULL REFERENCES interest_rate_plans(id),
+    balance NUMERIC NOT NULL,
+    rate NUMERIC NOT NULL,
+    amount NUMERIC NOT NULL,
+    posted_transaction_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, accrual_date)
+    );
+
+CREATE INDEX idx_interest_accruals_unposted ON interest_accruals (account_id) WHERE posted_transaction_id IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS fx_conversions;
+DROP TABLE IF EXISTS fx_quotes;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id UUID PRIMARY KEY,
+    from_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    mid_rate NUMERIC NOT NULL CHECK (mid_rate > 0),
+    spread NUMERIC NOT NULL CHECK (spread >= 0),
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    sell_amount NUMERIC NOT NULL CHECK (sell_amount > 0),
+    buy_amount NUMERIC NOT NULL CHECK (buy_amount > 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('open', 'executed')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS fx_conversions (
+    id UUID PRIMARY KEY,
+    quote_id UUID NOT NULL UNIQUE REFERENCES fx_quotes(id),
+    from_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    debit_transaction_id UUID NOT NULL REFERENCES transactions(id),
+    credit_transaction_id UUID NOT NULL REFERENCES transactions(id),
+    rate NUMERIC NOT NULL,
+    spread NUMERIC NOT NULL,
+    from_amount NUMERIC NOT NULL,
+    from_currency VARCHAR(3) NOT NULL,
+    to_amount NUMERIC NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX idx_fx_conversions_from_account_id ON fx_conversions (from_account_id);
+CREATE INDEX idx_fx_conversions_to_account_id ON fx_conversions (to_account_id);
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS schedule_executions;
+DROP TABLE IF EXISTS schedules;
+
+DELETE FROM transactions WHERE parent_id IN (SELECT id FROM transactions WHERE type = 'transfer');
+DELETE FROM transactions WHERE type = 'transfer';
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_type_check;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_type_check CHECK (type IN ('deposit', 'withdrawal', 'fee'));
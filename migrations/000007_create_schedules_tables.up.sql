@@ -0,0 +1,36 @@
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_type_check;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_type_check CHECK (type IN ('deposit', 'withdrawal', 'fee', 'transfer'));
+
+CREATE TABLE IF NOT EXISTS schedules (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    target_account_id UUID REFERENCES accounts(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('withdrawal', 'transfer')),
+    amount NUMERIC NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    cadence VARCHAR(20) NOT NULL CHECK (cadence IN ('once', 'weekly', 'monthly', 'end_of_month')),
+    day_of_month INTEGER CHECK (day_of_month BETWEEN 1 AND 31),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    next_run_date DATE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'paused', 'cancelled', 'completed')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX idx_schedules_due ON schedules (next_run_date) WHERE status = 'active';
+CREATE INDEX idx_schedules_account_id ON schedules (account_id);
+
+CREATE TABLE IF NOT EXISTS schedule_executions (
+    schedule_id UUID NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    occurrence_date DATE NOT NULL,
+    transaction_id UUID,
+    reference_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'failed')),
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (schedule_id, occurrence_date)
+    );
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS transaction_batches (
+    id UUID PRIMARY KEY,
+    item_count INTEGER NOT NULL CHECK (item_count > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_account_sequence;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS balance_after,
+    DROP COLUMN IF EXISTS sequence;
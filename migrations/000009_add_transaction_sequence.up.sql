@@ -0,0 +1,25 @@
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS sequence BIGINT,
+    ADD COLUMN IF NOT EXISTS balance_after NUMERIC;
+
+-- Number existing rows per account in created_at order and derive each row's resulting balance
+-- from the current balance less the movements that followed it
+WITH ordered AS (
+    SELECT t.id,
+           ROW_NUMBER() OVER (PARTITION BY t.account_id ORDER BY t.created_at, t.id) AS sequence,
+           a.balance - COALESCE(SUM(CASE WHEN t.type IN ('deposit') THEN t.amount ELSE -t.amount END)
+               OVER (PARTITION BY t.account_id ORDER BY t.created_at DESC, t.id DESC
+                     ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING), 0) AS balance_after
+    FROM transactions t
+    JOIN accounts a ON a.id = t.account_id
+)
+UPDATE transactions t
+SET sequence = o.sequence, balance_after = o.balance_after
+FROM ordered o
+WHERE o.id = t.id;
+
+ALTER TABLE transactions
+    ALTER COLUMN sequence SET NOT NULL,
+    ALTER COLUMN balance_after SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_account_sequence ON transactions (account_id, sequence);
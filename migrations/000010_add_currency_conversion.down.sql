@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_transactions_reference_original_currency;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS fx_rate,
+    DROP COLUMN IF EXISTS original_currency,
+    DROP COLUMN IF EXISTS original_amount;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS auto_convert;
@@ -0,0 +1,10 @@
+ALTER TABLE accounts
+    ADD COLUMN auto_convert BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE transactions
+    ADD COLUMN original_amount NUMERIC,
+    ADD COLUMN original_currency VARCHAR(3),
+    ADD COLUMN fx_rate NUMERIC;
+
+CREATE INDEX idx_transactions_reference_original_currency ON transactions (reference_id, original_currency)
+    WHERE original_currency IS NOT NULL;
@@ -0,0 +1,8 @@
+DELETE FROM transactions WHERE parent_id IN (SELECT id FROM transactions WHERE type = 'opening_balance');
+DELETE FROM transactions WHERE type = 'opening_balance';
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_type_check;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_type_check CHECK (type IN ('deposit', 'withdrawal', 'fee', 'transfer'));
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_accounts_metadata;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS nickname;
@@ -0,0 +1,5 @@
+ALTER TABLE accounts
+    ADD COLUMN nickname VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_accounts_metadata ON accounts USING GIN (metadata jsonb_path_ops);
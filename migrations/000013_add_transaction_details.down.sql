@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transactions_counterparty_account;
+DROP INDEX IF EXISTS idx_transactions_metadata;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS counterparty_account,
+    DROP COLUMN IF EXISTS counterparty_name,
+    DROP COLUMN IF EXISTS description;
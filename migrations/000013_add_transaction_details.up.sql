@@ -0,0 +1,8 @@
+ALTER TABLE transactions
+    ADD COLUMN description VARCHAR(140) NOT NULL DEFAULT '',
+    ADD COLUMN counterparty_name VARCHAR(140) NOT NULL DEFAULT '',
+    ADD COLUMN counterparty_account VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_transactions_metadata ON transactions USING GIN (metadata jsonb_path_ops);
+CREATE INDEX idx_transactions_counterparty_account ON transactions (counterparty_account) WHERE counterparty_account <> '';
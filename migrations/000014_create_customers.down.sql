@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS account_owners;
+
+DROP INDEX IF EXISTS idx_accounts_customer_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS customer_id;
+
+DROP TABLE IF EXISTS customers;
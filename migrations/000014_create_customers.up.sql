@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('individual', 'business')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'blocked', 'closed')),
+    kyc_level VARCHAR(20) NOT NULL DEFAULT 'none' CHECK (kyc_level IN ('none', 'basic', 'full')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_customers_status ON customers (status);
+
+-- Existing accounts get one customer per user_id, with an id derived from it
+INSERT INTO customers (id, name, type, status)
+SELECT DISTINCT md5('customer:' || user_id)::uuid, user_id, 'individual', 'active' FROM accounts;
+
+ALTER TABLE accounts ADD COLUMN customer_id UUID REFERENCES customers(id);
+UPDATE accounts SET customer_id = md5('customer:' || user_id)::uuid;
+ALTER TABLE accounts ALTER COLUMN customer_id SET NOT NULL;
+
+CREATE INDEX idx_accounts_customer_id ON accounts (customer_id);
+
+CREATE TABLE IF NOT EXISTS account_owners (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    role VARCHAR(20) NOT NULL CHECK (role IN ('primary', 'joint', 'authorized')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, customer_id)
+);
+
+CREATE UNIQUE INDEX idx_account_owners_primary ON account_owners (account_id) WHERE role = 'primary';
+CREATE INDEX idx_account_owners_customer_id ON account_owners (customer_id);
+
+INSERT INTO account_owners (account_id, customer_id, role)
+SELECT id, customer_id, 'primary' FROM accounts;
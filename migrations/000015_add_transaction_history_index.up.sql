@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_transactions_account_created_at ON transactions (account_id, created_at);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS balance_corrections (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    previous_balance NUMERIC NOT NULL,
+    corrected_balance NUMERIC NOT NULL,
+    adjustment NUMERIC NOT NULL,
+    through_sequence BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_balance_corrections_account_id ON balance_corrections (account_id, created_at);
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS bank_statements (
+    id UUID PRIMARY KEY,
+    format VARCHAR(16) NOT NULL,
+    reference VARCHAR(64) NOT NULL DEFAULT '',
+    account VARCHAR(64) NOT NULL DEFAULT '',
+    currency VARCHAR(3) NOT NULL DEFAULT '',
+    opening_balance NUMERIC,
+    closing_balance NUMERIC,
+    digest VARCHAR(64) NOT NULL UNIQUE,
+    imported_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The bank's statement identifier is unique per account, whatever the file looked like
+CREATE UNIQUE INDEX idx_bank_statements_reference ON bank_statements (format, account, reference) WHERE reference <> '';
+
+CREATE TABLE IF NOT EXISTS bank_statement_lines (
+    id UUID PRIMARY KEY,
+    statement_id UUID NOT NULL REFERENCES bank_statements(id) ON DELETE CASCADE,
+    line_number INT NOT NULL,
+    booking_date DATE NOT NULL,
+    value_date DATE NOT NULL,
+    amount NUMERIC NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reference VARCHAR(140) NOT NULL DEFAULT '',
+    bank_reference VARCHAR(140) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    counterparty_name VARCHAR(140) NOT NULL DEFAULT '',
+    counterparty_account VARCHAR(64) NOT NULL DEFAULT '',
+    transaction_id UUID REFERENCES transactions(id),
+    match_method VARCHAR(16),
+    matched_at TIMESTAMP,
+    UNIQUE (statement_id, line_number)
+);
+
+-- A ledger transaction settles against at most one statement line
+CREATE UNIQUE INDEX idx_bank_statement_lines_transaction_id ON bank_statement_lines (transaction_id) WHERE transaction_id IS NOT NULL;
+CREATE INDEX idx_bank_statement_lines_unmatched ON bank_statement_lines (booking_date, id) WHERE transaction_id IS NULL;
+
+-- Completed deposits and withdrawals looked up as match candidates for statement lines
+CREATE INDEX idx_transactions_settlement ON transactions (currency, amount, created_at)
+    WHERE parent_id IS NULL AND status = 'completed' AND type IN ('deposit', 'withdrawal');
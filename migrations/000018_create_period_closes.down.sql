@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS period_closing_balances;
+DROP TABLE IF EXISTS period_closes;
+DROP TABLE IF EXISTS ledger_period;
+
+DROP INDEX IF EXISTS idx_transactions_value_date;
+ALTER TABLE transactions DROP COLUMN IF EXISTS value_date;
@@ -0,0 +1,27 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS value_date DATE;
+UPDATE transactions SET value_date = created_at::date WHERE value_date IS NULL;
+ALTER TABLE transactions ALTER COLUMN value_date SET NOT NULL;
+
+CREATE INDEX idx_transactions_value_date ON transactions (account_id, value_date);
+
+-- Single row holding the last closed business date. Postings lock it FOR SHARE and a close
+-- FOR UPDATE, so a close waits for the postings in flight and blocks new ones until it commits.
+CREATE TABLE IF NOT EXISTS ledger_period (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    closed_through DATE
+);
+
+INSERT INTO ledger_period (id) VALUES (TRUE) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS period_closes (
+    close_date DATE PRIMARY KEY,
+    closed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS period_closing_balances (
+    close_date DATE NOT NULL REFERENCES period_closes(close_date) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    balance NUMERIC NOT NULL,
+    PRIMARY KEY (close_date, account_id)
+);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_accounts_kind;
+DROP INDEX IF EXISTS idx_accounts_system_role;
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_balance_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_balance_check CHECK (balance >= 0);
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_kind_check;
+ALTER TABLE accounts ALTER COLUMN customer_id SET NOT NULL;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS system_role,
+    DROP COLUMN IF EXISTS type,
+    DROP COLUMN IF EXISTS kind;
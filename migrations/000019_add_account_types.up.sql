@@ -0,0 +1,18 @@
+ALTER TABLE accounts
+    ADD COLUMN kind VARCHAR(10) NOT NULL DEFAULT 'customer' CHECK (kind IN ('customer', 'system')),
+    ADD COLUMN type VARCHAR(10) NOT NULL DEFAULT 'liability' CHECK (type IN ('asset', 'liability', 'revenue', 'expense', 'equity')),
+    ADD COLUMN system_role VARCHAR(20) CHECK (system_role IN ('fee_revenue', 'suspense', 'settlement', 'fx_position'));
+
+-- System accounts have a role and no owners, customer accounts the reverse
+ALTER TABLE accounts ALTER COLUMN customer_id DROP NOT NULL;
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_kind_check CHECK (
+        (kind = 'customer' AND customer_id IS NOT NULL AND system_role IS NULL)
+        OR (kind = 'system' AND customer_id IS NULL AND system_role IS NOT NULL));
+
+-- Debit-normal accounts carry negative balances
+ALTER TABLE accounts DROP CONSTRAINT accounts_balance_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_balance_check CHECK (balance >= 0 OR type IN ('asset', 'expense'));
+
+CREATE UNIQUE INDEX idx_accounts_system_role ON accounts (system_role, currency) WHERE system_role IS NOT NULL;
+CREATE INDEX idx_accounts_kind ON accounts (kind);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS suspense_items;
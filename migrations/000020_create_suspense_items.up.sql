@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS suspense_items (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL UNIQUE REFERENCES transactions(id),
+    suspense_account_id UUID NOT NULL REFERENCES accounts(id),
+    intended_account_id UUID NOT NULL, -- May not exist, so no foreign key
+    amount NUMERIC NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('account_not_found', 'account_closed')),
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'rerouted', 'returned')),
+    resolved_account_id UUID REFERENCES accounts(id),
+    resolution_transaction_id UUID REFERENCES transactions(id),
+    note VARCHAR(140) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX idx_suspense_items_status ON suspense_items (status, created_at, id);
+CREATE INDEX idx_suspense_items_intended_account_id ON suspense_items (intended_account_id);
@@ -0,0 +1,7 @@
+UPDATE transactions SET type = 'deposit' WHERE type = 'interest';
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_type_check;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_type_check CHECK (type IN ('deposit', 'withdrawal', 'fee', 'transfer', 'opening_balance'));
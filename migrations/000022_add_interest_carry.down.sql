@@ -0,0 +1,10 @@
+DELETE FROM interest_accruals WHERE kind = 'carry';
+
+ALTER TABLE interest_accruals
+    DROP CONSTRAINT IF EXISTS interest_accruals_pkey;
+
+ALTER TABLE interest_accruals
+    ADD PRIMARY KEY (account_id, accrual_date);
+
+ALTER TABLE interest_accruals
+    DROP COLUMN kind;
@@ -0,0 +1,9 @@
+-- Carry rows hold the rounding remainder of a monthly posting, dated the first day of the next month
+ALTER TABLE interest_accruals
+    ADD COLUMN kind VARCHAR(10) NOT NULL DEFAULT 'daily' CHECK (kind IN ('daily', 'carry'));
+
+ALTER TABLE interest_accruals
+    DROP CONSTRAINT IF EXISTS interest_accruals_pkey;
+
+ALTER TABLE interest_accruals
+    ADD PRIMARY KEY (account_id, accrual_date, kind);
@@ -3,7 +3,9 @@ package model
 import (
 	"fmt"
 	"github.com/shopspring/decimal"
+	"regexp"
 	"time"
+	"unicode/utf8"
 )
 
 type AccountStatus string
@@ -14,14 +16,90 @@ const (
 	AccountStatusClosed    AccountStatus = "closed"
 )
 
+// AccountKind tells customer accounts from the bank's own system accounts.
+type AccountKind string
+
+const (
+	AccountKindCustomer AccountKind = "customer"
+	AccountKindSystem   AccountKind = "system"
+)
+
+// AccountType is the account's class in the chart of accounts. Customer accounts are
+// liabilities of the bank.
+type AccountType string
+
+const (
+	AccountTypeAsset     AccountType = "asset"
+	AccountTypeLiability AccountType = "liability"
+	AccountTypeRevenue   AccountType = "revenue"
+	AccountTypeExpense   AccountType = "expense"
+	AccountTypeEquity    AccountType = "equity"
+)
+
+func (t AccountType) IsValid() bool {
+	switch t {
+	case AccountTypeAsset, AccountTypeLiability, AccountTypeRevenue, AccountTypeExpense, AccountTypeEquity:
+		return true
+	}
+	return false
+}
+
+// AllowsNegative reports whether the balance can go below zero. Balances are credits less
+// debits, so debit-normal accounts, assets and expenses, normally carry a negative one.
+func (t AccountType) AllowsNegative() bool {
+	return t == AccountTypeAsset || t == AccountTypeExpense
+}
+
+// SystemRole is the purpose of a system account. The chart of accounts has at most one
+// account per role and currency.
+type SystemRole string
+
+const (
+	SystemRoleFeeRevenue SystemRole = "fee_revenue" // Collects fees charged to customer accounts
+	SystemRoleSuspense   SystemRole = "suspense"    // Holds funds that can't be booked to their account yet
+	SystemRoleSettlement SystemRole = "settlement"  // The bank's funds at its settlement bank, funding opening balances
+	SystemRoleFXPosition SystemRole = "fx_position" // The bank's open position from currency conversions
+)
+
+func (r SystemRole) IsValid() bool {
+	switch r {
+	case SystemRoleFeeRevenue, SystemRoleSuspense, SystemRoleSettlement, SystemRoleFXPosition:
+		return true
+	}
+	return false
+}
+
+// DefaultType is the account type of the role's accounts unless the chart says otherwise.
+func (r SystemRole) DefaultType() AccountType {
+	switch r {
+	case SystemRoleFeeRevenue:
+		return AccountTypeRevenue
+	case SystemRoleSettlement, SystemRoleFXPosition:
+		return AccountTypeAsset
+	}
+	return AccountTypeLiability
+}
+
+// AccountTierStandard is the pricing tier assigned to accounts that don't request another one.
+const AccountTierStandard = "standard"
+
 type Account struct {
-	ID        string          `json:"id"`
-	UserID    string          `json:"user_id"`
-	Balance   decimal.Decimal `json:"balance"`  // Using decimal for precise monetary values
-	Currency  string          `json:"currency"` // ISO 4217 currency code
-	Status    AccountStatus   `json:"status"`   // Enumerated type for safety
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	ID          string            `json:"id"`
+	UserID      string            `json:"user_id"`
+	CustomerID  string            `json:"customer_id,omitempty"` // Primary owner, empty on system accounts
+	Kind        AccountKind       `json:"kind"`
+	Type        AccountType       `json:"type"`
+	SystemRole  SystemRole        `json:"system_role,omitempty"` // Set on system accounts only
+	Balance     decimal.Decimal   `json:"balance"`               // Using decimal for precise monetary values
+	Currency    string            `json:"currency"`              // ISO 4217 currency code
+	Status      AccountStatus     `json:"status"`                // Enumerated type for safety
+	Tier        string            `json:"tier"`                  // Pricing tier used by the fee schedule
+	AutoConvert bool              `json:"auto_convert"`          // Deposits and withdrawals in other currencies are converted at the mid rate
+	Nickname    string            `json:"nickname,omitempty"`    // Display name chosen by the customer
+	Metadata    map[string]string `json:"metadata,omitempty"`    // Free-form labels such as purpose or product code
+	Owners      []AccountOwner    `json:"owners,omitempty"`      // Every customer owning the account, the primary one first
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
 func (a *Account) Validate() error {
@@ -35,9 +113,103 @@ func (a *Account) Validate() error {
 		return fmt.Errorf("currency must be 3-letter ISO code")
 	}
 
-	if a.Balance.IsNegative() {
-		return fmt.Errorf("account balance cannot be negative")
+	if !a.Type.IsValid() {
+		return fmt.Errorf("invalid account type: %s", a.Type)
+	}
+
+	if a.Balance.IsNegative() && !a.Type.AllowsNegative() {
+		return fmt.Errorf("%s account balance cannot be negative", a.Type)
+	}
+
+	if err := ValidateNickname(a.Nickname); err != nil {
+		return err
+	}
+
+	if err := ValidateMetadata(a.Metadata); err != nil {
+		return err
+	}
+
+	switch a.Kind {
+	case AccountKindCustomer:
+		if a.SystemRole != "" {
+			return fmt.Errorf("customer accounts have no system role")
+		}
+		return a.ValidateOwners()
+	case AccountKindSystem:
+		if !a.SystemRole.IsValid() {
+			return fmt.Errorf("invalid system role: %s", a.SystemRole)
+		}
+		if a.CustomerID != "" || len(a.Owners) > 0 {
+			return fmt.Errorf("system accounts have no owners")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid account kind: %s", a.Kind)
+	}
+}
+
+// ValidateOwners checks that the customer is the single primary owner and that no customer is
+// listed twice.
+func (a *Account) ValidateOwners() error {
+	if a.CustomerID == "" {
+		return fmt.Errorf("customer_id is required")
 	}
 
+	seen := make(map[string]bool, len(a.Owners))
+	primary := 0
+	for _, o := range a.Owners {
+		if !o.Role.IsValid() {
+			return fmt.Errorf("invalid owner role: %s", o.Role)
+		}
+		if seen[o.CustomerID] {
+			return fmt.Errorf("customer %s is listed as an owner more than once", o.CustomerID)
+		}
+		seen[o.CustomerID] = true
+
+		if o.Role == OwnerRolePrimary {
+			primary++
+			if o.CustomerID != a.CustomerID {
+				return fmt.Errorf("primary owner must be the account's customer")
+			}
+		}
+	}
+
+	if primary != 1 {
+		return fmt.Errorf("account must have exactly one primary owner")
+	}
+	return nil
+}
+
+// Limits on account nicknames and metadata.
+const (
+	MaxNicknameLength      = 64
+	MaxMetadataKeys        = 50
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+)
+
+var metadataKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+func ValidateNickname(nickname string) error {
+	if utf8.RuneCountInString(nickname) > MaxNicknameLength {
+		return fmt.Errorf("nickname must be at most %d characters", MaxNicknameLength)
+	}
+	return nil
+}
+
+// ValidateMetadata checks the number of keys, that keys are short identifiers and that values are not too long.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataKeys {
+		return fmt.Errorf("metadata must have at most %d keys", MaxMetadataKeys)
+	}
+
+	for key, value := range metadata {
+		if len(key) > MaxMetadataKeyLength || !metadataKeyPattern.MatchString(key) {
+			return fmt.Errorf("metadata key %q must be 1 to %d letters, digits, '_', '.' or '-'", key, MaxMetadataKeyLength)
+		}
+		if utf8.RuneCountInString(value) > MaxMetadataValueLength {
+			return fmt.Errorf("metadata value for %q must be at most %d characters", key, MaxMetadataValueLength)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+type AuditEventType string
+
+// Audit event types, in the order a transaction's timeline can record them.
+const (
+	AuditEventReceived      AuditEventType = "received"       // First delivery of the transaction to a processor
+	AuditEventReplayed      AuditEventType = "replayed"       // Later delivery of a transaction that was already received
+	AuditEventAttemptFailed AuditEventType = "attempt_failed" // Retryable failure; another attempt follows unless retries are exhausted
+	AuditEventCompleted     AuditEventType = "completed"
+	AuditEventFailed        AuditEventType = "failed"        // Permanent failure, not retried
+	AuditEventDuplicate     AuditEventType = "duplicate"     // Rejected because its reference was already processed; its audit record stands
+	AuditEventDeadLettered  AuditEventType = "dead_lettered" // Retries exhausted and the message was written to the dead letter topic
+)
+
+// AuditEvent is one state transition of a transaction in the processor. Events are only ever
+// appended, so a transaction's events form its timeline.
+type AuditEvent struct {
+	TransactionID string         `json:"transaction_id" bson:"transactionid"`
+	AccountID     string         `json:"account_id" bson:"accountid"`
+	Type          AuditEventType `json:"type" bson:"type"`
+	Attempt       int            `json:"attempt,omitempty" bson:"attempt,omitempty"` // Processing attempt the event belongs to, from 1
+	Error         string         `json:"error,omitempty" bson:"error,omitempty"`
+	Instance      string         `json:"instance" bson:"instance"` // Processor instance that recorded the event
+	OccurredAt    time.Time      `json:"occurred_at" bson:"occurredat"`
+}
+
+// ChainLink places an audit record in its account's hash chain. Hash covers the record with Hash
+// left empty, so it covers Seq and PrevHash too; PrevHash is empty on the first record.
+type ChainLink struct {
+	Seq      int64  `json:"seq" bson:"seq"`
+	PrevHash string `json:"prev_hash" bson:"prevhash"`
+	Hash     string `json:"hash" bson:"hash"`
+	Version  int    `json:"version,omitempty" bson:"version,omitempty"` // Field set the hash covers, 0 for records chained before versioning
+}
+
+// ChainHead is the last record of an account's audit chain.
+type ChainHead struct {
+	AccountID string `json:"account_id" bson:"accountid"`
+	Seq       int64  `json:"seq" bson:"seq"`
+	Hash      string `json:"hash" bson:"hash"`
+}
+
+// AuditCheckpoint is a signed snapshot of every audit chain's head. Root is the hash of the
+// heads, and Signature the ed25519 signature of the creation time and Root by PublicKey.
+type AuditCheckpoint struct {
+	ID        string      `json:"id" bson:"id"`
+	CreatedAt time.Time   `json:"created_at" bson:"createdat"`
+	Heads     []ChainHead `json:"heads" bson:"heads"`
+	Root      string      `json:"root" bson:"root"`
+	PublicKey string      `json:"public_key" bson:"publickey"` // Base64
+	Signature string      `json:"signature" bson:"signature"`  // Base64
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Balance is an account's balance at an instant. Sequence is the last entry included,
+// 0 when the balance predates the account's first entry.
+type Balance struct {
+	AccountID string          `json:"account_id"`
+	Currency  string          `json:"currency"`
+	AsOf      time.Time       `json:"as_of"`
+	Balance   decimal.Decimal `json:"balance"`
+	Sequence  int64           `json:"sequence"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Ways a bank statement line was matched to a ledger transaction.
+const (
+	MatchReference = "reference" // Same amount, with the bank reference naming the transaction
+	MatchAmount    = "amount"    // The only transaction with the amount within the date tolerance
+	MatchManual    = "manual"
+)
+
+// BankStatement is a statement received from the settlement bank. Balances are only known for
+// formats that carry them.
+type BankStatement struct {
+	ID             string              `json:"id"`
+	Format         string              `json:"format"`
+	Reference      string              `json:"reference,omitempty"` // The bank's identifier of the statement
+	Account        string              `json:"account,omitempty"`   // Bank account the statement is for
+	Currency       string              `json:"currency,omitempty"`
+	OpeningBalance decimal.NullDecimal `json:"opening_balance"`
+	ClosingBalance decimal.NullDecimal `json:"closing_balance"`
+	Digest         string              `json:"digest"` // SHA-256 of the imported file
+	ImportedAt     time.Time           `json:"imported_at"`
+	Lines          []BankStatementLine `json:"lines,omitempty"`
+}
+
+// BankStatementLine is one booking on a bank statement. Amount is signed: credits to the bank
+// account are positive, debits negative.
+type BankStatementLine struct {
+	ID            string          `json:"id"`
+	StatementID   string          `json:"statement_id"`
+	LineNumber    int             `json:"line_number"`
+	BookingDate   time.Time       `json:"booking_date"`
+	ValueDate     time.Time       `json:"value_date"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	Reference     string          `json:"reference,omitempty"`      // End-to-end or customer reference
+	BankReference string          `json:"bank_reference,omitempty"` // The bank's own reference
+	Description   string          `json:"description,omitempty"`
+	Counterparty  *Counterparty   `json:"counterparty,omitempty"`
+	TransactionID string          `json:"transaction_id,omitempty"` // Ledger transaction the line is matched to
+	MatchMethod   string          `json:"match_method,omitempty"`
+	MatchedAt     *time.Time      `json:"matched_at,omitempty"`
+}
+
+// Matched reports whether the line has been matched to a ledger transaction.
+func (l BankStatementLine) Matched() bool {
+	return l.TransactionID != ""
+}
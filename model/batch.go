@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Batch summarises a batch submission. Counts come from the processor's audit records,
+// so Pending shrinks as the processor works through the batch.
+type Batch struct {
+	ID        string    `json:"id"`
+	ItemCount int       `json:"item_count"`
+	Completed int       `json:"completed"`
+	Failed    int       `json:"failed"`
+	Pending   int       `json:"pending"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BatchItemResult is the outcome of one item of a batch submission, in submission order.
+type BatchItemResult struct {
+	Index         int    `json:"index"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	ReferenceID   string `json:"reference_id,omitempty"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"strings"
+)
+
+// currencyDecimals maps ISO 4217 codes to the number of minor units used when rounding amounts.
+var currencyDecimals = map[string]int32{
+	"AUD": 2, "BDT": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "CZK": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2,
+	"OMR": 3, "PHP": 2, "PKR": 2, "PLN": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2,
+	"TND": 3, "TRY": 2, "TWD": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// CurrencyDecimals returns the number of minor units for the currency, defaulting to 2 for unknown codes.
+func CurrencyDecimals(currency string) int32 {
+	if d, ok := currencyDecimals[currency]; ok {
+		return d
+	}
+	return 2
+}
+
+// IsKnownCurrency reports whether the currency is a supported ISO 4217 code.
+func IsKnownCurrency(currency string) bool {
+	_, ok := currencyDecimals[currency]
+	return ok
+}
+
+// DeriveUUID returns a deterministic UUID for the given parts, so that derived
+// ledger entries (fees, postings, occurrences) get stable IDs across retries.
+func DeriveUUID(parts ...string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(strings.Join(parts, "/"))).String()
+}
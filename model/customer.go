@@ -0,0 +1,90 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type CustomerType string
+
+const (
+	CustomerTypeIndividual CustomerType = "individual"
+	CustomerTypeBusiness   CustomerType = "business"
+)
+
+type CustomerStatus string
+
+const (
+	CustomerStatusActive  CustomerStatus = "active"
+	CustomerStatusBlocked CustomerStatus = "blocked" // Can't open or be added to accounts until unblocked
+	CustomerStatusClosed  CustomerStatus = "closed"
+)
+
+// KYCLevel is how far the customer's identity has been verified.
+type KYCLevel string
+
+const (
+	KYCLevelNone  KYCLevel = "none"
+	KYCLevelBasic KYCLevel = "basic"
+	KYCLevelFull  KYCLevel = "full"
+)
+
+// MaxCustomerNameLength is the longest customer name accepted.
+const MaxCustomerNameLength = 255
+
+type Customer struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Type      CustomerType   `json:"type"`
+	Status    CustomerStatus `json:"status"`
+	KYCLevel  KYCLevel       `json:"kyc_level"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func (c *Customer) Validate() error {
+	if strings.TrimSpace(c.Name) == "" || utf8.RuneCountInString(c.Name) > MaxCustomerNameLength {
+		return fmt.Errorf("name must be 1 to %d characters", MaxCustomerNameLength)
+	}
+
+	switch c.Type {
+	case CustomerTypeIndividual, CustomerTypeBusiness:
+	default:
+		return fmt.Errorf("invalid customer type: %s", c.Type)
+	}
+
+	switch c.Status {
+	case CustomerStatusActive, CustomerStatusBlocked, CustomerStatusClosed:
+	default:
+		return fmt.Errorf("invalid customer status: %s", c.Status)
+	}
+
+	switch c.KYCLevel {
+	case KYCLevelNone, KYCLevelBasic, KYCLevelFull:
+	default:
+		return fmt.Errorf("invalid kyc level: %s", c.KYCLevel)
+	}
+
+	return nil
+}
+
+// OwnerRole is a customer's role on an account they own.
+type OwnerRole string
+
+const (
+	OwnerRolePrimary    OwnerRole = "primary" // The account's customer_id, exactly one per account
+	OwnerRoleJoint      OwnerRole = "joint"
+	OwnerRoleAuthorized OwnerRole = "authorized" // May operate the account without owning the funds
+)
+
+func (r OwnerRole) IsValid() bool {
+	return r == OwnerRolePrimary || r == OwnerRoleJoint || r == OwnerRoleAuthorized
+}
+
+type AccountOwner struct {
+	CustomerID string    `json:"customer_id"`
+	Role       OwnerRole `json:"role"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type QuoteStatus string
+
+const (
+	QuoteStatusOpen     QuoteStatus = "open"
+	QuoteStatusExecuted QuoteStatus = "executed"
+)
+
+// FXQuote is a firm price for converting SellAmount from one of a user's accounts into another
+// of their accounts in a different currency. It can be executed once, before ExpiresAt.
+type FXQuote struct {
+	ID            string          `json:"id"`
+	FromAccountID string          `json:"from_account_id"`
+	ToAccountID   string          `json:"to_account_id"`
+	FromCurrency  string          `json:"from_currency"`
+	ToCurrency    string          `json:"to_currency"`
+	MidRate       decimal.Decimal `json:"mid_rate"` // Provider rate before spread
+	Spread        decimal.Decimal `json:"spread"`   // Spread in percent of the mid rate
+	Rate          decimal.Decimal `json:"rate"`     // Customer rate, mid rate less spread
+	SellAmount    decimal.Decimal `json:"sell_amount"`
+	BuyAmount     decimal.Decimal `json:"buy_amount"`
+	Status        QuoteStatus     `json:"status"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func (q *FXQuote) Expired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
+
+// FXConversion records an executed quote and the two ledger entries it produced.
+type FXConversion struct {
+	ID                  string          `json:"id"`
+	QuoteID             string          `json:"quote_id"`
+	FromAccountID       string          `json:"from_account_id"`
+	ToAccountID         string          `json:"to_account_id"`
+	DebitTransactionID  string          `json:"debit_transaction_id"`
+	CreditTransactionID string          `json:"credit_transaction_id"`
+	Rate                decimal.Decimal `json:"rate"`
+	Spread              decimal.Decimal `json:"spread"`
+	FromAmount          decimal.Decimal `json:"from_amount"`
+	FromCurrency        string          `json:"from_currency"`
+	ToAmount            decimal.Decimal `json:"to_amount"`
+	ToCurrency          string          `json:"to_currency"`
+	CreatedAt           time.Time       `json:"created_at"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// AccrualPrecision is the number of decimal places kept on daily interest accruals.
+// Accruals are rounded to currency minor units only when they are posted.
+const AccrualPrecision = 10
+
+type RatePlan struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Currency   string          `json:"currency"`    // ISO 4217 currency code
+	AnnualRate decimal.Decimal `json:"annual_rate"` // Nominal yearly rate in percent
+	DayCount   int             `json:"day_count"`   // Day count basis, 360 or 365
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func (p *RatePlan) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("rate plan name is required")
+	}
+
+	if !isValidCurrency(p.Currency) {
+		return ErrInvalidCurrency
+	}
+
+	if p.AnnualRate.IsNegative() {
+		return fmt.Errorf("annual rate cannot be negative")
+	}
+
+	if p.DayCount != 360 && p.DayCount != 365 {
+		return fmt.Errorf("day count must be 360 or 365")
+	}
+
+	return nil
+}
+
+// DailyInterest returns one day of interest on the balance, rounded half-even to AccrualPrecision.
+// Non-positive balances earn nothing.
+func (p *RatePlan) DailyInterest(balance decimal.Decimal) decimal.Decimal {
+	if !balance.IsPositive() {
+		return decimal.Zero
+	}
+	return balance.Mul(p.AnnualRate).
+		DivRound(decimal.NewFromInt(int64(100*p.DayCount)), AccrualPrecision+4).
+		RoundBank(AccrualPrecision)
+}
+
+type InterestAccrual struct {
+	AccountID           string          `json:"account_id"`
+	PlanID              string          `json:"plan_id"`
+	Date                time.Time       `json:"date"`
+	Balance             decimal.Decimal `json:"balance"` // End-of-day balance the interest was computed from
+	Rate                decimal.Decimal `json:"rate"`
+	Amount              decimal.Decimal `json:"amount"`
+	PostedTransactionID string          `json:"posted_transaction_id,omitempty"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PeriodClose is the end-of-day close of a business date. Closing a date closes every earlier
+// date with it: nothing can be booked with a value date on or before the latest close.
+type PeriodClose struct {
+	Date     string    `json:"date"`     // Business date closed, YYYY-MM-DD
+	Accounts int       `json:"accounts"` // Number of closing balances snapshotted
+	ClosedAt time.Time `json:"closed_at"`
+}
+
+// ClosingBalance is an account's balance at the end of a closed business date, counting every
+// entry with a value date on or before it.
+type ClosingBalance struct {
+	Date      string          `json:"date"`
+	AccountID string          `json:"account_id"`
+	Currency  string          `json:"currency"`
+	Balance   decimal.Decimal `json:"balance"`
+}
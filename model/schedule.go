@@ -0,0 +1,104 @@
+package model
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleStatusActive    ScheduleStatus = "active"
+	ScheduleStatusPaused    ScheduleStatus = "paused"
+	ScheduleStatusCancelled ScheduleStatus = "cancelled"
+	ScheduleStatusCompleted ScheduleStatus = "completed"
+)
+
+type Cadence string
+
+const (
+	CadenceOnce       Cadence = "once"
+	CadenceWeekly     Cadence = "weekly"
+	CadenceMonthly    Cadence = "monthly"      // On DayOfMonth, or the month's last day when it is shorter
+	CadenceEndOfMonth Cadence = "end_of_month" // On the last day of every month
+)
+
+// Schedule is a standing order that queues a withdrawal or transfer on each occurrence date.
+// Dates are UTC calendar days.
+type Schedule struct {
+	ID              string          `json:"id"`
+	AccountID       string          `json:"account_id"`
+	TargetAccountID string          `json:"target_account_id,omitempty"`
+	Type            string          `json:"type"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        string          `json:"currency"`
+	Cadence         Cadence         `json:"cadence"`
+	DayOfMonth      int             `json:"day_of_month,omitempty"`
+	StartDate       time.Time       `json:"start_date"`
+	EndDate         *time.Time      `json:"end_date,omitempty"`
+	NextRunDate     *time.Time      `json:"next_run_date,omitempty"`
+	Status          ScheduleStatus  `json:"status"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+func (s *Schedule) Validate() error {
+	if !IsValidUUID(s.AccountID) {
+		return ErrInvalidAccountID
+	}
+
+	switch s.Type {
+	case "withdrawal":
+		if s.TargetAccountID != "" {
+			return fmt.Errorf("target account is only allowed on transfers")
+		}
+	case "transfer":
+		if !IsValidUUID(s.TargetAccountID) || s.TargetAccountID == s.AccountID {
+			return ErrInvalidTargetAccountID
+		}
+	default:
+		return fmt.Errorf("schedule type must be withdrawal or transfer")
+	}
+
+	if !s.Amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+
+	if !isValidCurrency(s.Currency) {
+		return ErrInvalidCurrency
+	}
+
+	switch s.Cadence {
+	case CadenceOnce, CadenceWeekly, CadenceEndOfMonth:
+	case CadenceMonthly:
+		if s.DayOfMonth < 1 || s.DayOfMonth > 31 {
+			return fmt.Errorf("day_of_month must be between 1 and 31")
+		}
+	default:
+		return fmt.Errorf("cadence must be once, weekly, monthly or end_of_month")
+	}
+
+	if s.EndDate != nil && s.EndDate.Before(s.StartDate) {
+		return fmt.Errorf("end_date is before start_date")
+	}
+
+	return nil
+}
+
+type ScheduleExecutionStatus string
+
+const (
+	ScheduleExecutionQueued ScheduleExecutionStatus = "queued"
+	ScheduleExecutionFailed ScheduleExecutionStatus = "failed"
+)
+
+// ScheduleExecution records the transaction queued for one occurrence of a schedule.
+type ScheduleExecution struct {
+	ScheduleID     string                  `json:"schedule_id"`
+	OccurrenceDate time.Time               `json:"occurrence_date"`
+	TransactionID  string                  `json:"transaction_id,omitempty"`
+	ReferenceID    string                  `json:"reference_id"`
+	Status         ScheduleExecutionStatus `json:"status"`
+	Error          string                  `json:"error,omitempty"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Statement lists an account's completed transactions between two UTC calendar days,
+// inclusive. OpeningBalance plus the sum of the entries' movements is ClosingBalance.
+type Statement struct {
+	AccountID      string           `json:"account_id"`
+	Currency       string           `json:"currency"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance decimal.Decimal  `json:"opening_balance"`
+	TotalCredits   decimal.Decimal  `json:"total_credits"`
+	TotalDebits    decimal.Decimal  `json:"total_debits"`
+	ClosingBalance decimal.Decimal  `json:"closing_balance"`
+	Entries        []StatementEntry `json:"entries"`
+}
+
+type StatementEntry struct {
+	TransactionID  string          `json:"transaction_id"`
+	Sequence       int64           `json:"sequence"`
+	Type           string          `json:"type"`
+	Amount         decimal.Decimal `json:"amount"`
+	Movement       decimal.Decimal `json:"movement"` // Signed effect on the balance, negative for debits
+	ReferenceID    string          `json:"reference_id"`
+	ParentID       string          `json:"parent_id,omitempty"`
+	RunningBalance decimal.Decimal `json:"running_balance"`
+	Description    string          `json:"description,omitempty"`
+	Counterparty   *Counterparty   `json:"counterparty,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ValueDate      string          `json:"value_date"` // Business date (YYYY-MM-DD) the entry is booked on
+}
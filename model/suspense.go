@@ -0,0 +1,41 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type SuspenseStatus string
+
+const (
+	SuspenseStatusOpen     SuspenseStatus = "open"
+	SuspenseStatusRerouted SuspenseStatus = "rerouted" // Booked to the right account
+	SuspenseStatusReturned SuspenseStatus = "returned" // Sent back to the payer
+)
+
+// SuspenseReason is why a deposit couldn't be booked to its account.
+type SuspenseReason string
+
+const (
+	SuspenseReasonAccountNotFound SuspenseReason = "account_not_found"
+	SuspenseReasonAccountClosed   SuspenseReason = "account_closed"
+)
+
+// SuspenseItem is a deposit credited to the suspense account because the account it was sent to
+// doesn't exist or is closed. It stays open until it is rerouted to an account or returned, both
+// posted as a withdrawal from the suspense account linked to the suspended deposit.
+type SuspenseItem struct {
+	ID                      string          `json:"id"`
+	TransactionID           string          `json:"transaction_id"`      // Deposit credited to the suspense account
+	SuspenseAccountID       string          `json:"suspense_account_id"` // Account holding the funds
+	IntendedAccountID       string          `json:"intended_account_id"` // Account the deposit was sent to
+	Amount                  decimal.Decimal `json:"amount"`
+	Currency                string          `json:"currency"`
+	Reason                  SuspenseReason  `json:"reason"`
+	Status                  SuspenseStatus  `json:"status"`
+	ResolvedAccountID       string          `json:"resolved_account_id,omitempty"`       // Account the item was rerouted to
+	ResolutionTransactionID string          `json:"resolution_transaction_id,omitempty"` // Withdrawal from the suspense account
+	Note                    string          `json:"note,omitempty"`                      // Operator's note on the resolution
+	CreatedAt               time.Time       `json:"created_at"`
+	ResolvedAt              *time.Time      `json:"resolved_at,omitempty"`
+}
@@ -7,26 +7,73 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 var (
 	ErrInvalidTransactionID   = errors.New("invalid transaction ID")
 	ErrInvalidAccountID       = errors.New("invalid account ID")
+	ErrInvalidTargetAccountID = errors.New("invalid target account ID")
 	ErrInvalidReferenceID     = errors.New("invalid reference ID")
 	ErrInvalidAmount          = errors.New("amount must be greater than zero")
-	ErrInvalidTransactionType = errors.New("transaction type must be deposit or withdrawal")
+	ErrInvalidTransactionType = errors.New("transaction type must be deposit, withdrawal or transfer")
 	ErrInvalidCurrency        = errors.New("invalid currency format")
+	ErrInvalidDescription     = errors.New("description is too long")
+	ErrInvalidCounterparty    = errors.New("counterparty name or account is too long")
+	ErrInvalidMetadata        = errors.New("invalid transaction metadata")
+	ErrInvalidValueDate       = errors.New("value date must be a date (YYYY-MM-DD) not after the day the transaction is created")
+)
+
+// Limits on the free-text details of a transaction.
+const (
+	MaxDescriptionLength         = 140
+	MaxCounterpartyNameLength    = 140
+	MaxCounterpartyAccountLength = 64
 )
 
 type Transaction struct {
-	ID          string    `json:"id"`
-	AccountID   string    `json:"account_id"`
-	Type        string    `json:"type"`
-	Amount      Decimal   `json:"amount" bson:"amount"`
-	Currency    string    `json:"currency"`
-	ReferenceID string    `json:"reference_id"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID              string            `json:"id"`
+	AccountID       string            `json:"account_id"`
+	TargetAccountID string            `json:"target_account_id,omitempty" bson:"targetaccountid,omitempty"` // Credited account of a transfer
+	Type            string            `json:"type"`
+	Amount          Decimal           `json:"amount" bson:"amount"`
+	Currency        string            `json:"currency"`
+	ReferenceID     string            `json:"reference_id"`
+	Status          string            `json:"status"`
+	ParentID        string            `json:"parent_id,omitempty" bson:"parentid,omitempty"` // Set on entries posted on behalf of another transaction
+	Fees            []Fee             `json:"fees,omitempty" bson:"fees,omitempty"`
+	BatchID         string            `json:"batch_id,omitempty" bson:"batchid,omitempty"`           // Set on transactions submitted in a batch
+	Sequence        int64             `json:"sequence,omitempty" bson:"sequence,omitempty"`          // Position in the account's ledger, set once processed
+	BalanceAfter    *Decimal          `json:"balance_after,omitempty" bson:"balanceafter,omitempty"` // Account balance after the transaction, set once processed
+	Conversion      *Conversion       `json:"conversion,omitempty" bson:"conversion,omitempty"`      // Set when the amount was converted into the account currency
+	Description     string            `json:"description,omitempty" bson:"description,omitempty"`    // Free-text memo shown on statements
+	Counterparty    *Counterparty     `json:"counterparty,omitempty" bson:"counterparty,omitempty"`  // Other party of the payment, if known
+	Metadata        map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	ValueDate       string            `json:"value_date,omitempty" bson:"valuedate,omitempty"`            // Business date (YYYY-MM-DD) the transaction is booked on, the day it is created unless back-valued
+	SuspenseItemID  string            `json:"suspense_item_id,omitempty" bson:"suspenseitemid,omitempty"` // Set when a deposit was credited to the suspense account instead of its account
+	CreatedAt       time.Time         `json:"created_at"`
+	Chain           *ChainLink        `json:"chain,omitempty" bson:"chain,omitempty"` // Position in the account's audit hash chain, set on audit records
+}
+
+// Fee is a charge levied on a transaction according to the fee schedule.
+type Fee struct {
+	Rule     string  `json:"rule"`
+	Amount   Decimal `json:"amount" bson:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// Counterparty identifies the other side of a deposit or withdrawal, e.g. the payer of a deposit.
+type Counterparty struct {
+	Name    string `json:"name,omitempty" bson:"name,omitempty"`
+	Account string `json:"account,omitempty" bson:"account,omitempty"` // IBAN or other account identifier
+}
+
+// Conversion records the amount and currency a transaction was submitted in before it was
+// converted into its account's currency.
+type Conversion struct {
+	OriginalAmount   Decimal `json:"original_amount" bson:"originalamount"`
+	OriginalCurrency string  `json:"original_currency" bson:"originalcurrency"`
+	Rate             Decimal `json:"rate" bson:"rate"` // Mid rate for one unit of the original currency
 }
 
 func (t *Transaction) Validate() error {
@@ -45,6 +92,10 @@ func (t *Transaction) Validate() error {
 
 	switch strings.ToLower(t.Type) {
 	case "deposit", "withdrawal":
+	case "transfer":
+		if !IsValidUUID(t.TargetAccountID) || t.TargetAccountID == t.AccountID {
+			return ErrInvalidTargetAccountID
+		}
 	default:
 		return ErrInvalidTransactionType
 	}
@@ -53,6 +104,31 @@ func (t *Transaction) Validate() error {
 		return ErrInvalidCurrency
 	}
 
+	if t.ValueDate != "" {
+		date, err := time.Parse(time.DateOnly, t.ValueDate)
+		if err != nil || (!t.CreatedAt.IsZero() && date.After(t.CreatedAt.UTC())) {
+			return ErrInvalidValueDate
+		}
+	}
+
+	return t.ValidateDetails()
+}
+
+// ValidateDetails checks the length limits of the description and counterparty and the metadata limits.
+func (t *Transaction) ValidateDetails() error {
+	if utf8.RuneCountInString(t.Description) > MaxDescriptionLength {
+		return ErrInvalidDescription
+	}
+
+	if c := t.Counterparty; c != nil {
+		if utf8.RuneCountInString(c.Name) > MaxCounterpartyNameLength || utf8.RuneCountInString(c.Account) > MaxCounterpartyAccountLength {
+			return ErrInvalidCounterparty
+		}
+	}
+
+	if err := ValidateMetadata(t.Metadata); err != nil {
+		return errors.Wrap(ErrInvalidMetadata, err.Error())
+	}
 	return nil
 }
 
@@ -3,15 +3,42 @@ package account
 import (
 	"context"
 	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
 	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type AccountRequest struct {
-	UserID         string  `json:"user_id"`
-	InitialBalance float64 `json:"initial_balance"`
-	Currency       string  `json:"currency"`
+	UserID         string            `json:"user_id,omitempty"`
+	CustomerID     string            `json:"customer_id"`
+	Owners         []OwnerRequest    `json:"owners,omitempty"`
+	InitialBalance float64           `json:"initial_balance"`
+	Currency       string            `json:"currency"`
+	Tier           string            `json:"tier,omitempty"`
+	AutoConvert    bool              `json:"auto_convert,omitempty"`
+	Nickname       string            `json:"nickname,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+type UpdateRequest struct {
+	ID       string             `json:"-"`
+	Nickname *string            `json:"nickname"`
+	Metadata map[string]*string `json:"metadata"`
+}
+
+type AccountIDRequest struct {
+	ID string
+}
+
+type OwnerRequest struct {
+	AccountID  string `json:"-"`
+	CustomerID string `json:"customer_id"`
+	Role       string `json:"role"`
 }
 
 func decodeCreateAccountRequest(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -24,9 +51,16 @@ func decodeCreateAccountRequest(ctx context.Context, r *http.Request) (interface
 		return nil, eError.NewServiceError(err, "failed to decode create account request", "payload", http.StatusBadRequest)
 	}
 
-	if req.UserID == "" {
-		slog.Warn("user_id is required", "request", req)
-		return nil, eError.NewServiceError(nil, "user_id is required", "validation", http.StatusBadRequest)
+	if req.CustomerID == "" {
+		slog.Warn("customer_id is required", "request", req)
+		return nil, eError.NewServiceError(nil, "customer_id is required", "validation", http.StatusBadRequest)
+	}
+
+	for _, owner := range append([]OwnerRequest{{CustomerID: req.CustomerID}}, req.Owners...) {
+		if !model.IsValidUUID(owner.CustomerID) {
+			return nil, eError.NewServiceError(
+				errors.New("invalid customer id"), "customer_id must be a valid UUID", "INVALID_CUSTOMER_ID", http.StatusBadRequest)
+		}
 	}
 
 	if req.Currency == "" {
@@ -41,3 +75,95 @@ func decodeCreateAccountRequest(ctx context.Context, r *http.Request) (interface
 
 	return req, nil
 }
+
+func decodeAccountIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return AccountIDRequest{ID: chi.URLParam(r, "id")}, nil
+}
+
+func decodeAddOwnerRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req OwnerRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("failed to decode add owner request", "error", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	if !model.IsValidUUID(req.CustomerID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid customer id"), "customer_id must be a valid UUID", "INVALID_CUSTOMER_ID", http.StatusBadRequest)
+	}
+
+	req.AccountID = chi.URLParam(r, "id")
+	return req, nil
+}
+
+func decodeRemoveOwnerRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return OwnerRequest{AccountID: chi.URLParam(r, "id"), CustomerID: chi.URLParam(r, "customer_id")}, nil
+}
+
+func decodeUpdateAccountRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req UpdateRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("failed to decode update account request", "error", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	req.ID = chi.URLParam(r, "id")
+	return req, nil
+}
+
+// decodeListAccountsRequest reads the listing filters. Metadata is filtered with metadata.<key>=<value>
+// parameters, e.g. ?metadata.purpose=savings.
+func decodeListAccountsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	filter := ListFilter{
+		UserID:     query.Get("user_id"),
+		CustomerID: query.Get("customer_id"),
+		Currency:   strings.ToUpper(query.Get("currency")),
+		Status:     strings.ToLower(query.Get("status")),
+		Kind:       strings.ToLower(query.Get("kind")),
+		Type:       strings.ToLower(query.Get("type")),
+		Nickname:   query.Get("nickname"),
+		After:      query.Get("after"),
+	}
+
+	if filter.CustomerID != "" && !model.IsValidUUID(filter.CustomerID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid customer id"), "customer_id must be a valid UUID", "INVALID_CUSTOMER_ID", http.StatusBadRequest)
+	}
+
+	if kind := model.AccountKind(filter.Kind); kind != "" && kind != model.AccountKindCustomer && kind != model.AccountKindSystem {
+		return nil, eError.NewServiceError(
+			errors.New("invalid account kind"), "kind must be customer or system", "INVALID_KIND", http.StatusBadRequest)
+	}
+
+	if filter.Type != "" && !model.AccountType(filter.Type).IsValid() {
+		return nil, eError.NewServiceError(
+			errors.New("invalid account type"), "type must be asset, liability, revenue, expense or equity", "INVALID_TYPE", http.StatusBadRequest)
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, eError.NewServiceError(
+				errors.New("invalid limit"), "limit must be a positive integer", "INVALID_LIMIT", http.StatusBadRequest)
+		}
+		filter.Limit = n
+	}
+
+	for key, values := range query {
+		if name, ok := strings.CutPrefix(key, "metadata."); ok && name != "" {
+			if filter.Metadata == nil {
+				filter.Metadata = make(map[string]string)
+			}
+			filter.Metadata[name] = values[0]
+		}
+	}
+
+	return filter, nil
+}
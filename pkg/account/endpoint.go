@@ -3,6 +3,7 @@ package account
 import (
 	"context"
 	"github.com/go-kit/kit/endpoint"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
 	eError "github.com/mdshahjahanmiah/explore-go/error"
 	"github.com/shopspring/decimal"
 	"net/http"
@@ -11,13 +12,24 @@ import (
 )
 
 type AccountResponse struct {
-	ID        string `json:"id"`
-	UserID    string `json:"user_id"`
-	Balance   string `json:"balance"`
-	Currency  string `json:"currency"`
-	Status    string `json:"status"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID          string               `json:"id"`
+	UserID      string               `json:"user_id"`
+	CustomerID  string               `json:"customer_id"`
+	Owners      []model.AccountOwner `json:"owners,omitempty"`
+	Balance     string               `json:"balance"`
+	Currency    string               `json:"currency"`
+	Status      string               `json:"status"`
+	Tier        string               `json:"tier"`
+	AutoConvert bool                 `json:"auto_convert"`
+	Nickname    string               `json:"nickname,omitempty"`
+	Metadata    map[string]string    `json:"metadata,omitempty"`
+	CreatedAt   string               `json:"created_at"`
+	UpdatedAt   string               `json:"updated_at"`
+}
+
+type ListAccountsResponse struct {
+	Accounts []AccountResponse `json:"accounts"`
+	Next     string            `json:"next,omitempty"` // Pass as after to get the next page
 }
 
 func makePostAccountEndpoint(s Service) endpoint.Endpoint {
@@ -27,10 +39,21 @@ func makePostAccountEndpoint(s Service) endpoint.Endpoint {
 			return nil, eError.NewServiceError(nil, "invalid request type", "invalid_request_type", http.StatusBadRequest)
 		}
 
+		owners := make([]model.AccountOwner, len(req.Owners))
+		for i, o := range req.Owners {
+			owners[i] = model.AccountOwner{CustomerID: o.CustomerID, Role: model.OwnerRole(strings.ToLower(o.Role))}
+		}
+
 		createReq := CreateAccountRequest{
-			UserID:   req.UserID,
-			Currency: strings.ToUpper(req.Currency),
-			Balance:  decimal.NewFromFloat(req.InitialBalance),
+			UserID:      req.UserID,
+			CustomerID:  req.CustomerID,
+			Owners:      owners,
+			Currency:    strings.ToUpper(req.Currency),
+			Balance:     decimal.NewFromFloat(req.InitialBalance),
+			Tier:        strings.ToLower(req.Tier),
+			AutoConvert: req.AutoConvert,
+			Nickname:    req.Nickname,
+			Metadata:    req.Metadata,
 		}
 
 		account, err := s.CreateAccount(ctx, createReq)
@@ -38,14 +61,110 @@ func makePostAccountEndpoint(s Service) endpoint.Endpoint {
 			return nil, err
 		}
 
-		return AccountResponse{
-			ID:        account.ID,
-			UserID:    account.UserID,
-			Balance:   account.Balance.String(),
-			Currency:  account.Currency,
-			Status:    string(account.Status),
-			CreatedAt: account.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: account.UpdatedAt.Format(time.RFC3339),
-		}, nil
+		return newAccountResponse(account), nil
+	}
+}
+
+func makeGetAccountEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(AccountIDRequest)
+		if !ok {
+			return nil, eError.NewServiceError(nil, "invalid request type", "invalid_request_type", http.StatusBadRequest)
+		}
+
+		account, err := s.GetAccount(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		}
+		return newAccountResponse(account), nil
+	}
+}
+
+func makeUpdateAccountEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(UpdateRequest)
+		if !ok {
+			return nil, eError.NewServiceError(nil, "invalid request type", "invalid_request_type", http.StatusBadRequest)
+		}
+
+		account, err := s.UpdateAccount(ctx, req.ID, UpdateAccountRequest{Nickname: req.Nickname, Metadata: req.Metadata})
+		if err != nil {
+			return nil, err
+		}
+		return newAccountResponse(account), nil
+	}
+}
+
+func makeAddOwnerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(OwnerRequest)
+		if !ok {
+			return nil, eError.NewServiceError(nil, "invalid request type", "invalid_request_type", http.StatusBadRequest)
+		}
+
+		owner := model.AccountOwner{CustomerID: req.CustomerID, Role: model.OwnerRole(strings.ToLower(req.Role))}
+		account, err := s.AddOwner(ctx, req.AccountID, owner)
+		if err != nil {
+			return nil, err
+		}
+		return newAccountResponse(account), nil
+	}
+}
+
+func makeRemoveOwnerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(OwnerRequest)
+		if !ok {
+			return nil, eError.NewServiceError(nil, "invalid request type", "invalid_request_type", http.StatusBadRequest)
+		}
+
+		account, err := s.RemoveOwner(ctx, req.AccountID, req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		return newAccountResponse(account), nil
+	}
+}
+
+func makeListAccountsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		filter, ok := request.(ListFilter)
+		if !ok {
+			return nil, eError.NewServiceError(nil, "invalid request type", "invalid_request_type", http.StatusBadRequest)
+		}
+
+		accounts, err := s.ListAccounts(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := ListAccountsResponse{Accounts: make([]AccountResponse, 0, len(accounts))}
+		for i := range accounts {
+			resp.Accounts = append(resp.Accounts, newAccountResponse(&accounts[i]))
+		}
+
+		// A full page may be followed by more accounts
+		if len(accounts) > 0 && len(accounts) == listLimit(filter.Limit) {
+			resp.Next = accounts[len(accounts)-1].ID
+		}
+		return resp, nil
+	}
+}
+
+func newAccountResponse(account *model.Account) AccountResponse {
+	return AccountResponse{
+		ID:          account.ID,
+		UserID:      account.UserID,
+		CustomerID:  account.CustomerID,
+		Owners:      account.Owners,
+		Balance:     account.Balance.String(),
+		Currency:    account.Currency,
+		Status:      string(account.Status),
+		Tier:        account.Tier,
+		AutoConvert: account.AutoConvert,
+		Nickname:    account.Nickname,
+		Metadata:    account.Metadata,
+		CreatedAt:   account.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   account.UpdatedAt.Format(time.RFC3339),
 	}
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/chart"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/customer"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
 	eError "github.com/mdshahjahanmiah/explore-go/error"
 	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/mdshahjahanmiah/explore-go/repository"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	"net/http"
@@ -23,40 +28,135 @@ const (
 )
 
 var (
-	ErrInvalidAccount = errors.New("invalid account")
+	ErrInvalidAccount          = errors.New("invalid account")
+	ErrAccountNotFound         = errors.New("account not found")
+	ErrFundingAccountNotFound  = errors.New("opening balance funding account not found")
+	ErrFundingCurrencyMismatch = errors.New("opening balance funding account is in another currency")
+	ErrInsufficientFunding     = errors.New("insufficient funds in opening balance funding account")
+	ErrOwnerExists             = errors.New("customer already owns the account")
+	ErrOwnerNotFound           = errors.New("customer does not own the account")
+	ErrPrimaryOwner            = errors.New("primary owner can't be removed")
+	ErrSystemAccount           = errors.New("system accounts have no owners")
+)
+
+// Listing page sizes.
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
 )
 
 type Service interface {
 	CreateAccount(ctx context.Context, input CreateAccountRequest) (*model.Account, error)
+	GetAccount(ctx context.Context, id string) (*model.Account, error)
+	ListAccounts(ctx context.Context, filter ListFilter) ([]model.Account, error)
+	UpdateAccount(ctx context.Context, id string, req UpdateAccountRequest) (*model.Account, error)
+	AddOwner(ctx context.Context, accountID string, owner model.AccountOwner) (*model.Account, error)
+	RemoveOwner(ctx context.Context, accountID, customerID string) (*model.Account, error)
 }
 
 type service struct {
-	config config.Config
-	logger *logging.Logger
-	store  Store
+	config  config.Config
+	logger  *logging.Logger
+	store   Store
+	audit   *audit.Chain
+	funding map[string]string // Funding account per currency for opening balances
 }
 
 type CreateAccountRequest struct {
-	UserID   string          `json:"user_id" validate:"required"`
-	Currency string          `json:"currency" validate:"required,len=3"`
-	Balance  decimal.Decimal `json:"balance" validate:"gte=0"`
+	UserID      string               `json:"user_id"` // Defaults to the customer id
+	CustomerID  string               `json:"customer_id" validate:"required"`
+	Owners      []model.AccountOwner `json:"owners"` // Joint and authorized owners besides the customer
+	Currency    string               `json:"currency" validate:"required,len=3"`
+	Balance     decimal.Decimal      `json:"balance" validate:"gte=0"`
+	Tier        string               `json:"tier"`
+	AutoConvert bool                 `json:"auto_convert"`
+	Nickname    string               `json:"nickname"`
+	Metadata    map[string]string    `json:"metadata"`
+}
+
+// UpdateAccountRequest changes an account's profile. A nil Nickname leaves it unchanged and an
+// empty one clears it. Metadata is merged into the existing metadata; a nil value removes the key.
+type UpdateAccountRequest struct {
+	Nickname *string
+	Metadata map[string]*string
 }
 
-func NewService(config config.Config, logger *logging.Logger, database *db.DB) Service {
+func NewService(config config.Config, logger *logging.Logger, database *db.DB, repo *repository.Repository[model.Transaction]) (Service, error) {
+	funding, err := parseFundingAccounts(config.OpeningFunding)
+	if err != nil {
+		return nil, err
+	}
+
+	// Opening balances are funded from the settlement account where none is configured
+	accounts, err := chart.Load(config.ChartOfAccounts)
+	if err != nil {
+		return nil, err
+	}
+	for currency, id := range accounts.ByCurrency(model.SystemRoleSettlement) {
+		if _, ok := funding[currency]; !ok {
+			funding[currency] = id
+		}
+	}
+
 	return &service{
-		config: config,
-		logger: logger,
-		store:  NewStore(database),
+		config:  config,
+		logger:  logger,
+		store:   NewStore(database),
+		audit:   audit.NewChain(repo.Collection),
+		funding: funding,
+	}, nil
+}
+
+// parseFundingAccounts reads opening balance funding accounts given as "USD=<id>,EUR=<id>".
+func parseFundingAccounts(s string) (map[string]string, error) {
+	funding := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		currency, accountID, ok := strings.Cut(pair, "=")
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		accountID = strings.TrimSpace(accountID)
+		if !ok || len(currency) != 3 || !model.IsValidUUID(accountID) {
+			return nil, errors.Errorf("invalid opening balance funding account %q, expected CUR=account_id", pair)
+		}
+		funding[currency] = accountID
 	}
+	return funding, nil
 }
 
 func (s *service) CreateAccount(ctx context.Context, req CreateAccountRequest) (*model.Account, error) {
+	if req.Tier == "" {
+		req.Tier = model.AccountTierStandard
+	}
+
+	if req.UserID == "" {
+		req.UserID = req.CustomerID
+	}
+
 	account := &model.Account{
-		ID:       uuid.NewString(),
-		UserID:   req.UserID,
-		Balance:  req.Balance,
-		Currency: req.Currency,
-		Status:   model.AccountStatusActive,
+		ID:          uuid.NewString(),
+		UserID:      req.UserID,
+		CustomerID:  req.CustomerID,
+		Kind:        model.AccountKindCustomer,
+		Type:        model.AccountTypeLiability,
+		Owners:      append([]model.AccountOwner{{CustomerID: req.CustomerID, Role: model.OwnerRolePrimary}}, req.Owners...),
+		Balance:     req.Balance,
+		Currency:    req.Currency,
+		Status:      model.AccountStatusActive,
+		Tier:        req.Tier,
+		AutoConvert: req.AutoConvert,
+		Nickname:    strings.TrimSpace(req.Nickname),
+		Metadata:    req.Metadata,
+	}
+
+	if err := validateProfile(account); err != nil {
+		return nil, err
+	}
+
+	if err := account.ValidateOwners(); err != nil {
+		return nil, eError.NewServiceError(err, err.Error(), "INVALID_OWNERS", http.StatusBadRequest)
 	}
 
 	// Validate account
@@ -65,18 +165,176 @@ func (s *service) CreateAccount(ctx context.Context, req CreateAccountRequest) (
 		return nil, errors.Wrap(ErrInvalidAccount, err.Error())
 	}
 
-	// Store in database
-	if err := s.store.Insert(ctx, account); err != nil {
+	// Store in database, with the initial balance posted as an opening transaction
+	movements, err := s.store.Insert(ctx, account, s.funding[account.Currency])
+	if err != nil {
 		s.logger.Error("failed to create account", "account_id", account.ID, "error", err)
 
 		// Unique constraint violation
 		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
 			return nil, eError.NewServiceError(err, ErrDuplicateAccountMsg, ErrDuplicateAccountCode, http.StatusConflict)
 		}
+		if errors.Is(err, ErrInsufficientFunding) {
+			return nil, eError.NewServiceError(err, "opening balance funding account has insufficient funds", "INSUFFICIENT_FUNDING", http.StatusConflict)
+		}
+		if errors.Is(err, ErrFundingAccountNotFound) {
+			return nil, eError.NewServiceError(err, "opening balance funding account not found", "FUNDING_ACCOUNT_NOT_FOUND", http.StatusConflict)
+		}
+		if errors.Is(err, ErrFundingCurrencyMismatch) {
+			return nil, eError.NewServiceError(err, "opening balance funding account is in another currency", "FUNDING_CURRENCY_MISMATCH", http.StatusConflict)
+		}
+		if errors.Is(err, transaction.ErrPeriodClosed) {
+			return nil, eError.NewServiceError(err, "the opening balance falls in a closed period", "PERIOD_CLOSED", http.StatusConflict)
+		}
+		if err := customerError(err); err != nil {
+			return nil, err
+		}
 
 		return nil, errors.Wrap(err, "failed to create account")
 	}
 
+	for _, txn := range movements {
+		if err := s.audit.Append(ctx, txn); err != nil {
+			s.logger.Error("Audit failed (non-critical)", "id", txn.ID, "error", err)
+		}
+	}
+
 	s.logger.Info("account created successfully", "account_id", account.ID)
 	return account, nil
 }
+
+func (s *service) GetAccount(ctx context.Context, id string) (*model.Account, error) {
+	account, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			return nil, eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+		}
+		s.logger.Error("failed to get account", "account_id", id, "error", err)
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *service) ListAccounts(ctx context.Context, filter ListFilter) ([]model.Account, error) {
+	filter.Limit = listLimit(filter.Limit)
+
+	accounts, err := s.store.List(ctx, filter)
+	if err != nil {
+		s.logger.Error("failed to list accounts", "error", err)
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (s *service) UpdateAccount(ctx context.Context, id string, req UpdateAccountRequest) (*model.Account, error) {
+	account, err := s.store.Update(ctx, id, func(a *model.Account) error {
+		if req.Nickname != nil {
+			a.Nickname = strings.TrimSpace(*req.Nickname)
+		}
+
+		if len(req.Metadata) > 0 && a.Metadata == nil {
+			a.Metadata = make(map[string]string, len(req.Metadata))
+		}
+		for key, value := range req.Metadata {
+			if value == nil {
+				delete(a.Metadata, key)
+			} else {
+				a.Metadata[key] = *value
+			}
+		}
+
+		return validateProfile(a)
+	})
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			return nil, eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+		}
+		var serviceErr eError.ServiceError
+		if !errors.As(err, &serviceErr) {
+			s.logger.Error("failed to update account", "account_id", id, "error", err)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("account updated successfully", "account_id", id)
+	return account, nil
+}
+
+// AddOwner adds a joint or authorized owner to the account.
+func (s *service) AddOwner(ctx context.Context, accountID string, owner model.AccountOwner) (*model.Account, error) {
+	if owner.Role != model.OwnerRoleJoint && owner.Role != model.OwnerRoleAuthorized {
+		return nil, eError.NewServiceError(
+			errors.Errorf("invalid owner role: %s", owner.Role), "role must be joint or authorized", "INVALID_OWNERS", http.StatusBadRequest)
+	}
+
+	account, err := s.store.AddOwner(ctx, accountID, owner)
+	if err != nil {
+		return nil, s.ownerError(err, accountID, owner.CustomerID)
+	}
+
+	s.logger.Info("account owner added", "account_id", accountID, "customer_id", owner.CustomerID, "role", owner.Role)
+	return account, nil
+}
+
+func (s *service) RemoveOwner(ctx context.Context, accountID, customerID string) (*model.Account, error) {
+	account, err := s.store.RemoveOwner(ctx, accountID, customerID)
+	if err != nil {
+		return nil, s.ownerError(err, accountID, customerID)
+	}
+
+	s.logger.Info("account owner removed", "account_id", accountID, "customer_id", customerID)
+	return account, nil
+}
+
+// ownerError maps a failed owner change to the error reported by the API.
+func (s *service) ownerError(err error, accountID, customerID string) error {
+	switch {
+	case errors.Is(err, ErrAccountNotFound):
+		return eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrOwnerExists):
+		return eError.NewServiceError(err, "customer already owns the account", "OWNER_EXISTS", http.StatusConflict)
+	case errors.Is(err, ErrOwnerNotFound):
+		return eError.NewServiceError(err, "customer does not own the account", "OWNER_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrPrimaryOwner):
+		return eError.NewServiceError(err, "the primary owner can't be removed", "PRIMARY_OWNER", http.StatusConflict)
+	case errors.Is(err, ErrSystemAccount):
+		return eError.NewServiceError(err, "system accounts have no owners", "SYSTEM_ACCOUNT", http.StatusConflict)
+	}
+	if customerErr := customerError(err); customerErr != nil {
+		return customerErr
+	}
+
+	s.logger.Error("failed to change account owners", "account_id", accountID, "customer_id", customerID, "error", err)
+	return err
+}
+
+// customerError maps an unknown or inactive owner to the error reported by the API, or returns nil
+// for other errors.
+func customerError(err error) error {
+	switch {
+	case errors.Is(err, customer.ErrCustomerNotFound):
+		return eError.NewServiceError(err, "customer not found", "CUSTOMER_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, customer.ErrCustomerNotActive):
+		return eError.NewServiceError(err, "customer is blocked or closed", "CUSTOMER_NOT_ACTIVE", http.StatusConflict)
+	}
+	return nil
+}
+
+// listLimit returns the page size for a requested limit, 0 for the default.
+func listLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultListLimit
+	}
+	return min(limit, MaxListLimit)
+}
+
+// validateProfile checks the account's nickname and metadata limits.
+func validateProfile(a *model.Account) error {
+	if err := model.ValidateNickname(a.Nickname); err != nil {
+		return eError.NewServiceError(err, err.Error(), "INVALID_NICKNAME", http.StatusBadRequest)
+	}
+	if err := model.ValidateMetadata(a.Metadata); err != nil {
+		return eError.NewServiceError(err, err.Error(), "INVALID_METADATA", http.StatusBadRequest)
+	}
+	return nil
+}
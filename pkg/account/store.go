@@ -2,16 +2,44 @@ package account
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"github.com/lib/pq"
 	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/customer"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
 	eError "github.com/mdshahjahanmiah/explore-go/error"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
 type Store interface {
-	Insert(ctx context.Context, a *model.Account) error
+	Insert(ctx context.Context, a *model.Account, fundingAccountID string) ([]model.Transaction, error)
+	Get(ctx context.Context, id string) (*model.Account, error)
+	List(ctx context.Context, filter ListFilter) ([]model.Account, error)
+	Update(ctx context.Context, id string, update func(*model.Account) error) (*model.Account, error)
+	AddOwner(ctx context.Context, accountID string, owner model.AccountOwner) (*model.Account, error)
+	RemoveOwner(ctx context.Context, accountID, customerID string) (*model.Account, error)
+}
+
+// ListFilter selects accounts for listing. Empty fields don't filter; Metadata matches accounts
+// having all the given key-value pairs. Accounts are ordered by id, starting after After.
+type ListFilter struct {
+	UserID     string
+	CustomerID string // Accounts the customer owns in any role
+	Currency   string
+	Status     string
+	Kind       string
+	Type       string
+	Nickname   string
+	Metadata   map[string]string
+	After      string
+	Limit      int
 }
 
 type store struct {
@@ -22,29 +50,439 @@ func NewStore(db *db.DB) *store {
 	return &store{db: db}
 }
 
-func (s *store) Insert(ctx context.Context, a *model.Account) error {
+// Insert creates the account with its owners, who must all be active customers, and, for a positive
+// balance, posts it as an opening_balance entry in the same SQL transaction. The entry is debited
+// from the funding account when one is given. It returns the opening entry and its funding
+// withdrawal, if any, or none when the account opens empty.
+func (s *store) Insert(ctx context.Context, a *model.Account, fundingAccountID string) ([]model.Transaction, error) {
 	if err := a.Validate(); err != nil {
-		return errors.Wrap(err, "account validation failed")
+		return nil, errors.Wrap(err, "account validation failed")
+	}
+
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
 	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
 
-	err := s.db.DB.QueryRowContext(ctx,
-		`INSERT INTO accounts (id, user_id, balance, currency, status)
-		VALUES ($1, $2, $3, $4, $5)
+	customerIDs := make([]string, len(a.Owners))
+	for i, o := range a.Owners {
+		customerIDs[i] = o.CustomerID
+	}
+	if err := customer.LockActive(ctx, tx, customerIDs...); err != nil {
+		return nil, err
+	}
+
+	metadata, err := marshalMetadata(a.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO accounts (id, user_id, customer_id, balance, currency, status, tier, auto_convert, nickname, metadata, kind, type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING created_at, updated_at`,
-		a.ID, a.UserID, a.Balance, a.Currency, a.Status,
+		a.ID, a.UserID, a.CustomerID, a.Balance, a.Currency, a.Status, a.Tier, a.AutoConvert, a.Nickname, metadata, a.Kind, a.Type,
 	).Scan(&a.CreatedAt, &a.UpdatedAt)
 
 	if err != nil {
 		// PostgreSQL unique violation
 		if pgErr, ok := err.(*pq.Error); ok {
 			if pgErr.Code == "23505" {
-				return eError.NewServiceError(err, ErrDuplicateAccountMsg, ErrDuplicateAccountCode, http.StatusConflict)
+				return nil, eError.NewServiceError(err, ErrDuplicateAccountMsg, ErrDuplicateAccountCode, http.StatusConflict)
 			}
 		}
 
 		// Other unexpected errors
-		return eError.NewServiceError(err, ErrInternalServerMsg, ErrInternalServerCode, http.StatusInternalServerError)
+		return nil, eError.NewServiceError(err, ErrInternalServerMsg, ErrInternalServerCode, http.StatusInternalServerError)
+	}
+
+	for i := range a.Owners {
+		if err := insertOwner(ctx, tx, a.ID, &a.Owners[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var movements []model.Transaction
+	if a.Balance.IsPositive() {
+		if movements, err = postOpeningBalance(ctx, tx, a, fundingAccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "transaction commit failed")
+	}
+	return movements, nil
+}
+
+// postOpeningBalance credits the new account's balance with an opening_balance entry and, when a
+// funding account is given, debits it with a withdrawal linked to that entry. It returns the
+// opening entry followed by the funding one. The new account's row is not visible to other
+// transactions yet, so only the funding account needs locking, after the ledger period.
+func postOpeningBalance(ctx context.Context, tx *sql.Tx, a *model.Account, fundingAccountID string) ([]model.Transaction, error) {
+	now := time.Now().UTC()
+	valueDate, err := transaction.BookingDate(ctx, tx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	opening := model.Transaction{
+		ID:           model.NewUUID(),
+		AccountID:    a.ID,
+		Type:         transaction.TransactionTypeOpeningBalance,
+		Amount:       model.Decimal{Decimal: a.Balance},
+		Currency:     a.Currency,
+		ReferenceID:  model.DeriveUUID("opening_balance", a.ID),
+		Status:       transaction.TransactionStatusCompleted,
+		BalanceAfter: &model.Decimal{Decimal: a.Balance},
+		ValueDate:    valueDate,
+		CreatedAt:    now,
+	}
+	if err := insertMovement(ctx, tx, &opening); err != nil {
+		return nil, errors.Wrap(err, "failed to create opening balance record")
+	}
+
+	if fundingAccountID == "" {
+		return []model.Transaction{opening}, nil
+	}
+
+	var currency string
+	var balance decimal.Decimal
+	var accountType model.AccountType
+	err = tx.QueryRowContext(ctx,
+		`SELECT currency, balance, type FROM accounts WHERE id = $1 FOR UPDATE`, fundingAccountID,
+	).Scan(&currency, &balance, &accountType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.Wrapf(ErrFundingAccountNotFound, "%s", fundingAccountID)
+		}
+		return nil, errors.Wrap(err, "failed to get funding account")
+	}
+	if currency != a.Currency {
+		return nil, errors.Wrapf(ErrFundingCurrencyMismatch, "%s is in %s, not %s", fundingAccountID, currency, a.Currency)
+	}
+
+	balance = balance.Sub(a.Balance)
+	if balance.IsNegative() && !accountType.AllowsNegative() {
+		return nil, ErrInsufficientFunding
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, balance, fundingAccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to debit funding account")
+	}
+
+	funding := model.Transaction{
+		ID:           model.DeriveUUID(opening.ID, "funding"),
+		AccountID:    fundingAccountID,
+		Type:         transaction.TransactionTypeWithdrawal,
+		Amount:       opening.Amount,
+		Currency:     a.Currency,
+		ReferenceID:  model.DeriveUUID(opening.ReferenceID, "funding"),
+		Status:       transaction.TransactionStatusCompleted,
+		ParentID:     opening.ID,
+		BalanceAfter: &model.Decimal{Decimal: balance},
+		ValueDate:    valueDate,
+		CreatedAt:    now,
 	}
+	if err := insertMovement(ctx, tx, &funding); err != nil {
+		return nil, errors.Wrap(err, "failed to create funding record")
+	}
+
+	return []model.Transaction{opening, funding}, nil
+}
 
+// insertMovement writes txn as a ledger entry and sets the sequence it was given.
+func insertMovement(ctx context.Context, tx *sql.Tx, txn *model.Transaction) error {
+	sequence, err := transaction.InsertEntry(ctx, tx, transaction.Entry{
+		ID:           txn.ID,
+		AccountID:    txn.AccountID,
+		Type:         txn.Type,
+		Amount:       txn.Amount.Decimal,
+		Currency:     txn.Currency,
+		ReferenceID:  txn.ReferenceID,
+		ParentID:     txn.ParentID,
+		BalanceAfter: txn.BalanceAfter.Decimal,
+		ValueDate:    txn.ValueDate,
+		CreatedAt:    txn.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	txn.Sequence = sequence
 	return nil
 }
+
+const accountColumns = `id, user_id, COALESCE(customer_id::text, ''), balance, currency, status, tier, auto_convert, nickname, metadata,
+	kind, type, COALESCE(system_role, ''), created_at, updated_at`
+
+func (s *store) Get(ctx context.Context, id string) (*model.Account, error) {
+	a, err := scanAccount(s.db.DB.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get account")
+	}
+
+	if a.Owners, err = loadOwners(ctx, s.db.DB, id); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// List returns accounts without their owners.
+func (s *store) List(ctx context.Context, filter ListFilter) ([]model.Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id::text > $1`
+	args := []interface{}{filter.After}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	if filter.UserID != "" {
+		where("user_id = $%d", filter.UserID)
+	}
+	if filter.CustomerID != "" {
+		where("id IN (SELECT account_id FROM account_owners WHERE customer_id = $%d)", filter.CustomerID)
+	}
+	if filter.Currency != "" {
+		where("currency = $%d", filter.Currency)
+	}
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	if filter.Kind != "" {
+		where("kind = $%d", filter.Kind)
+	}
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+	if filter.Nickname != "" {
+		where("nickname = $%d", filter.Nickname)
+	}
+	if len(filter.Metadata) > 0 {
+		metadata, err := marshalMetadata(filter.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		where("metadata @> $%d::jsonb", metadata)
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id::text LIMIT $%d", len(args))
+
+	rows, err := s.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list accounts")
+	}
+	defer rows.Close()
+
+	accounts := []model.Account{}
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan account")
+		}
+		accounts = append(accounts, *a)
+	}
+	return accounts, rows.Err()
+}
+
+// Update locks the account, applies update to it and stores its nickname and metadata.
+// Nothing is stored when update fails.
+func (s *store) Update(ctx context.Context, id string, update func(*model.Account) error) (*model.Account, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	a, err := lockAccount(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update(a); err != nil {
+		return nil, err
+	}
+
+	metadata, err := marshalMetadata(a.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE accounts SET nickname = $1, metadata = $2, updated_at = NOW() WHERE id = $3 RETURNING updated_at`,
+		a.Nickname, metadata, id,
+	).Scan(&a.UpdatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update account")
+	}
+
+	if a.Owners, err = loadOwners(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "transaction commit failed")
+	}
+	return a, nil
+}
+
+// AddOwner adds an active customer as an owner of the account, which can't be a system account.
+func (s *store) AddOwner(ctx context.Context, accountID string, owner model.AccountOwner) (*model.Account, error) {
+	return s.updateOwners(ctx, accountID, func(tx *sql.Tx, a *model.Account) error {
+		if a.Kind == model.AccountKindSystem {
+			return ErrSystemAccount
+		}
+		if err := customer.LockActive(ctx, tx, owner.CustomerID); err != nil {
+			return err
+		}
+		return insertOwner(ctx, tx, accountID, &owner)
+	})
+}
+
+// RemoveOwner removes a joint or authorized owner from the account. The primary owner can't be removed.
+func (s *store) RemoveOwner(ctx context.Context, accountID, customerID string) (*model.Account, error) {
+	return s.updateOwners(ctx, accountID, func(tx *sql.Tx, a *model.Account) error {
+		if customerID == a.CustomerID {
+			return ErrPrimaryOwner
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`DELETE FROM account_owners WHERE account_id = $1 AND customer_id = $2`, accountID, customerID)
+		if err != nil {
+			return errors.Wrap(err, "failed to remove account owner")
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return errors.Wrap(err, "failed to remove account owner")
+		} else if n == 0 {
+			return ErrOwnerNotFound
+		}
+		return nil
+	})
+}
+
+// updateOwners locks the account, applies change to its owners and returns the account with the
+// resulting owners.
+func (s *store) updateOwners(ctx context.Context, accountID string, change func(*sql.Tx, *model.Account) error) (*model.Account, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	a, err := lockAccount(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := change(tx, a); err != nil {
+		return nil, err
+	}
+
+	if a.Owners, err = loadOwners(ctx, tx, accountID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "transaction commit failed")
+	}
+	return a, nil
+}
+
+func lockAccount(ctx context.Context, tx *sql.Tx, id string) (*model.Account, error) {
+	a, err := scanAccount(tx.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get account")
+	}
+	return a, nil
+}
+
+func insertOwner(ctx context.Context, tx *sql.Tx, accountID string, owner *model.AccountOwner) error {
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO account_owners (account_id, customer_id, role) VALUES ($1, $2, $3) RETURNING created_at`,
+		accountID, owner.CustomerID, owner.Role,
+	).Scan(&owner.CreatedAt)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return ErrOwnerExists
+		}
+		return errors.Wrap(err, "failed to add account owner")
+	}
+	return nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadOwners returns the account's owners, the primary one first.
+func loadOwners(ctx context.Context, q queryer, accountID string) ([]model.AccountOwner, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT customer_id::text, role, created_at FROM account_owners WHERE account_id = $1
+		ORDER BY role <> 'primary', created_at, customer_id`,
+		accountID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get account owners")
+	}
+	defer rows.Close()
+
+	owners := []model.AccountOwner{}
+	for rows.Next() {
+		var o model.AccountOwner
+		if err := rows.Scan(&o.CustomerID, &o.Role, &o.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan account owner")
+		}
+		owners = append(owners, o)
+	}
+	return owners, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccount(row rowScanner) (*model.Account, error) {
+	var a model.Account
+	var metadata []byte
+	err := row.Scan(&a.ID, &a.UserID, &a.CustomerID, &a.Balance, &a.Currency, &a.Status, &a.Tier, &a.AutoConvert,
+		&a.Nickname, &metadata, &a.Kind, &a.Type, &a.SystemRole, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(metadata, &a.Metadata); err != nil {
+		return nil, errors.Wrap(err, "failed to decode account metadata")
+	}
+	return &a, nil
+}
+
+func marshalMetadata(metadata map[string]string) ([]byte, error) {
+	if metadata == nil {
+		return []byte("{}"), nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode account metadata")
+	}
+	return data, nil
+}
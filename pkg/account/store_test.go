@@ -2,13 +2,17 @@ package account_test
 
 import (
 	"context"
+	"database/sql"
 	database "github.com/mdshahjahanmiah/banking-ledger/pkg/db"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/mdshahjahanmiah/banking-ledger/model"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/account"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/customer"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,26 +30,415 @@ func TestStore_Insert_Success(t *testing.T) {
 	// Prepare the account to insert
 	balance := decimal.NewFromFloat(123.45)
 	acc := &model.Account{
-		ID:       "acc1",
-		UserID:   "user1",
-		Balance:  balance,
-		Currency: "USD",
-		Status:   model.AccountStatusActive,
+		ID:         "acc1",
+		UserID:     "user1",
+		CustomerID: "cust1",
+		Kind:       model.AccountKindCustomer,
+		Type:       model.AccountTypeLiability,
+		Owners:     primaryOwner("cust1"),
+		Balance:    balance,
+		Currency:   "USD",
+		Status:     model.AccountStatusActive,
+		Tier:       model.AccountTierStandard,
 	}
 
-	// Mock expected DB behavior: QueryRowContext for INSERT returning created_at, updated_at
+	// Mock expected DB behavior: the account and its opening balance are written in one transaction
 	rows := sqlmock.NewRows([]string{"created_at", "updated_at"}).
 		AddRow(time.Now(), time.Now())
 
+	mock.ExpectBegin()
+	expectActiveCustomers(mock, "cust1")
 	mock.ExpectQuery(`INSERT INTO accounts .* RETURNING created_at, updated_at`).
-		WithArgs(acc.ID, acc.UserID, balance.String(), acc.Currency, acc.Status).
+		WithArgs(acc.ID, acc.UserID, acc.CustomerID, balance.String(), acc.Currency, acc.Status, acc.Tier, acc.AutoConvert, "", []byte("{}"), acc.Kind, acc.Type).
 		WillReturnRows(rows)
+	expectOwnerInsert(mock, "acc1", "cust1", model.OwnerRolePrimary)
+	expectOpenPeriod(mock)
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(sqlmock.AnyArg(), acc.ID, balance, transaction.TransactionTypeOpeningBalance, sqlmock.AnyArg(), "USD",
+			transaction.TransactionStatusCompleted, "", balance, sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectCommit()
 
 	// Call the method
-	err = store.Insert(context.Background(), acc)
+	movements, err := store.Insert(context.Background(), acc, "")
 	assert.NoError(t, err)
+	assert.Len(t, movements, 1)
+	opening := movements[0]
+	assert.Equal(t, transaction.TransactionTypeOpeningBalance, opening.Type)
+	assert.Equal(t, int64(1), opening.Sequence)
+	assert.Equal(t, "123.45", opening.Amount.String())
+	assert.Equal(t, opening.CreatedAt.Format(time.DateOnly), opening.ValueDate)
 
 	// Ensure expectations were met
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+
+func TestStore_Insert_Funded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	acc := &model.Account{ID: "acc1", UserID: "user1", Kind: model.AccountKindCustomer, Type: model.AccountTypeLiability,
+		Balance: decimal.NewFromInt(100), Currency: "USD",
+		Status: model.AccountStatusActive, Tier: model.AccountTierStandard, CustomerID: "cust1", Owners: primaryOwner("cust1")}
+
+	mock.ExpectBegin()
+	expectActiveCustomers(mock, "cust1")
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	expectOwnerInsert(mock, "acc1", "cust1", model.OwnerRolePrimary)
+	expectOpenPeriod(mock)
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(sqlmock.AnyArg(), "acc1", decimal.NewFromInt(100), transaction.TransactionTypeOpeningBalance, sqlmock.AnyArg(), "USD",
+			transaction.TransactionStatusCompleted, "", decimal.NewFromInt(100), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+
+	// The funding account is debited with a withdrawal linked to the opening entry
+	mock.ExpectQuery(`SELECT currency, balance, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("funding").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance", "type"}).AddRow("USD", "1000", model.AccountTypeLiability))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(900), "funding").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(sqlmock.AnyArg(), "funding", decimal.NewFromInt(100), transaction.TransactionTypeWithdrawal, sqlmock.AnyArg(), "USD",
+			transaction.TransactionStatusCompleted, sqlmock.AnyArg(), decimal.NewFromInt(900), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(8))
+	mock.ExpectCommit()
+
+	movements, err := store.Insert(context.Background(), acc, "funding")
+	assert.NoError(t, err)
+	assert.Len(t, movements, 2)
+	opening, funding := movements[0], movements[1]
+	assert.Equal(t, transaction.TransactionTypeOpeningBalance, opening.Type)
+	assert.Equal(t, "funding", funding.AccountID)
+	assert.Equal(t, transaction.TransactionTypeWithdrawal, funding.Type)
+	assert.Equal(t, opening.ID, funding.ParentID)
+	assert.Equal(t, int64(8), funding.Sequence)
+	assert.Equal(t, "900", funding.BalanceAfter.String())
+	assert.Equal(t, opening.ValueDate, funding.ValueDate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Insert_FundingCurrencyMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	acc := &model.Account{ID: "acc1", UserID: "user1", Kind: model.AccountKindCustomer, Type: model.AccountTypeLiability,
+		Balance: decimal.NewFromInt(100), Currency: "USD",
+		Status: model.AccountStatusActive, Tier: model.AccountTierStandard, CustomerID: "cust1", Owners: primaryOwner("cust1")}
+
+	mock.ExpectBegin()
+	expectActiveCustomers(mock, "cust1")
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	expectOwnerInsert(mock, "acc1", "cust1", model.OwnerRolePrimary)
+	expectOpenPeriod(mock)
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectQuery(`SELECT currency, balance, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("funding").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance", "type"}).AddRow("EUR", "1000", model.AccountTypeLiability))
+	mock.ExpectRollback()
+
+	_, err = store.Insert(context.Background(), acc, "funding")
+	assert.ErrorIs(t, err, account.ErrFundingCurrencyMismatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Insert_InsufficientFunding(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	acc := &model.Account{ID: "acc1", UserID: "user1", Kind: model.AccountKindCustomer, Type: model.AccountTypeLiability,
+		Balance: decimal.NewFromInt(100), Currency: "USD",
+		Status: model.AccountStatusActive, Tier: model.AccountTierStandard, CustomerID: "cust1", Owners: primaryOwner("cust1")}
+
+	mock.ExpectBegin()
+	expectActiveCustomers(mock, "cust1")
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	expectOwnerInsert(mock, "acc1", "cust1", model.OwnerRolePrimary)
+	expectOpenPeriod(mock)
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectQuery(`SELECT currency, balance, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("funding").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance", "type"}).AddRow("USD", "40", model.AccountTypeLiability))
+	mock.ExpectRollback()
+
+	_, err = store.Insert(context.Background(), acc, "funding")
+	assert.ErrorIs(t, err, account.ErrInsufficientFunding)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Insert_PeriodClosed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	acc := &model.Account{ID: "acc1", UserID: "user1", Kind: model.AccountKindCustomer, Type: model.AccountTypeLiability,
+		Balance: decimal.NewFromInt(100), Currency: "USD",
+		Status: model.AccountStatusActive, Tier: model.AccountTierStandard, CustomerID: "cust1", Owners: primaryOwner("cust1")}
+
+	mock.ExpectBegin()
+	expectActiveCustomers(mock, "cust1")
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	expectOwnerInsert(mock, "acc1", "cust1", model.OwnerRolePrimary)
+	expectOpenPeriod(mock)
+
+	// An opening entry dated into a closed period is refused by the insert's own period check, and
+	// the account is not created
+	mock.ExpectQuery(`INSERT INTO transactions .* FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}))
+	mock.ExpectRollback()
+
+	_, err = store.Insert(context.Background(), acc, "")
+	assert.ErrorIs(t, err, transaction.ErrPeriodClosed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Insert_FundedFromSettlement(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	acc := &model.Account{ID: "acc1", UserID: "user1", Kind: model.AccountKindCustomer, Type: model.AccountTypeLiability,
+		Balance: decimal.NewFromInt(100), Currency: "USD",
+		Status: model.AccountStatusActive, Tier: model.AccountTierStandard, CustomerID: "cust1", Owners: primaryOwner("cust1")}
+
+	mock.ExpectBegin()
+	expectActiveCustomers(mock, "cust1")
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	expectOwnerInsert(mock, "acc1", "cust1", model.OwnerRolePrimary)
+	expectOpenPeriod(mock)
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+
+	// The settlement account is an asset, so it goes negative rather than running out
+	mock.ExpectQuery(`SELECT currency, balance, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("settlement").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance", "type"}).AddRow("USD", "40", model.AccountTypeAsset))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(-60), "settlement").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(5))
+	mock.ExpectCommit()
+
+	_, err = store.Insert(context.Background(), acc, "settlement")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Insert_BlockedCustomer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	acc := &model.Account{ID: "acc1", UserID: "user1", Kind: model.AccountKindCustomer, Type: model.AccountTypeLiability,
+		Currency: "USD", Status: model.AccountStatusActive, Tier: model.AccountTierStandard,
+		CustomerID: "cust1", Owners: append(primaryOwner("cust1"), model.AccountOwner{CustomerID: "cust2", Role: model.OwnerRoleJoint})}
+
+	// Nothing is written when an owner is blocked
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id::text, status FROM customers WHERE id = ANY\(\$1::uuid\[\]\) FOR SHARE`).
+		WithArgs(pq.Array([]string{"cust1", "cust2"})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).
+			AddRow("cust1", model.CustomerStatusActive).
+			AddRow("cust2", model.CustomerStatusBlocked))
+	mock.ExpectRollback()
+
+	_, err = store.Insert(context.Background(), acc, "")
+	assert.ErrorIs(t, err, customer.ErrCustomerNotActive)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Insert_ZeroBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	acc := &model.Account{ID: "acc1", UserID: "user1", Kind: model.AccountKindCustomer, Type: model.AccountTypeLiability,
+		Currency: "USD", Status: model.AccountStatusActive, Tier: model.AccountTierStandard,
+		CustomerID: "cust1", Owners: primaryOwner("cust1")}
+
+	// No opening entry for an account that opens empty
+	mock.ExpectBegin()
+	expectActiveCustomers(mock, "cust1")
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+	expectOwnerInsert(mock, "acc1", "cust1", model.OwnerRolePrimary)
+	mock.ExpectCommit()
+
+	movements, err := store.Insert(context.Background(), acc, "funding")
+	assert.NoError(t, err)
+	assert.Empty(t, movements)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func primaryOwner(customerID string) []model.AccountOwner {
+	return []model.AccountOwner{{CustomerID: customerID, Role: model.OwnerRolePrimary}}
+}
+
+// expectOpenPeriod expects the ledger period to be locked with nothing closed yet.
+func expectOpenPeriod(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+}
+
+func expectActiveCustomers(mock sqlmock.Sqlmock, ids ...string) {
+	rows := sqlmock.NewRows([]string{"id", "status"})
+	for _, id := range ids {
+		rows.AddRow(id, model.CustomerStatusActive)
+	}
+	mock.ExpectQuery(`SELECT id::text, status FROM customers WHERE id = ANY\(\$1::uuid\[\]\) FOR SHARE`).
+		WithArgs(pq.Array(ids)).
+		WillReturnRows(rows)
+}
+
+func expectOwnerInsert(mock sqlmock.Sqlmock, accountID, customerID string, role model.OwnerRole) {
+	mock.ExpectQuery(`INSERT INTO account_owners \(account_id, customer_id, role\) VALUES \(\$1, \$2, \$3\) RETURNING created_at`).
+		WithArgs(accountID, customerID, role).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+}
+
+var ownerColumns = []string{"customer_id", "role", "created_at"}
+
+var accountColumns = []string{"id", "user_id", "customer_id", "balance", "currency", "status", "tier", "auto_convert", "nickname", "metadata", "kind", "type", "system_role", "created_at", "updated_at"}
+
+func TestStore_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT .* FROM accounts WHERE id::text > \$1 AND user_id = \$2 AND currency = \$3 AND metadata @> \$4::jsonb ORDER BY id::text LIMIT \$5`).
+		WithArgs("", "user1", "USD", []byte(`{"purpose":"savings"}`), 10).
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow("acc1", "user1", "cust1", "10", "USD", "active", "standard", false, "Holiday", []byte(`{"purpose":"savings","product":"S1"}`), "customer", "liability", "", now, now))
+
+	accounts, err := store.List(context.Background(), account.ListFilter{
+		UserID:   "user1",
+		Currency: "USD",
+		Metadata: map[string]string{"purpose": "savings"},
+		Limit:    10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.Equal(t, "Holiday", accounts[0].Nickname)
+	assert.Equal(t, map[string]string{"purpose": "savings", "product": "S1"}, accounts[0].Metadata)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Update(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow("acc1", "user1", "cust1", "10", "USD", "active", "standard", false, "", []byte(`{"purpose":"savings"}`), "customer", "liability", "", now, now))
+	mock.ExpectQuery(`UPDATE accounts SET nickname = \$1, metadata = \$2, updated_at = NOW\(\) WHERE id = \$3 RETURNING updated_at`).
+		WithArgs("Rainy day", []byte(`{"product":"S1","purpose":"savings"}`), "acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+	mock.ExpectQuery(`SELECT customer_id::text, role, created_at FROM account_owners WHERE account_id = \$1`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows(ownerColumns).AddRow("cust1", model.OwnerRolePrimary, now))
+	mock.ExpectCommit()
+
+	updated, err := store.Update(context.Background(), "acc1", func(a *model.Account) error {
+		a.Nickname = "Rainy day"
+		a.Metadata["product"] = "S1"
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Rainy day", updated.Nickname)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Update_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = store.Update(context.Background(), "acc1", func(*model.Account) error { return nil })
+	assert.ErrorIs(t, err, account.ErrAccountNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_AddOwner(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow("acc1", "user1", "cust1", "10", "USD", "active", "standard", false, "", []byte(`{}`), "customer", "liability", "", now, now))
+	expectActiveCustomers(mock, "cust2")
+	expectOwnerInsert(mock, "acc1", "cust2", model.OwnerRoleJoint)
+	mock.ExpectQuery(`SELECT customer_id::text, role, created_at FROM account_owners WHERE account_id = \$1`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows(ownerColumns).
+			AddRow("cust1", model.OwnerRolePrimary, now).
+			AddRow("cust2", model.OwnerRoleJoint, now))
+	mock.ExpectCommit()
+
+	updated, err := store.AddOwner(context.Background(), "acc1", model.AccountOwner{CustomerID: "cust2", Role: model.OwnerRoleJoint})
+	assert.NoError(t, err)
+	assert.Len(t, updated.Owners, 2)
+	assert.Equal(t, model.OwnerRoleJoint, updated.Owners[1].Role)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_RemoveOwner_Primary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := account.NewStore(&database.DB{DB: db})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow("acc1", "user1", "cust1", "10", "USD", "active", "standard", false, "", []byte(`{}`), "customer", "liability", "", now, now))
+	mock.ExpectRollback()
+
+	_, err = store.RemoveOwner(context.Background(), "acc1", "cust1")
+	assert.ErrorIs(t, err, account.ErrPrimaryOwner)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
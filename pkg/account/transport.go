@@ -19,9 +19,75 @@ func MakeHandler(ms Service) http.Endpoint {
 		opts...,
 	)
 
+	listAccountsHandler := kithttp.NewServer(
+		makeListAccountsEndpoint(ms),
+		decodeListAccountsRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
 	r := chi.NewRouter()
 
 	r.Method("POST", "/accounts", postAccountHandler)
+	r.Method("GET", "/accounts", listAccountsHandler)
 
 	return http.Endpoint{Pattern: "/accounts", Handler: r}
 }
+
+// MakeAccountHandler serves a single account. The id must be a UUID, so that other paths
+// under /accounts (e.g. /accounts/deposit) still reach their handlers.
+func MakeAccountHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	getAccountHandler := kithttp.NewServer(
+		makeGetAccountEndpoint(ms),
+		decodeAccountIDRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	updateAccountHandler := kithttp.NewServer(
+		makeUpdateAccountEndpoint(ms),
+		decodeUpdateAccountRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("GET", "/accounts/{id}", getAccountHandler)
+	r.Method("PATCH", "/accounts/{id}", updateAccountHandler)
+
+	return http.Endpoint{Pattern: "/accounts/{id:[0-9a-fA-F-]{36}}", Handler: r}
+}
+
+// MakeOwnersHandler adds and removes an account's joint and authorized owners.
+func MakeOwnersHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	addOwnerHandler := kithttp.NewServer(
+		makeAddOwnerEndpoint(ms),
+		decodeAddOwnerRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	removeOwnerHandler := kithttp.NewServer(
+		makeRemoveOwnerEndpoint(ms),
+		decodeRemoveOwnerRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("POST", "/accounts/{id}/owners", addOwnerHandler)
+	r.Method("DELETE", "/accounts/{id}/owners/{customer_id}", removeOwnerHandler)
+
+	// Routed ahead of the transaction handler's /accounts/* catch-all
+	return http.Endpoint{Pattern: "/accounts/{id}/owners*", Handler: r}
+}
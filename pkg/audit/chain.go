@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// maxAppendAttempts bounds the retries when another writer extends the same chain concurrently.
+const maxAppendAttempts = 10
+
+// HashVersion is the version of the field set hashed for new records. Records chained before
+// versioning have version 0, which hashes the whole record.
+const HashVersion = 1
+
+var ErrChainContention = errors.New("audit chain contention")
+
+// Chain appends audit records to per-account hash chains. Each record links to the previous
+// record of its account by hash, and a unique index on the account and chain position makes
+// concurrent writers retry instead of forking the chain.
+type Chain struct {
+	collection *mongo.Collection
+}
+
+func NewChain(collection *mongo.Collection) *Chain {
+	return &Chain{collection: collection}
+}
+
+// Append links txn to the end of its account's chain and saves it.
+func (c *Chain) Append(ctx context.Context, txn model.Transaction) error {
+	txn = normalize(txn)
+
+	for range maxAppendAttempts {
+		head, err := c.head(ctx, txn.AccountID)
+		if err != nil {
+			return err
+		}
+
+		txn.Chain = &model.ChainLink{Seq: head.Seq + 1, PrevHash: head.Hash, Version: HashVersion}
+		if txn.Chain.Hash, err = HashRecord(txn); err != nil {
+			return err
+		}
+
+		_, err = c.collection.InsertOne(ctx, txn)
+		if mongo.IsDuplicateKeyError(err) {
+			continue // Another record took this position
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to save audit record")
+		}
+		return nil
+	}
+	return ErrChainContention
+}
+
+// head returns the last link of the account's chain, the zero link for an empty chain.
+func (c *Chain) head(ctx context.Context, accountID string) (model.ChainLink, error) {
+	var last model.Transaction
+	opts := options.FindOne().SetSort(bson.D{{Key: "chain.seq", Value: -1}})
+
+	err := c.collection.FindOne(ctx, bson.M{"accountid": accountID, "chain": bson.M{"$exists": true}}, opts).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.ChainLink{}, nil
+	}
+	if err != nil {
+		return model.ChainLink{}, errors.Wrap(err, "failed to read audit chain head")
+	}
+	return *last.Chain, nil
+}
+
+// Heads returns the last link of every account's chain, ordered by account.
+func (c *Chain) Heads(ctx context.Context) ([]model.ChainHead, error) {
+	cursor, err := c.collection.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"chain": bson.M{"$exists": true}}},
+		bson.M{"$sort": bson.D{{Key: "accountid", Value: 1}, {Key: "chain.seq", Value: -1}}},
+		bson.M{"$group": bson.M{
+			"_id":  "$accountid",
+			"seq":  bson.M{"$first": "$chain.seq"},
+			"hash": bson.M{"$first": "$chain.hash"},
+		}},
+		bson.M{"$project": bson.M{"_id": 0, "accountid": "$_id", "seq": 1, "hash": 1}},
+		bson.M{"$sort": bson.M{"accountid": 1}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read audit chain heads")
+	}
+	defer cursor.Close(ctx)
+
+	heads := []model.ChainHead{}
+	if err := cursor.All(ctx, &heads); err != nil {
+		return nil, errors.Wrap(err, "failed to decode audit chain heads")
+	}
+	return heads, nil
+}
+
+// hashedFields is the canonical field set of hash version 1. A field added to the record is only
+// hashed under a new version, so existing records keep verifying.
+type hashedFields struct {
+	Version     int    `json:"version"`
+	ID          string `json:"id"`
+	AccountID   string `json:"account_id"`
+	Type        string `json:"type"`
+	Amount      string `json:"amount"`
+	Currency    string `json:"currency"`
+	Status      string `json:"status"`
+	ReferenceID string `json:"reference_id"`
+	CreatedAt   string `json:"created_at"`
+	Seq         int64  `json:"seq"`
+	PrevHash    string `json:"prev_hash"`
+}
+
+// HashRecord returns the hex SHA-256 of the record's fields under its chain link's version. The
+// record must be normalized the way it is stored, so that it hashes the same once read back.
+func HashRecord(txn model.Transaction) (string, error) {
+	if txn.Chain == nil {
+		return "", errors.New("audit record has no chain link")
+	}
+
+	var fields interface{}
+	switch link := *txn.Chain; link.Version {
+	case 0:
+		// The whole record's JSON with the chain hash left empty
+		link.Hash = ""
+		txn.Chain = &link
+		fields = txn
+	case 1:
+		fields = hashedFields{
+			Version:     link.Version,
+			ID:          txn.ID,
+			AccountID:   txn.AccountID,
+			Type:        txn.Type,
+			Amount:      txn.Amount.String(),
+			Currency:    txn.Currency,
+			Status:      txn.Status,
+			ReferenceID: txn.ReferenceID,
+			CreatedAt:   txn.CreatedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+			Seq:         link.Seq,
+			PrevHash:    link.PrevHash,
+		}
+	default:
+		return "", errors.Errorf("unsupported audit hash version %d", link.Version)
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode audit record")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalize drops what MongoDB does not keep: times are stored in UTC with millisecond
+// precision, and empty collections are omitted.
+func normalize(txn model.Transaction) model.Transaction {
+	txn.CreatedAt = txn.CreatedAt.UTC().Truncate(time.Millisecond)
+	if len(txn.Fees) == 0 {
+		txn.Fees = nil
+	}
+	if len(txn.Metadata) == 0 {
+		txn.Metadata = nil
+	}
+	return txn
+}
+
+// EnsureChainIndexes creates the index that orders each account's chain and rejects a second
+// record at the same position. Records saved before chaining have no position and are not indexed.
+func EnsureChainIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "accountid", Value: 1}, {Key: "chain.seq", Value: 1}},
+		Options: options.Index().
+			SetName("audit_chain").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"chain": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create audit chain index")
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package audit_test
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// chain links the records of one account the way Chain.Append does.
+func chain(t *testing.T, txns ...model.Transaction) []model.Transaction {
+	var prev model.ChainLink
+	for i := range txns {
+		txns[i].Chain = &model.ChainLink{Seq: prev.Seq + 1, PrevHash: prev.Hash, Version: audit.HashVersion}
+		hash, err := audit.HashRecord(txns[i])
+		require.NoError(t, err)
+		txns[i].Chain.Hash = hash
+		prev = *txns[i].Chain
+	}
+	return txns
+}
+
+func record(id, amount string) model.Transaction {
+	return model.Transaction{
+		ID:        id,
+		AccountID: "acc1",
+		Type:      "deposit",
+		Amount:    model.Decimal{Decimal: decimal.RequireFromString(amount)},
+		Currency:  "USD",
+		Status:    "completed",
+		Metadata:  map[string]string{"invoice": "42"},
+		CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 123000000, time.UTC),
+	}
+}
+
+func verify(checkpoint *model.AuditCheckpoint, txns ...model.Transaction) ([]audit.Break, audit.VerifySummary) {
+	var breaks []audit.Break
+	v := audit.NewVerifier(checkpoint, func(b audit.Break) { breaks = append(breaks, b) })
+	for _, txn := range txns {
+		v.Add(txn)
+	}
+	return breaks, v.Finish()
+}
+
+func TestHashRecord_StableAcrossBSON(t *testing.T) {
+	txn := chain(t, record("txn1", "10.50"))[0]
+
+	data, err := bson.Marshal(txn)
+	require.NoError(t, err)
+	var stored model.Transaction
+	require.NoError(t, bson.Unmarshal(data, &stored))
+
+	hash, err := audit.HashRecord(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, txn.Chain.Hash, hash)
+}
+
+func TestHashRecord_Versions(t *testing.T) {
+	txn := chain(t, record("txn1", "10.50"))[0]
+
+	// Fields outside the canonical set don't change the hash, so adding one to records keeps them verifying
+	described := txn
+	described.Description = "Invoice 42"
+	hash, err := audit.HashRecord(described)
+	require.NoError(t, err)
+	assert.Equal(t, txn.Chain.Hash, hash)
+
+	// Records chained before versioning hash the whole record and still verify
+	legacy := record("txn1", "10.50")
+	legacy.Chain = &model.ChainLink{Seq: 1}
+	legacy.Chain.Hash, err = audit.HashRecord(legacy)
+	require.NoError(t, err)
+	assert.NotEqual(t, txn.Chain.Hash, legacy.Chain.Hash)
+	next := chain(t, record("txn2", "20"))[0]
+	next.Chain.Seq, next.Chain.PrevHash = 2, legacy.Chain.Hash
+	next.Chain.Hash, err = audit.HashRecord(next)
+	require.NoError(t, err)
+
+	breaks, summary := verify(nil, legacy, next)
+	assert.Empty(t, breaks)
+	assert.Equal(t, audit.VerifySummary{Chains: 1, Records: 2}, summary)
+
+	unknown := *txn.Chain
+	unknown.Version = audit.HashVersion + 1
+	txn.Chain = &unknown
+	_, err = audit.HashRecord(txn)
+	assert.Error(t, err)
+}
+
+func TestVerifier_IntactChain(t *testing.T) {
+	txns := chain(t, record("txn1", "10"), record("txn2", "20"), record("txn3", "30"))
+
+	breaks, summary := verify(nil, txns...)
+	assert.Empty(t, breaks)
+	assert.Equal(t, audit.VerifySummary{Chains: 1, Records: 3}, summary)
+}
+
+func TestVerifier_Breaks(t *testing.T) {
+	tampered := chain(t, record("txn1", "10"), record("txn2", "20"), record("txn3", "30"))
+	tampered[1].Amount = model.Decimal{Decimal: decimal.NewFromInt(2000)}
+
+	breaks, _ := verify(nil, tampered...)
+	assert.Len(t, breaks, 1)
+	assert.Equal(t, audit.BreakHash, breaks[0].Kind)
+	assert.Equal(t, "txn2", breaks[0].TransactionID)
+
+	deleted := chain(t, record("txn1", "10"), record("txn2", "20"), record("txn3", "30"))
+	breaks, _ = verify(nil, deleted[0], deleted[2])
+	assert.Len(t, breaks, 1)
+	assert.Equal(t, audit.BreakSequence, breaks[0].Kind)
+
+	// A record rehashed after editing no longer matches the next record's link
+	rehashed := chain(t, record("txn1", "10"), record("txn2", "20"), record("txn3", "30"))
+	rehashed[1].Amount = model.Decimal{Decimal: decimal.NewFromInt(2000)}
+	rehashed[1].Chain.Hash, _ = audit.HashRecord(rehashed[1])
+	breaks, _ = verify(nil, rehashed...)
+	assert.Len(t, breaks, 1)
+	assert.Equal(t, audit.BreakLink, breaks[0].Kind)
+	assert.Equal(t, "txn3", breaks[0].TransactionID)
+}
+
+func TestVerifier_Unchained(t *testing.T) {
+	legacy := record("txn0", "5")
+	legacy.CreatedAt = legacy.CreatedAt.Add(-time.Hour)
+	txns := chain(t, record("txn1", "10"), record("txn2", "20"))
+
+	// Records saved before the account's chain began can't be verified but are tolerated
+	breaks, summary := verify(nil, append([]model.Transaction{legacy}, txns...)...)
+	assert.Empty(t, breaks)
+	assert.Equal(t, audit.VerifySummary{Chains: 1, Records: 3, Unchained: 1}, summary)
+
+	// So are those of an account that has no chain, whatever their age
+	other := record("txn9", "1")
+	other.AccountID = "acc0"
+	breaks, summary = verify(nil, append([]model.Transaction{other}, txns...)...)
+	assert.Empty(t, breaks)
+	assert.Equal(t, audit.VerifySummary{Chains: 1, Records: 3, Unchained: 1}, summary)
+
+	// A record stripped of its chain, or saved without one later, is sorted first but is as old as the chain
+	stripped := record("txn3", "30")
+	stripped.CreatedAt = stripped.CreatedAt.Add(time.Hour)
+	breaks, summary = verify(nil, append([]model.Transaction{legacy, stripped}, txns...)...)
+	assert.Len(t, breaks, 1)
+	assert.Equal(t, audit.BreakUnchained, breaks[0].Kind)
+	assert.Equal(t, "txn3", breaks[0].TransactionID)
+	assert.Equal(t, audit.VerifySummary{Chains: 1, Records: 4, Unchained: 1, Breaks: 1}, summary)
+
+	// One fed after the chain began is reported as well
+	breaks, _ = verify(nil, txns[0], stripped, txns[1])
+	assert.Len(t, breaks, 1)
+	assert.Equal(t, audit.BreakUnchained, breaks[0].Kind)
+}
+
+func TestVerifier_Checkpoint(t *testing.T) {
+	txns := chain(t, record("txn1", "10"), record("txn2", "20"))
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	cp := audit.NewCheckpoint([]model.ChainHead{{AccountID: "acc1", Seq: 2, Hash: txns[1].Chain.Hash}}, time.Now(), key)
+	breaks, _ := verify(&cp, txns...)
+	assert.Empty(t, breaks)
+
+	// Dropping the checkpointed head and everything after it is caught
+	breaks, _ = verify(&cp, txns[0])
+	assert.Len(t, breaks, 1)
+	assert.Equal(t, audit.BreakCheckpoint, breaks[0].Kind)
+}
+
+func TestCheckpoint_Signature(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	cp := audit.NewCheckpoint([]model.ChainHead{{AccountID: "acc1", Seq: 7, Hash: "abc"}}, time.Now(), key)
+	assert.NoError(t, audit.VerifyCheckpoint(cp))
+
+	edited := cp
+	edited.Heads = []model.ChainHead{{AccountID: "acc1", Seq: 6, Hash: "abc"}}
+	assert.ErrorIs(t, audit.VerifyCheckpoint(edited), audit.ErrInvalidSignature)
+
+	resigned := audit.NewCheckpoint(edited.Heads, cp.CreatedAt, key)
+	resigned.PublicKey = cp.PublicKey
+	resigned.Signature = cp.Signature
+	assert.ErrorIs(t, audit.VerifyCheckpoint(resigned), audit.ErrInvalidSignature)
+}
+
+func TestParseSigningKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	key, err := audit.ParseSigningKey(base64.StdEncoding.EncodeToString(seed))
+	assert.NoError(t, err)
+	assert.Equal(t, ed25519.NewKeyFromSeed(seed), key)
+
+	key, err = audit.ParseSigningKey(base64.StdEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed)))
+	assert.NoError(t, err)
+	assert.Equal(t, ed25519.NewKeyFromSeed(seed), key)
+
+	_, err = audit.ParseSigningKey("c2hvcnQ=")
+	assert.Error(t, err)
+}
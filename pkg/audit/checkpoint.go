@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid checkpoint signature")
+
+// ParseSigningKey reads a base64 ed25519 private key, either the 32-byte seed or the 64-byte key.
+func ParseSigningKey(s string) (ed25519.PrivateKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.Wrap(err, "signing key is not base64")
+	}
+
+	switch len(data) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(data), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(data), nil
+	default:
+		return nil, errors.Errorf("signing key has %d bytes, expected %d or %d", len(data), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+// NewCheckpoint signs the given chain heads as of createdAt.
+func NewCheckpoint(heads []model.ChainHead, createdAt time.Time, key ed25519.PrivateKey) model.AuditCheckpoint {
+	cp := model.AuditCheckpoint{
+		ID:        model.NewUUID(),
+		CreatedAt: createdAt.UTC().Truncate(time.Millisecond),
+		Heads:     heads,
+		Root:      headsRoot(heads),
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+	cp.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, checkpointMessage(cp)))
+	return cp
+}
+
+// VerifyCheckpoint checks that the root matches the heads and that the signature is valid for
+// the checkpoint's public key.
+func VerifyCheckpoint(cp model.AuditCheckpoint) error {
+	if headsRoot(cp.Heads) != cp.Root {
+		return errors.Wrap(ErrInvalidSignature, "root does not match the heads")
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(cp.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return errors.Wrap(ErrInvalidSignature, "malformed public key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(cp.Signature)
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, "malformed signature")
+	}
+
+	if !ed25519.Verify(publicKey, checkpointMessage(cp), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// headsRoot hashes one "account seq hash" line per head, in the order given.
+func headsRoot(heads []model.ChainHead) string {
+	h := sha256.New()
+	for _, head := range heads {
+		fmt.Fprintf(h, "%s %d %s\n", head.AccountID, head.Seq, head.Hash)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func checkpointMessage(cp model.AuditCheckpoint) []byte {
+	return []byte("audit-checkpoint\n" + cp.CreatedAt.UTC().Format(time.RFC3339Nano) + "\n" + cp.Root)
+}
+
+// Checkpoints stores signed checkpoints of the audit chains.
+type Checkpoints struct {
+	chain      *Chain
+	collection *mongo.Collection
+	key        ed25519.PrivateKey
+}
+
+// NewCheckpoints returns the checkpoint store; key may be nil when checkpoints are only read.
+func NewCheckpoints(chain *Chain, collection *mongo.Collection, key ed25519.PrivateKey) *Checkpoints {
+	return &Checkpoints{chain: chain, collection: collection, key: key}
+}
+
+// LoadCheckpoints returns the checkpoint store for the audit records in records, parsing
+// signingKey when one is configured.
+func LoadCheckpoints(records, checkpoints *mongo.Collection, signingKey string) (*Checkpoints, error) {
+	var key ed25519.PrivateKey
+	if signingKey != "" {
+		var err error
+		if key, err = ParseSigningKey(signingKey); err != nil {
+			return nil, err
+		}
+	}
+	return NewCheckpoints(NewChain(records), checkpoints, key), nil
+}
+
+// Create signs the current chain heads and saves the checkpoint.
+func (c *Checkpoints) Create(ctx context.Context) (model.AuditCheckpoint, error) {
+	if c.key == nil {
+		return model.AuditCheckpoint{}, errors.New("no audit signing key configured")
+	}
+
+	heads, err := c.chain.Heads(ctx)
+	if err != nil {
+		return model.AuditCheckpoint{}, err
+	}
+
+	cp := NewCheckpoint(heads, time.Now(), c.key)
+	if _, err := c.collection.InsertOne(ctx, cp); err != nil {
+		return model.AuditCheckpoint{}, errors.Wrap(err, "failed to save audit checkpoint")
+	}
+	return cp, nil
+}
+
+// List returns the checkpoints created at or after since, oldest first.
+func (c *Checkpoints) List(ctx context.Context, since time.Time) ([]model.AuditCheckpoint, error) {
+	opts := options.Find().SetSort(bson.M{"createdat": 1})
+
+	cursor, err := c.collection.Find(ctx, bson.M{"createdat": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read audit checkpoints")
+	}
+	defer cursor.Close(ctx)
+
+	var checkpoints []model.AuditCheckpoint
+	if err := cursor.All(ctx, &checkpoints); err != nil {
+		return nil, errors.Wrap(err, "failed to decode audit checkpoints")
+	}
+	return checkpoints, nil
+}
@@ -0,0 +1,24 @@
+package audit
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type TimelineRequest struct {
+	TransactionID string
+}
+
+func decodeTimelineRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id := chi.URLParam(r, "id")
+	if !model.IsValidUUID(id) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid transaction id"), "transaction id must be a valid UUID", "INVALID_TRANSACTION_ID", http.StatusBadRequest)
+	}
+
+	return TimelineRequest{TransactionID: id}, nil
+}
@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+func makeTimelineEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(TimelineRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+		return s.Timeline(ctx, req.TransactionID)
+	}
+}
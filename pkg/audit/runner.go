@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// CheckpointRunner periodically signs a checkpoint of the audit chains. It implements
+// di.StartCloser so the ledger service starts and stops it with the HTTP server.
+type CheckpointRunner struct {
+	checkpoints *Checkpoints
+	logger      *logging.Logger
+	interval    time.Duration
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func NewCheckpointRunner(checkpoints *Checkpoints, logger *logging.Logger, interval time.Duration) *CheckpointRunner {
+	return &CheckpointRunner{checkpoints: checkpoints, logger: logger, interval: interval}
+}
+
+func (r *CheckpointRunner) Start() error {
+	if r.interval <= 0 || r.checkpoints.key == nil {
+		r.logger.Info("audit checkpoint runner disabled")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			cp, err := r.checkpoints.Create(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.logger.Error("audit checkpoint failed", "error", err)
+			} else if err == nil {
+				r.logger.Info("audit checkpoint created", "id", cp.ID, "chains", len(cp.Heads), "root", cp.Root)
+			}
+		}
+	}()
+
+	r.logger.Info("audit checkpoint runner started", "interval", r.interval)
+	return nil
+}
+
+func (r *CheckpointRunner) Close() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	r.logger.Info("audit checkpoint runner stopped")
+}
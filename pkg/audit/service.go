@@ -0,0 +1,100 @@
+// Package audit records the processor's state transitions for each transaction as an append-only
+// event stream in MongoDB, and serves a transaction's timeline.
+package audit
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/mdshahjahanmiah/explore-go/repository"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http"
+	"time"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+type Service interface {
+	// Record appends an event to its transaction's timeline, stamped with this instance and the current time.
+	Record(ctx context.Context, event model.AuditEvent) error
+	// Received reports whether the transaction already has events, i.e. a delivery of it was seen before.
+	Received(ctx context.Context, transactionID string) (bool, error)
+	Timeline(ctx context.Context, transactionID string) (Timeline, error)
+}
+
+type Timeline struct {
+	TransactionID string             `json:"transaction_id"`
+	Events        []model.AuditEvent `json:"events"`
+}
+
+type service struct {
+	logger   *logging.Logger
+	repo     *repository.Repository[model.AuditEvent]
+	instance string
+}
+
+// NewService returns the audit service of the given processor instance; instance is only used
+// for recording events.
+func NewService(logger *logging.Logger, repo *repository.Repository[model.AuditEvent], instance string) Service {
+	return &service{
+		logger:   logger,
+		repo:     repo,
+		instance: instance,
+	}
+}
+
+func (s *service) Record(ctx context.Context, event model.AuditEvent) error {
+	event.Instance = s.instance
+	event.OccurredAt = time.Now().UTC()
+
+	if _, err := s.repo.Collection.InsertOne(ctx, event); err != nil {
+		return errors.Wrap(err, "failed to record audit event")
+	}
+	return nil
+}
+
+func (s *service) Received(ctx context.Context, transactionID string) (bool, error) {
+	n, err := s.repo.Collection.CountDocuments(ctx, bson.M{"transactionid": transactionID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to count audit events")
+	}
+	return n > 0, nil
+}
+
+func (s *service) Timeline(ctx context.Context, transactionID string) (Timeline, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "occurredat", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := s.repo.Collection.Find(ctx, bson.M{"transactionid": transactionID}, opts)
+	if err != nil {
+		s.logger.Error("failed to read audit events", "transaction_id", transactionID, "error", err)
+		return Timeline{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []model.AuditEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		s.logger.Error("failed to decode audit events", "transaction_id", transactionID, "error", err)
+		return Timeline{}, err
+	}
+
+	if len(events) == 0 {
+		return Timeline{}, eError.NewServiceError(ErrTransactionNotFound, "transaction has no audit events", "TRANSACTION_NOT_FOUND", http.StatusNotFound)
+	}
+	return Timeline{TransactionID: transactionID, Events: events}, nil
+}
+
+// EnsureIndexes creates the index the timeline is read through.
+func EnsureIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "transactionid", Value: 1}, {Key: "occurredat", Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("transaction_timeline"),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create audit event indexes")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/mdshahjahanmiah/explore-go/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const transactionID = "3f0c8a52-9d41-4e7b-8b6a-2c5d1e9f7a10"
+
+func newService(mt *mtest.T) audit.Service {
+	logger := &logging.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	return audit.NewService(logger, &repository.Repository[model.AuditEvent]{Collection: mt.Coll}, "processor-1")
+}
+
+func event(t *testing.T, e model.AuditEvent) bson.D {
+	data, err := bson.Marshal(e)
+	require.NoError(t, err)
+	var doc bson.D
+	require.NoError(t, bson.Unmarshal(data, &doc))
+	return doc
+}
+
+func TestService_Record(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("stamps instance and time", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		before := time.Now().UTC().Truncate(time.Millisecond)
+
+		err := newService(mt).Record(context.Background(), model.AuditEvent{
+			TransactionID: transactionID, AccountID: "acc1", Type: model.AuditEventAttemptFailed, Attempt: 2, Error: "timeout",
+			Instance: "spoofed", OccurredAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+
+		var saved model.AuditEvent
+		insert := mt.GetStartedEvent()
+		require.Equal(t, "insert", insert.CommandName)
+		require.NoError(t, bson.Unmarshal(insert.Command.Lookup("documents").Array().Index(0).Value().Document(), &saved))
+		assert.Equal(t, "processor-1", saved.Instance)
+		assert.False(t, saved.OccurredAt.Before(before))
+		assert.Equal(t, model.AuditEventAttemptFailed, saved.Type)
+		assert.Equal(t, 2, saved.Attempt)
+		assert.Equal(t, "timeout", saved.Error)
+	})
+}
+
+func TestService_Received(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("first delivery", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "ledger.audit_events", mtest.FirstBatch))
+
+		seen, err := newService(mt).Received(context.Background(), transactionID)
+		assert.NoError(t, err)
+		assert.False(t, seen)
+	})
+
+	mt.Run("replay", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "ledger.audit_events", mtest.FirstBatch, bson.D{{Key: "n", Value: int32(1)}}))
+
+		seen, err := newService(mt).Received(context.Background(), transactionID)
+		assert.NoError(t, err)
+		assert.True(t, seen)
+
+		count := mt.GetStartedEvent()
+		assert.Equal(t, "aggregate", count.CommandName)
+	})
+}
+
+func TestTimelineEndpoint(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	occurred := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	get := func(mt *mtest.T, id string) *httptest.ResponseRecorder {
+		endpoint := audit.MakeHandler(newService(mt))
+		w := httptest.NewRecorder()
+		endpoint.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/transactions/"+id+"/events", nil))
+		return w
+	}
+
+	mt.Run("timeline", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "ledger.audit_events", mtest.FirstBatch,
+			event(t, model.AuditEvent{TransactionID: transactionID, Type: model.AuditEventReceived, Instance: "processor-1", OccurredAt: occurred}),
+			event(t, model.AuditEvent{TransactionID: transactionID, Type: model.AuditEventCompleted, Attempt: 1, Instance: "processor-1", OccurredAt: occurred.Add(time.Second)}),
+		))
+
+		w := get(mt, transactionID)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var timeline audit.Timeline
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&timeline))
+		assert.Equal(t, transactionID, timeline.TransactionID)
+		require.Len(t, timeline.Events, 2)
+		assert.Equal(t, model.AuditEventReceived, timeline.Events[0].Type)
+		assert.Equal(t, model.AuditEventCompleted, timeline.Events[1].Type)
+	})
+
+	mt.Run("unknown transaction", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "ledger.audit_events", mtest.FirstBatch))
+
+		w := get(mt, transactionID)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "TRANSACTION_NOT_FOUND")
+	})
+
+	mt.Run("invalid id", func(mt *mtest.T) {
+		w := get(mt, "not-a-uuid")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_TRANSACTION_ID")
+	})
+}
@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(s Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	timelineHandler := kithttp.NewServer(
+		makeTimelineEndpoint(s),
+		decodeTimelineRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("GET", "/transactions/{id}/events", timelineHandler)
+
+	return http.Endpoint{Pattern: "/transactions/{id}/events", Handler: r}
+}
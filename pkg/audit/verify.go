@@ -0,0 +1,210 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type BreakKind string
+
+const (
+	BreakHash       BreakKind = "hash_mismatch"       // the record's content does not match its hash
+	BreakLink       BreakKind = "link_mismatch"       // prev_hash is not the previous record's hash
+	BreakSequence   BreakKind = "sequence_gap"        // chain positions are not contiguous from 1
+	BreakCheckpoint BreakKind = "checkpoint_mismatch" // a checkpointed head is missing or has another hash
+	BreakSignature  BreakKind = "checkpoint_signature"
+	BreakUnchained  BreakKind = "unchained" // a record without a chain position saved after its account's chain began
+)
+
+// Break is one place where an audit chain or checkpoint does not verify.
+type Break struct {
+	Kind          BreakKind
+	AccountID     string
+	Seq           int64
+	TransactionID string
+	Detail        string
+}
+
+func (b Break) String() string {
+	s := fmt.Sprintf("%s account=%s seq=%d", b.Kind, b.AccountID, b.Seq)
+	if b.TransactionID != "" {
+		s += " transaction=" + b.TransactionID
+	}
+	return s + " " + b.Detail
+}
+
+// VerifySummary counts what a verification checked and found.
+type VerifySummary struct {
+	Chains    int
+	Records   int
+	Unchained int // Records saved before their account's chain began, which can't be verified
+	Breaks    int
+}
+
+// Verifier checks audit records fed to it in chain order: grouped by account, by position
+// within an account, records without a position first. Heads of the given checkpoint, if any,
+// must be found with the same hash. A record without a position is only tolerated when it was
+// created before its account's first chained record, as chaining can't have been skipped since.
+type Verifier struct {
+	report    func(Break)
+	heads     map[string]model.ChainHead
+	account   string
+	chained   bool                // the account's chain has begun
+	unchained []model.Transaction // the account's records without a position, until its chain begins
+	last      model.ChainLink
+	summary   VerifySummary
+}
+
+func NewVerifier(checkpoint *model.AuditCheckpoint, report func(Break)) *Verifier {
+	v := &Verifier{report: report, heads: map[string]model.ChainHead{}}
+	if checkpoint != nil {
+		for _, head := range checkpoint.Heads {
+			v.heads[head.AccountID] = head
+		}
+	}
+	return v
+}
+
+func (v *Verifier) found(b Break) {
+	v.summary.Breaks++
+	v.report(b)
+}
+
+// Add checks the next record.
+func (v *Verifier) Add(txn model.Transaction) {
+	v.summary.Records++
+	if v.summary.Records == 1 || txn.AccountID != v.account {
+		v.checkUnchained(time.Time{})
+		v.account = txn.AccountID
+		v.chained = false
+		v.last = model.ChainLink{}
+	}
+
+	if txn.Chain == nil {
+		if v.chained {
+			v.found(Break{Kind: BreakUnchained, AccountID: txn.AccountID, TransactionID: txn.ID, Detail: "saved after the chain began"})
+			return
+		}
+		v.unchained = append(v.unchained, txn)
+		return
+	}
+
+	if !v.chained {
+		v.summary.Chains++
+		v.chained = true
+		v.checkUnchained(txn.CreatedAt)
+	}
+
+	link := *txn.Chain
+	brk := Break{AccountID: txn.AccountID, Seq: link.Seq, TransactionID: txn.ID}
+
+	if link.Seq != v.last.Seq+1 {
+		brk.Kind, brk.Detail = BreakSequence, fmt.Sprintf("expected seq %d", v.last.Seq+1)
+		v.found(brk)
+	} else if link.PrevHash != v.last.Hash {
+		brk.Kind, brk.Detail = BreakLink, fmt.Sprintf("prev_hash %s, previous record hash %s", link.PrevHash, v.last.Hash)
+		v.found(brk)
+	}
+
+	if hash, err := HashRecord(normalize(txn)); err != nil || hash != link.Hash {
+		brk.Kind, brk.Detail = BreakHash, fmt.Sprintf("stored %s, computed %s", link.Hash, hash)
+		v.found(brk)
+	}
+
+	if head, ok := v.heads[txn.AccountID]; ok && head.Seq == link.Seq {
+		if head.Hash != link.Hash {
+			brk.Kind, brk.Detail = BreakCheckpoint, fmt.Sprintf("checkpoint hash %s, record hash %s", head.Hash, link.Hash)
+			v.found(brk)
+		}
+		delete(v.heads, txn.AccountID)
+	}
+
+	v.last = link
+}
+
+// checkUnchained counts the account's records without a position that were created before its
+// chain began at start, and reports those created since. All of them are counted when start is
+// zero, the account having no chain.
+func (v *Verifier) checkUnchained(start time.Time) {
+	for _, txn := range v.unchained {
+		if start.IsZero() || txn.CreatedAt.Before(start) {
+			v.summary.Unchained++
+			continue
+		}
+		v.found(Break{Kind: BreakUnchained, AccountID: txn.AccountID, TransactionID: txn.ID,
+			Detail: fmt.Sprintf("created %s, after the chain began %s", txn.CreatedAt.Format(time.RFC3339Nano), start.Format(time.RFC3339Nano))})
+	}
+	v.unchained = nil
+}
+
+// Finish reports checkpointed heads that no record matched and returns the summary.
+func (v *Verifier) Finish() VerifySummary {
+	v.checkUnchained(time.Time{})
+	for _, head := range v.heads {
+		v.found(Break{Kind: BreakCheckpoint, AccountID: head.AccountID, Seq: head.Seq, Detail: "checkpointed record is missing"})
+	}
+	v.heads = map[string]model.ChainHead{}
+	return v.summary
+}
+
+// Verify walks every chain and checks the signatures of the checkpoints, matching the chains
+// against the latest one. With a signing key, checkpoints must also be signed by it. Each break
+// is reported as it is found.
+func (c *Checkpoints) Verify(ctx context.Context, report func(Break)) (VerifySummary, error) {
+	checkpoints, err := c.List(ctx, time.Time{})
+	if err != nil {
+		return VerifySummary{}, err
+	}
+
+	var publicKey string
+	if c.key != nil {
+		publicKey = base64.StdEncoding.EncodeToString(c.key.Public().(ed25519.PublicKey))
+	}
+
+	var signatureBreaks int
+	for _, cp := range checkpoints {
+		err := VerifyCheckpoint(cp)
+		if err == nil && publicKey != "" && cp.PublicKey != publicKey {
+			err = errors.New("signed by another key")
+		}
+		if err != nil {
+			signatureBreaks++
+			report(Break{Kind: BreakSignature, Detail: fmt.Sprintf("checkpoint %s: %v", cp.ID, err)})
+		}
+	}
+
+	var latest *model.AuditCheckpoint
+	if len(checkpoints) > 0 {
+		latest = &checkpoints[len(checkpoints)-1]
+	}
+	verifier := NewVerifier(latest, report)
+
+	opts := options.Find().SetSort(bson.D{{Key: "accountid", Value: 1}, {Key: "chain.seq", Value: 1}})
+	cursor, err := c.chain.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return VerifySummary{}, errors.Wrap(err, "failed to read audit records")
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var txn model.Transaction
+		if err := cursor.Decode(&txn); err != nil {
+			return VerifySummary{}, errors.Wrap(err, "failed to decode audit record")
+		}
+		verifier.Add(txn)
+	}
+	if err := cursor.Err(); err != nil {
+		return VerifySummary{}, errors.Wrap(err, "failed to read audit records")
+	}
+
+	summary := verifier.Finish()
+	summary.Breaks += signatureBreaks
+	return summary, nil
+}
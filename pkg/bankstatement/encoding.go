@@ -0,0 +1,167 @@
+package bankstatement
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxStatementSize = 10 << 20 // Largest statement file accepted, in bytes
+	DefaultListLimit = 100
+	MaxListLimit     = 500
+)
+
+type ImportRequest struct {
+	Format string
+	Data   []byte
+}
+
+type MatchRequest struct {
+	LineID        string `json:"-"`
+	TransactionID string `json:"transaction_id"`
+}
+
+// contentFormats maps Content-Type media types to statement formats for uploads without a format parameter.
+var contentFormats = map[string]string{
+	"application/xml":                       FormatCamt053,
+	"text/xml":                              FormatCamt053,
+	"application/vnd.iso20022.camt.053+xml": FormatCamt053,
+	"text/csv":                              FormatCSV,
+	"application/x-mt940":                   FormatMT940,
+}
+
+func decodeImportRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil {
+			format = contentFormats[mediaType]
+		}
+	}
+	if !IsFormat(format) {
+		return nil, eError.NewServiceError(
+			errors.Errorf("unsupported format %q", format), "format must be camt053, mt940 or csv", "UNSUPPORTED_FORMAT", http.StatusBadRequest)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxStatementSize+1))
+	if err != nil {
+		slog.Error("read bank statement", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+	if len(data) > MaxStatementSize {
+		return nil, eError.NewServiceError(
+			errors.New("statement too large"), fmt.Sprintf("statement must not exceed %d bytes", MaxStatementSize), "INVALID_PAYLOAD", http.StatusRequestEntityTooLarge)
+	}
+	if len(data) == 0 {
+		return nil, eError.NewServiceError(
+			errors.New("empty statement"), "statement file is required", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	return ImportRequest{Format: format, Data: data}, nil
+}
+
+func decodeGetStatementRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id := chi.URLParam(r, "id")
+	if !model.IsValidUUID(id) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid statement id"), "statement id must be a valid UUID", "INVALID_STATEMENT_ID", http.StatusBadRequest)
+	}
+	return id, nil
+}
+
+func decodeAutoMatchRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeFilterRequest reads currency, account_id, from and to (YYYY-MM-DD, inclusive) and limit.
+func decodeFilterRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	filter := Filter{
+		Currency:  strings.ToUpper(query.Get("currency")),
+		AccountID: query.Get("account_id"),
+		Limit:     DefaultListLimit,
+	}
+
+	if filter.Currency != "" && !model.IsKnownCurrency(filter.Currency) {
+		return nil, eError.NewServiceError(
+			errors.Errorf("unknown currency %q", filter.Currency), "currency must be a supported ISO 4217 code", "MISSING_CURRENCY", http.StatusBadRequest)
+	}
+	if filter.AccountID != "" && !model.IsValidUUID(filter.AccountID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid account id"), "account id must be a valid UUID", "MISSING_ACCOUNT_ID", http.StatusBadRequest)
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, eError.NewServiceError(err, "from must be a date in YYYY-MM-DD format", "INVALID_DATE_RANGE", http.StatusBadRequest)
+		}
+		filter.From = &from
+	}
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, eError.NewServiceError(err, "to must be a date in YYYY-MM-DD format", "INVALID_DATE_RANGE", http.StatusBadRequest)
+		}
+		end := to.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, eError.NewServiceError(
+			errors.New("to is before from"), "to must not be before from", "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxListLimit {
+			return nil, eError.NewServiceError(
+				errors.New("invalid limit"), fmt.Sprintf("limit must be an integer between 1 and %d", MaxListLimit), "INVALID_LIMIT", http.StatusBadRequest)
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+func decodeMatchRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req MatchRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode match request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	req.LineID = chi.URLParam(r, "id")
+	if !model.IsValidUUID(req.LineID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid line id"), "line id must be a valid UUID", "INVALID_LINE_ID", http.StatusBadRequest)
+	}
+	if !model.IsValidUUID(req.TransactionID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid transaction id"), "transaction_id must be a valid UUID", "INVALID_TRANSACTION_ID", http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+func decodeUnmatchRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id := chi.URLParam(r, "id")
+	if !model.IsValidUUID(id) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid line id"), "line id must be a valid UUID", "INVALID_LINE_ID", http.StatusBadRequest)
+	}
+	return id, nil
+}
@@ -0,0 +1,81 @@
+package bankstatement
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+func makeImportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(ImportRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.Import(ctx, req.Format, req.Data)
+	}
+}
+
+func makeGetStatementEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		id, ok := request.(string)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.GetStatement(ctx, id)
+	}
+}
+
+func makeAutoMatchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return s.AutoMatch(ctx)
+	}
+}
+
+func makeUnmatchedLinesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		filter, ok := request.(Filter)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.UnmatchedLines(ctx, filter)
+	}
+}
+
+func makeUnmatchedTransactionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		filter, ok := request.(Filter)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.UnmatchedTransactions(ctx, filter)
+	}
+}
+
+func makeMatchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(MatchRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.MatchLine(ctx, req.LineID, req.TransactionID)
+	}
+}
+
+func makeUnmatchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		lineID, ok := request.(string)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.UnmatchLine(ctx, lineID)
+	}
+}
@@ -0,0 +1,100 @@
+package bankstatement
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+var ErrMatchMismatch = errors.New("transaction does not settle the statement line")
+
+// uuidPattern finds UUIDs with or without hyphens, as banks often drop them to fit 35 characters.
+var uuidPattern = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{12}\b`)
+
+// References returns the UUIDs named in the line's references and description, in canonical form.
+// A ledger transaction is matched by reference when one of them is its id or reference_id.
+func References(line model.BankStatementLine) []string {
+	seen := make(map[string]bool)
+	var refs []string
+	for _, text := range []string{line.Reference, line.BankReference, line.Description} {
+		for _, match := range uuidPattern.FindAllString(text, -1) {
+			id, err := uuid.Parse(match)
+			if err != nil || seen[id.String()] {
+				continue
+			}
+			seen[id.String()] = true
+			refs = append(refs, id.String())
+		}
+	}
+	return refs
+}
+
+// TransactionType is the type of ledger transaction that settles the line: a deposit for money
+// received, a withdrawal for money paid out.
+func TransactionType(line model.BankStatementLine) string {
+	if line.Amount.IsNegative() {
+		return transaction.TransactionTypeWithdrawal
+	}
+	return transaction.TransactionTypeDeposit
+}
+
+// Window is the creation time range, [from, to), of the transactions that may settle the line
+// without a reference: its booking day widened by tolerance on either side.
+func Window(line model.BankStatementLine, tolerance time.Duration) (time.Time, time.Time) {
+	day := truncateDay(line.BookingDate)
+	return day.Add(-tolerance), day.AddDate(0, 0, 1).Add(tolerance)
+}
+
+// Compatible checks that txn is a completed deposit or withdrawal moving the line's amount and
+// currency in the line's direction. Errors wrap ErrMatchMismatch.
+func Compatible(line model.BankStatementLine, txn model.Transaction) error {
+	switch {
+	case txn.Status != transaction.TransactionStatusCompleted:
+		return errors.Wrapf(ErrMatchMismatch, "transaction is %s", txn.Status)
+	case txn.ParentID != "":
+		return errors.Wrap(ErrMatchMismatch, "transaction was posted on behalf of another transaction")
+	case txn.Type != TransactionType(line):
+		return errors.Wrapf(ErrMatchMismatch, "line needs a %s, transaction is a %s", TransactionType(line), txn.Type)
+	case txn.Currency != line.Currency:
+		return errors.Wrapf(ErrMatchMismatch, "line is in %s, transaction in %s", line.Currency, txn.Currency)
+	case !txn.Amount.Equal(line.Amount.Abs()):
+		return errors.Wrapf(ErrMatchMismatch, "line amount %s, transaction amount %s", line.Amount.Abs(), txn.Amount)
+	}
+	return nil
+}
+
+// Match picks the candidate that settles the line. A compatible candidate named by one of the line's
+// references wins; otherwise the line matches only when a single compatible candidate was created
+// within the tolerance window. Anything ambiguous is left for manual matching.
+func Match(line model.BankStatementLine, candidates []model.Transaction, tolerance time.Duration) (model.Transaction, string, bool) {
+	refs := make(map[string]bool)
+	for _, ref := range References(line) {
+		refs[ref] = true
+	}
+	from, to := Window(line, tolerance)
+
+	var byReference, byAmount []model.Transaction
+	for _, txn := range candidates {
+		if Compatible(line, txn) != nil {
+			continue
+		}
+		if refs[txn.ID] || refs[txn.ReferenceID] {
+			byReference = append(byReference, txn)
+		} else if !txn.CreatedAt.Before(from) && txn.CreatedAt.Before(to) {
+			byAmount = append(byAmount, txn)
+		}
+	}
+
+	switch {
+	case len(byReference) == 1:
+		return byReference[0], model.MatchReference, true
+	case len(byReference) == 0 && len(byAmount) == 1:
+		return byAmount[0], model.MatchAmount, true
+	default:
+		return model.Transaction{}, "", false
+	}
+}
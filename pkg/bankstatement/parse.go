@@ -0,0 +1,510 @@
+package bankstatement
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FormatCamt053 = "camt053"
+	FormatMT940   = "mt940"
+	FormatCSV     = "csv"
+)
+
+var ErrInvalidStatement = errors.New("invalid bank statement")
+
+// maxReferenceLength is the longest reference stored on a statement line.
+const maxReferenceLength = 140
+
+var parsers = map[string]func(data []byte) (model.BankStatement, error){
+	FormatCamt053: parseCamt053,
+	FormatMT940:   parseMT940,
+	FormatCSV:     parseCSV,
+}
+
+// IsFormat reports whether statements in format can be imported.
+func IsFormat(format string) bool {
+	_, ok := parsers[format]
+	return ok
+}
+
+// Parse reads a statement file holding a single statement. Errors wrap ErrInvalidStatement.
+func Parse(format string, data []byte) (model.BankStatement, error) {
+	parse, ok := parsers[format]
+	if !ok {
+		return model.BankStatement{}, errors.Wrapf(ErrInvalidStatement, "unsupported format %q", format)
+	}
+
+	st, err := parse(data)
+	if err != nil {
+		return model.BankStatement{}, errors.Wrap(ErrInvalidStatement, err.Error())
+	}
+	if len(st.Lines) == 0 {
+		return model.BankStatement{}, errors.Wrap(ErrInvalidStatement, "statement has no booked lines")
+	}
+
+	for i := range st.Lines {
+		line := &st.Lines[i]
+		line.LineNumber = i + 1
+		if !model.IsKnownCurrency(line.Currency) {
+			return model.BankStatement{}, errors.Wrapf(ErrInvalidStatement, "line %d: unknown currency %q", line.LineNumber, line.Currency)
+		}
+		if line.Amount.IsZero() {
+			return model.BankStatement{}, errors.Wrapf(ErrInvalidStatement, "line %d: amount is zero", line.LineNumber)
+		}
+		if line.BookingDate.IsZero() {
+			return model.BankStatement{}, errors.Wrapf(ErrInvalidStatement, "line %d: missing booking date", line.LineNumber)
+		}
+		if line.ValueDate.IsZero() {
+			line.ValueDate = line.BookingDate
+		}
+		line.Reference = truncate(line.Reference, maxReferenceLength)
+		line.BankReference = truncate(line.BankReference, maxReferenceLength)
+	}
+
+	st.Format = format
+	return st, nil
+}
+
+// camt.053 elements read on import. Tags carry no namespace so every version of the message matches.
+type camtImport struct {
+	Statements []struct {
+		ID      string `xml:"Id"`
+		Account struct {
+			IBAN     string `xml:"Id>IBAN"`
+			Other    string `xml:"Id>Othr>Id"`
+			Currency string `xml:"Ccy"`
+		} `xml:"Acct"`
+		Balances []struct {
+			Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+			Amount    camtAmount `xml:"Amt"`
+			Indicator string     `xml:"CdtDbtInd"`
+		} `xml:"Bal"`
+		Entries []camtImportEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtImportEntry struct {
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Status    struct {
+		Value string `xml:",chardata"` // camt.053.001.02 to .07
+		Code  string `xml:"Cd"`        // camt.053.001.08 onwards
+	} `xml:"Sts"`
+	BookingDate camtDate `xml:"BookgDt"`
+	ValueDate   camtDate `xml:"ValDt"`
+	ServicerRef string   `xml:"AcctSvcrRef"`
+	Details     []struct {
+		EndToEndID string   `xml:"Refs>EndToEndId"`
+		Debtor     string   `xml:"RltdPties>Dbtr>Nm"`
+		DebtorIBAN string   `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+		DebtorAcct string   `xml:"RltdPties>DbtrAcct>Id>Othr>Id"`
+		Creditor   string   `xml:"RltdPties>Cdtr>Nm"`
+		CredIBAN   string   `xml:"RltdPties>CdtrAcct>Id>IBAN"`
+		CredAcct   string   `xml:"RltdPties>CdtrAcct>Id>Othr>Id"`
+		Remittance []string `xml:"RmtInf>Ustrd"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// parseCamt053 reads the booked entries of an ISO 20022 bank-to-customer statement. Only the first
+// transaction details of a batch booking are read.
+func parseCamt053(data []byte) (model.BankStatement, error) {
+	var doc camtImport
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return model.BankStatement{}, errors.Wrap(err, "malformed camt.053")
+	}
+	if len(doc.Statements) != 1 {
+		return model.BankStatement{}, errors.Errorf("expected one statement, found %d", len(doc.Statements))
+	}
+
+	s := doc.Statements[0]
+	st := model.BankStatement{
+		Reference: strings.TrimSpace(s.ID),
+		Account:   firstOf(s.Account.IBAN, s.Account.Other),
+		Currency:  strings.TrimSpace(s.Account.Currency),
+	}
+
+	for _, bal := range s.Balances {
+		amount, err := parseCamtAmount(bal.Amount.Value, bal.Indicator)
+		if err != nil {
+			return model.BankStatement{}, errors.Wrapf(err, "balance %s", bal.Type)
+		}
+		switch bal.Type {
+		case "OPBD", "PRCD":
+			st.OpeningBalance = decimal.NewNullDecimal(amount)
+		case "CLBD":
+			st.ClosingBalance = decimal.NewNullDecimal(amount)
+		}
+		if st.Currency == "" {
+			st.Currency = bal.Amount.Currency
+		}
+	}
+
+	for i, e := range s.Entries {
+		if status := firstOf(e.Status.Code, e.Status.Value); status != "BOOK" {
+			continue
+		}
+
+		amount, err := parseCamtAmount(e.Amount.Value, e.Indicator)
+		if err != nil {
+			return model.BankStatement{}, errors.Wrapf(err, "entry %d", i+1)
+		}
+		booked, err := e.BookingDate.parse()
+		if err != nil {
+			return model.BankStatement{}, errors.Wrapf(err, "entry %d booking date", i+1)
+		}
+		valued, err := e.ValueDate.parse()
+		if err != nil {
+			return model.BankStatement{}, errors.Wrapf(err, "entry %d value date", i+1)
+		}
+
+		line := model.BankStatementLine{
+			BookingDate:   booked,
+			ValueDate:     valued,
+			Amount:        amount,
+			Currency:      firstOf(e.Amount.Currency, st.Currency),
+			BankReference: strings.TrimSpace(e.ServicerRef),
+		}
+		if len(e.Details) > 0 {
+			d := e.Details[0]
+			if ref := strings.TrimSpace(d.EndToEndID); ref != "NOTPROVIDED" {
+				line.Reference = ref
+			}
+			line.Description = strings.Join(d.Remittance, " ")
+			if amount.IsPositive() {
+				line.Counterparty = counterparty(d.Debtor, firstOf(d.DebtorIBAN, d.DebtorAcct))
+			} else {
+				line.Counterparty = counterparty(d.Creditor, firstOf(d.CredIBAN, d.CredAcct))
+			}
+		}
+		st.Lines = append(st.Lines, line)
+	}
+	return st, nil
+}
+
+func parseCamtAmount(value, indicator string) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return decimal.Decimal{}, errors.Errorf("invalid amount %q", value)
+	}
+	switch indicator {
+	case "CRDT":
+		return amount, nil
+	case "DBIT":
+		return amount.Neg(), nil
+	default:
+		return decimal.Decimal{}, errors.Errorf("invalid credit/debit indicator %q", indicator)
+	}
+}
+
+func (d camtDate) parse() (time.Time, error) {
+	if d.Date != "" {
+		return time.Parse(time.DateOnly, strings.TrimSpace(d.Date))
+	}
+	if d.DateTime != "" {
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(d.DateTime))
+		if err != nil {
+			// ISO 20022 allows local date times without an offset
+			t, err = time.Parse("2006-01-02T15:04:05", strings.TrimSpace(d.DateTime))
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+		return truncateDay(t), nil
+	}
+	return time.Time{}, nil
+}
+
+var (
+	mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+
+	// :61: value date, entry date, debit/credit mark, funds code, amount, type, references
+	mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?(\d+,\d*)([NFS][A-Z0-9]{3})(.*)$`)
+
+	// :60F:, :60M:, :62F: and :62M: balances: debit/credit mark, date, currency, amount
+	mt940Balance = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+)
+
+// parseMT940 reads a SWIFT MT940 customer statement message, with or without the block wrapper.
+// The :86: information following a :61: line becomes its description.
+func parseMT940(data []byte) (model.BankStatement, error) {
+	type field struct{ tag, value string }
+	var fields []field
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r ")
+		if m := mt940Tag.FindStringSubmatch(text); m != nil {
+			fields = append(fields, field{tag: m[1], value: m[2]})
+			continue
+		}
+		if text == "" || strings.HasPrefix(text, "-") || strings.HasPrefix(text, "{") || len(fields) == 0 {
+			continue
+		}
+		fields[len(fields)-1].value += "\n" + text
+	}
+	if err := scanner.Err(); err != nil {
+		return model.BankStatement{}, err
+	}
+
+	var st model.BankStatement
+	var line *model.BankStatementLine
+	statements := 0
+	for _, f := range fields {
+		switch f.tag {
+		case "20":
+			statements++
+			st.Reference = strings.TrimSpace(f.value)
+		case "25":
+			st.Account = strings.TrimSpace(f.value)
+		case "60F", "60M":
+			currency, amount, err := parseMT940Balance(f.value)
+			if err != nil {
+				return model.BankStatement{}, errors.Wrapf(err, ":%s:", f.tag)
+			}
+			st.Currency = currency
+			st.OpeningBalance = decimal.NewNullDecimal(amount)
+		case "62F", "62M":
+			_, amount, err := parseMT940Balance(f.value)
+			if err != nil {
+				return model.BankStatement{}, errors.Wrapf(err, ":%s:", f.tag)
+			}
+			st.ClosingBalance = decimal.NewNullDecimal(amount)
+		case "61":
+			l, err := parseMT940Line(f.value, st.Currency)
+			if err != nil {
+				return model.BankStatement{}, errors.Wrapf(err, ":61: line %d", len(st.Lines)+1)
+			}
+			st.Lines = append(st.Lines, l)
+			line = &st.Lines[len(st.Lines)-1]
+			continue
+		case "86":
+			if line != nil {
+				line.Description = strings.Join(strings.Fields(f.value), " ")
+			}
+		}
+		line = nil
+	}
+
+	if statements != 1 {
+		return model.BankStatement{}, errors.Errorf("expected one statement, found %d", statements)
+	}
+	if st.Currency == "" {
+		return model.BankStatement{}, errors.New("missing opening balance :60F:")
+	}
+	return st, nil
+}
+
+func parseMT940Balance(value string) (string, decimal.Decimal, error) {
+	m := mt940Balance.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return "", decimal.Decimal{}, errors.Errorf("malformed balance %q", value)
+	}
+	amount, err := parseMT940Amount(m[4])
+	if err != nil {
+		return "", decimal.Decimal{}, err
+	}
+	if m[1] == "D" {
+		amount = amount.Neg()
+	}
+	return m[3], amount, nil
+}
+
+func parseMT940Line(value, currency string) (model.BankStatementLine, error) {
+	first, supplementary, _ := strings.Cut(value, "\n")
+	m := mt940Line.FindStringSubmatch(first)
+	if m == nil {
+		return model.BankStatementLine{}, errors.Errorf("malformed statement line %q", first)
+	}
+
+	valued, err := time.Parse("060102", m[1])
+	if err != nil {
+		return model.BankStatementLine{}, err
+	}
+	booked := valued
+	if m[2] != "" {
+		if booked, err = mt940EntryDate(valued, m[2]); err != nil {
+			return model.BankStatementLine{}, err
+		}
+	}
+
+	amount, err := parseMT940Amount(m[5])
+	if err != nil {
+		return model.BankStatementLine{}, err
+	}
+	// Debits and reversals of credits take money out of the account
+	if m[3] == "D" || m[3] == "RC" {
+		amount = amount.Neg()
+	}
+
+	reference, bankReference, _ := strings.Cut(m[7], "//")
+	if reference == "NONREF" {
+		reference = ""
+	}
+
+	return model.BankStatementLine{
+		BookingDate:   booked,
+		ValueDate:     valued,
+		Amount:        amount,
+		Currency:      currency,
+		Reference:     strings.TrimSpace(reference),
+		BankReference: strings.TrimSpace(bankReference),
+		Description:   strings.Join(strings.Fields(supplementary), " "),
+	}, nil
+}
+
+// mt940EntryDate reads the MMDD entry date in the year that puts it closest to the value date.
+func mt940EntryDate(valued time.Time, mmdd string) (time.Time, error) {
+	entry, err := time.Parse("0102", mmdd)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	best := time.Time{}
+	for _, year := range []int{valued.Year() - 1, valued.Year(), valued.Year() + 1} {
+		t := time.Date(year, entry.Month(), entry.Day(), 0, 0, 0, 0, time.UTC)
+		if best.IsZero() || absDuration(t.Sub(valued)) < absDuration(best.Sub(valued)) {
+			best = t
+		}
+	}
+	return best, nil
+}
+
+func parseMT940Amount(s string) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(strings.TrimSuffix(strings.Replace(s, ",", ".", 1), "."))
+	if err != nil {
+		return decimal.Decimal{}, errors.Errorf("invalid amount %q", s)
+	}
+	return amount, nil
+}
+
+// parseCSV reads a CSV export whose header row names the columns booking_date (or date), amount and
+// currency, and optionally value_date, reference, bank_reference, description, counterparty_name and
+// counterparty_account, in any order and case, with spaces for underscores. Amounts are signed, dates
+// are YYYY-MM-DD, and other columns are ignored.
+func parseCSV(data []byte) (model.BankStatement, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return model.BankStatement{}, errors.Wrap(err, "missing header row")
+	}
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")] = i
+	}
+	if _, ok := index["booking_date"]; !ok {
+		if i, ok := index["date"]; ok {
+			index["booking_date"] = i
+		}
+	}
+	for _, name := range []string{"booking_date", "amount", "currency"} {
+		if _, ok := index[name]; !ok {
+			return model.BankStatement{}, errors.Errorf("missing column %s", name)
+		}
+	}
+
+	var st model.BankStatement
+	for row := 2; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return model.BankStatement{}, err
+		}
+
+		get := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		line := model.BankStatementLine{
+			Currency:      strings.ToUpper(get("currency")),
+			Reference:     get("reference"),
+			BankReference: get("bank_reference"),
+			Description:   get("description"),
+			Counterparty:  counterparty(get("counterparty_name"), get("counterparty_account")),
+		}
+		if line.BookingDate, err = time.Parse(time.DateOnly, get("booking_date")); err != nil {
+			return model.BankStatement{}, fmt.Errorf("row %d: booking date must be YYYY-MM-DD", row)
+		}
+		if v := get("value_date"); v != "" {
+			if line.ValueDate, err = time.Parse(time.DateOnly, v); err != nil {
+				return model.BankStatement{}, fmt.Errorf("row %d: value date must be YYYY-MM-DD", row)
+			}
+		}
+		if line.Amount, err = decimal.NewFromString(get("amount")); err != nil {
+			return model.BankStatement{}, fmt.Errorf("row %d: invalid amount %q", row, get("amount"))
+		}
+
+		// The statement currency is only known when every line agrees on it
+		if row == 2 {
+			st.Currency = line.Currency
+		} else if st.Currency != line.Currency {
+			st.Currency = ""
+		}
+		st.Lines = append(st.Lines, line)
+	}
+	return st, nil
+}
+
+func counterparty(name, account string) *model.Counterparty {
+	name, account = strings.TrimSpace(name), strings.TrimSpace(account)
+	if name == "" && account == "" {
+		return nil
+	}
+	return &model.Counterparty{Name: truncate(name, model.MaxCounterpartyNameLength), Account: truncate(account, model.MaxCounterpartyAccountLength)}
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,161 @@
+package bankstatement_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/bankstatement"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFile(t *testing.T, format, name string) model.BankStatement {
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+
+	st, err := bankstatement.Parse(format, data)
+	require.NoError(t, err)
+	return st
+}
+
+func day(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+// Every format of the testdata statement reads as the same two bookings.
+func assertLines(t *testing.T, st model.BankStatement) {
+	require.Len(t, st.Lines, 2)
+
+	credit := st.Lines[0]
+	assert.Equal(t, 1, credit.LineNumber)
+	assert.Equal(t, day("2026-06-01"), credit.BookingDate)
+	assert.Equal(t, "1250.5", credit.Amount.String())
+	assert.Equal(t, "EUR", credit.Currency)
+	assert.Equal(t, []string{"a3e1f6c2-7b8d-4e9f-a0b1-c2d3e4f5a6b7"}, bankstatement.References(credit))
+	assert.Contains(t, credit.Description, "Invoice 1042")
+
+	debit := st.Lines[1]
+	assert.Equal(t, 2, debit.LineNumber)
+	assert.Equal(t, day("2026-06-01"), debit.BookingDate)
+	assert.Equal(t, "-200", debit.Amount.String())
+	assert.Empty(t, debit.Reference)
+}
+
+func TestParse_Camt053(t *testing.T) {
+	st := parseFile(t, bankstatement.FormatCamt053, "statement.camt053.xml")
+
+	assert.Equal(t, "STMT-20260601-EUR", st.Reference)
+	assert.Equal(t, "DE02120300000000202051", st.Account)
+	assert.Equal(t, "EUR", st.Currency)
+	assert.Equal(t, "10000", st.OpeningBalance.Decimal.String())
+	assert.Equal(t, "11050.5", st.ClosingBalance.Decimal.String())
+
+	// The pending entry is not imported
+	assertLines(t, st)
+	assert.Equal(t, "BNK-0001", st.Lines[0].BankReference)
+	assert.Equal(t, &model.Counterparty{Name: "ACME Manufacturing Ltd", Account: "DE89370400440532013000"}, st.Lines[0].Counterparty)
+	assert.Equal(t, &model.Counterparty{Name: "Jane Doe"}, st.Lines[1].Counterparty)
+	assert.Equal(t, day("2026-06-02"), st.Lines[1].ValueDate)
+}
+
+func TestParse_MT940(t *testing.T) {
+	st := parseFile(t, bankstatement.FormatMT940, "statement.mt940")
+
+	assert.Equal(t, "STMT-20260601", st.Reference)
+	assert.Equal(t, "DE02120300000000202051", st.Account)
+	assert.Equal(t, "EUR", st.Currency)
+	assert.Equal(t, "10000", st.OpeningBalance.Decimal.String())
+	assert.Equal(t, "11050.5", st.ClosingBalance.Decimal.String())
+
+	assertLines(t, st)
+	assert.Equal(t, "Invoice 1042 ACME Manufacturing Ltd", st.Lines[0].Description)
+	assert.Equal(t, "BNK-0002", st.Lines[1].BankReference)
+	assert.Equal(t, "Payout Jane Doe", st.Lines[1].Description)
+	assert.Equal(t, day("2026-06-02"), st.Lines[1].ValueDate)
+}
+
+func TestParse_CSV(t *testing.T) {
+	st := parseFile(t, bankstatement.FormatCSV, "statement.csv")
+
+	assert.Equal(t, "EUR", st.Currency)
+	assert.False(t, st.OpeningBalance.Valid)
+
+	assertLines(t, st)
+	assert.Equal(t, day("2026-06-01"), st.Lines[1].ValueDate)
+	assert.Equal(t, "Payout, Jane Doe", st.Lines[1].Description)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	cases := map[string]struct {
+		format string
+		data   string
+	}{
+		"unknown format":   {"ofx", "<OFX/>"},
+		"malformed xml":    {bankstatement.FormatCamt053, "<Document><BkToCstmrStmt>"},
+		"missing column":   {bankstatement.FormatCSV, "date,amount\n2026-06-01,10\n"},
+		"unknown currency": {bankstatement.FormatCSV, "date,amount,currency\n2026-06-01,10,XYZ\n"},
+		"no lines":         {bankstatement.FormatCSV, "date,amount,currency\n"},
+		"two statements":   {bankstatement.FormatMT940, ":20:A\n:60F:C260601EUR1,\n:61:260601C1,NTRFX\n:20:B\n"},
+		"bad mt940 line":   {bankstatement.FormatMT940, ":20:A\n:60F:C260601EUR1,\n:61:2606C1,00\n"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := bankstatement.Parse(tc.format, []byte(tc.data))
+			assert.ErrorIs(t, err, bankstatement.ErrInvalidStatement)
+		})
+	}
+}
+
+func ledgerTxn(id, reference, txnType, amount string, createdAt time.Time) model.Transaction {
+	return model.Transaction{
+		ID:          id,
+		Type:        txnType,
+		Amount:      model.Decimal{Decimal: decimal.RequireFromString(amount)},
+		Currency:    "EUR",
+		ReferenceID: reference,
+		Status:      "completed",
+		CreatedAt:   createdAt,
+	}
+}
+
+func TestMatch(t *testing.T) {
+	line := model.BankStatementLine{
+		BookingDate: day("2026-06-01"),
+		Amount:      decimal.RequireFromString("1250.50"),
+		Currency:    "EUR",
+		Reference:   "a3e1f6c27b8d4e9fa0b1c2d3e4f5a6b7",
+	}
+	tolerance := 48 * time.Hour
+	inWindow := day("2026-05-31").Add(9 * time.Hour)
+	named := ledgerTxn("11111111-1111-1111-1111-111111111111", "a3e1f6c2-7b8d-4e9f-a0b1-c2d3e4f5a6b7", "deposit", "1250.5", day("2026-05-20"))
+	other := ledgerTxn("22222222-2222-2222-2222-222222222222", "33333333-3333-3333-3333-333333333333", "deposit", "1250.50", inWindow)
+
+	// The reference wins over an unreferenced candidate, even outside the tolerance
+	txn, method, ok := bankstatement.Match(line, []model.Transaction{other, named}, tolerance)
+	assert.True(t, ok)
+	assert.Equal(t, named.ID, txn.ID)
+	assert.Equal(t, model.MatchReference, method)
+
+	// Without a reference the only candidate in the window matches
+	line.Reference = ""
+	txn, method, ok = bankstatement.Match(line, []model.Transaction{other, named}, tolerance)
+	assert.True(t, ok)
+	assert.Equal(t, other.ID, txn.ID)
+	assert.Equal(t, model.MatchAmount, method)
+
+	// Two candidates in the window are ambiguous
+	twin := ledgerTxn("44444444-4444-4444-4444-444444444444", "55555555-5555-5555-5555-555555555555", "deposit", "1250.50", inWindow.Add(time.Hour))
+	_, _, ok = bankstatement.Match(line, []model.Transaction{other, twin}, tolerance)
+	assert.False(t, ok)
+
+	// A withdrawal does not settle a credit
+	withdrawal := ledgerTxn("66666666-6666-6666-6666-666666666666", "77777777-7777-7777-7777-777777777777", "withdrawal", "1250.50", inWindow)
+	_, _, ok = bankstatement.Match(line, []model.Transaction{withdrawal}, tolerance)
+	assert.False(t, ok)
+	assert.ErrorIs(t, bankstatement.Compatible(line, withdrawal), bankstatement.ErrMatchMismatch)
+}
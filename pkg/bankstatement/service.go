@@ -0,0 +1,199 @@
+// Package bankstatement imports the settlement bank's camt.053, MT940 and CSV statements and
+// matches their lines to the ledger's deposits and withdrawals.
+//
+// A credit on the statement is settled by a completed deposit and a debit by a completed
+// withdrawal of the same amount and currency. Lines are matched automatically on import and on
+// request: a transaction named by the line's reference wins, otherwise the single candidate created
+// within the date tolerance of the booking day. Lines and transactions left unmatched are listed
+// for matching by hand, and each transaction settles at most one line.
+package bankstatement
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type Service interface {
+	Import(ctx context.Context, format string, data []byte) (ImportResult, error)
+	GetStatement(ctx context.Context, id string) (model.BankStatement, error)
+	AutoMatch(ctx context.Context) (MatchSummary, error)
+	UnmatchedLines(ctx context.Context, filter Filter) ([]model.BankStatementLine, error)
+	UnmatchedTransactions(ctx context.Context, filter Filter) ([]model.Transaction, error)
+	MatchLine(ctx context.Context, lineID, transactionID string) (model.BankStatementLine, error)
+	UnmatchLine(ctx context.Context, lineID string) (model.BankStatementLine, error)
+}
+
+// MatchSummary counts the lines an automatic matching run looked at.
+type MatchSummary struct {
+	Matched   int `json:"matched"`
+	Unmatched int `json:"unmatched"`
+}
+
+type ImportResult struct {
+	Statement model.BankStatement `json:"statement"`
+	MatchSummary
+}
+
+type service struct {
+	config config.Config
+	logger *logging.Logger
+	store  Store
+}
+
+func NewService(config config.Config, logger *logging.Logger, database *db.DB) Service {
+	return &service{
+		config: config,
+		logger: logger,
+		store:  NewStore(database),
+	}
+}
+
+// Import parses and stores a statement file, then matches its lines. A file is only imported once.
+func (s *service) Import(ctx context.Context, format string, data []byte) (ImportResult, error) {
+	st, err := Parse(format, data)
+	if err != nil {
+		return ImportResult{}, eError.NewServiceError(err, err.Error(), "INVALID_STATEMENT", http.StatusBadRequest)
+	}
+
+	digest := sha256.Sum256(data)
+	st.Digest = hex.EncodeToString(digest[:])
+
+	if err := s.store.InsertStatement(ctx, &st); err != nil {
+		if errors.Is(err, ErrDuplicateStatement) {
+			return ImportResult{}, eError.NewServiceError(err, "bank statement already imported", "DUPLICATE_STATEMENT", http.StatusConflict)
+		}
+		s.logger.Error("failed to import bank statement", "format", format, "reference", st.Reference, "error", err)
+		return ImportResult{}, err
+	}
+
+	summary, err := s.matchLines(ctx, st.Lines)
+	if err != nil {
+		s.logger.Error("failed to match bank statement", "statement_id", st.ID, "error", err)
+		return ImportResult{}, err
+	}
+
+	s.logger.Info("bank statement imported", "statement_id", st.ID, "format", format, "reference", st.Reference,
+		"lines", len(st.Lines), "matched", summary.Matched, "unmatched", summary.Unmatched)
+	return ImportResult{Statement: st, MatchSummary: summary}, nil
+}
+
+func (s *service) GetStatement(ctx context.Context, id string) (model.BankStatement, error) {
+	st, err := s.store.Statement(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrStatementNotFound) {
+			return model.BankStatement{}, eError.NewServiceError(err, "bank statement not found", "STATEMENT_NOT_FOUND", http.StatusNotFound)
+		}
+		return model.BankStatement{}, err
+	}
+	return st, nil
+}
+
+// AutoMatch retries every unmatched line, e.g. once transactions that were still pending at import
+// have completed.
+func (s *service) AutoMatch(ctx context.Context) (MatchSummary, error) {
+	lines, err := s.store.UnmatchedLines(ctx, Filter{})
+	if err != nil {
+		return MatchSummary{}, err
+	}
+
+	summary, err := s.matchLines(ctx, lines)
+	if err != nil {
+		s.logger.Error("bank statement matching failed", "error", err)
+		return summary, err
+	}
+
+	s.logger.Info("bank statement lines matched", "matched", summary.Matched, "unmatched", summary.Unmatched)
+	return summary, nil
+}
+
+// matchLines matches each unmatched line it can, updating lines in place.
+func (s *service) matchLines(ctx context.Context, lines []model.BankStatementLine) (MatchSummary, error) {
+	var summary MatchSummary
+	for i, line := range lines {
+		if line.Matched() {
+			continue
+		}
+
+		from, to := Window(line, s.config.BankTolerance)
+		candidates, err := s.store.Candidates(ctx, line, References(line), from, to)
+		if err != nil {
+			return summary, err
+		}
+
+		txn, method, ok := Match(line, candidates, s.config.BankTolerance)
+		if !ok {
+			summary.Unmatched++
+			continue
+		}
+
+		matched, err := s.store.MatchLine(ctx, line.ID, txn.ID, method, Compatible)
+		switch {
+		case err == nil:
+			lines[i] = matched
+			summary.Matched++
+		case errors.Is(err, ErrLineMatched), errors.Is(err, ErrTransactionMatched):
+			// Matched by hand or by another run in the meantime
+			s.logger.Warn("bank statement line not matched", "line_id", line.ID, "transaction_id", txn.ID, "error", err)
+			summary.Unmatched++
+		default:
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+func (s *service) UnmatchedLines(ctx context.Context, filter Filter) ([]model.BankStatementLine, error) {
+	return s.store.UnmatchedLines(ctx, filter)
+}
+
+func (s *service) UnmatchedTransactions(ctx context.Context, filter Filter) ([]model.Transaction, error) {
+	return s.store.UnmatchedTransactions(ctx, filter)
+}
+
+// MatchLine matches a line by hand. The transaction must still settle the line exactly.
+func (s *service) MatchLine(ctx context.Context, lineID, transactionID string) (model.BankStatementLine, error) {
+	line, err := s.store.MatchLine(ctx, lineID, transactionID, model.MatchManual, Compatible)
+	switch {
+	case err == nil:
+		s.logger.Info("bank statement line matched", "line_id", lineID, "transaction_id", transactionID)
+		return line, nil
+	case errors.Is(err, ErrLineNotFound):
+		return model.BankStatementLine{}, eError.NewServiceError(err, "statement line not found", "LINE_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrTransactionNotFound):
+		return model.BankStatementLine{}, eError.NewServiceError(err, "transaction not found", "TRANSACTION_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrLineMatched):
+		return model.BankStatementLine{}, eError.NewServiceError(err, "statement line is already matched", "LINE_ALREADY_MATCHED", http.StatusConflict)
+	case errors.Is(err, ErrTransactionMatched):
+		return model.BankStatementLine{}, eError.NewServiceError(err, "transaction is already matched to a statement line", "TRANSACTION_ALREADY_MATCHED", http.StatusConflict)
+	case errors.Is(err, ErrMatchMismatch):
+		return model.BankStatementLine{}, eError.NewServiceError(err, err.Error(), "MATCH_MISMATCH", http.StatusConflict)
+	default:
+		s.logger.Error("failed to match bank statement line", "line_id", lineID, "transaction_id", transactionID, "error", err)
+		return model.BankStatementLine{}, err
+	}
+}
+
+// UnmatchLine undoes a match, automatic or manual, leaving the line and transaction unmatched.
+func (s *service) UnmatchLine(ctx context.Context, lineID string) (model.BankStatementLine, error) {
+	line, err := s.store.UnmatchLine(ctx, lineID)
+	switch {
+	case err == nil:
+		s.logger.Info("bank statement line unmatched", "line_id", lineID)
+		return line, nil
+	case errors.Is(err, ErrLineNotFound):
+		return model.BankStatementLine{}, eError.NewServiceError(err, "statement line not found", "LINE_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrLineNotMatched):
+		return model.BankStatementLine{}, eError.NewServiceError(err, "statement line is not matched", "LINE_NOT_MATCHED", http.StatusConflict)
+	default:
+		s.logger.Error("failed to unmatch bank statement line", "line_id", lineID, "error", err)
+		return model.BankStatementLine{}, err
+	}
+}
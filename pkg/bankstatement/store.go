@@ -0,0 +1,360 @@
+package bankstatement
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrDuplicateStatement  = errors.New("bank statement already imported")
+	ErrStatementNotFound   = errors.New("bank statement not found")
+	ErrLineNotFound        = errors.New("statement line not found")
+	ErrLineMatched         = errors.New("statement line is already matched")
+	ErrLineNotMatched      = errors.New("statement line is not matched")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrTransactionMatched  = errors.New("transaction is already matched to a statement line")
+)
+
+// maxCandidates bounds the transactions read as match candidates for one line; more than one
+// compatible candidate without a reference is ambiguous anyway.
+const maxCandidates = 20
+
+// Filter selects unmatched statement lines by booking date or unmatched transactions by creation time.
+type Filter struct {
+	Currency  string
+	AccountID string     // Transactions only
+	From      *time.Time // Inclusive
+	To        *time.Time // Exclusive
+	Limit     int        // 0 for no limit
+}
+
+type Store interface {
+	InsertStatement(ctx context.Context, st *model.BankStatement) error
+	Statement(ctx context.Context, id string) (model.BankStatement, error)
+	UnmatchedLines(ctx context.Context, filter Filter) ([]model.BankStatementLine, error)
+	UnmatchedTransactions(ctx context.Context, filter Filter) ([]model.Transaction, error)
+	Candidates(ctx context.Context, line model.BankStatementLine, refs []string, from, to time.Time) ([]model.Transaction, error)
+	MatchLine(ctx context.Context, lineID, transactionID, method string, check func(model.BankStatementLine, model.Transaction) error) (model.BankStatementLine, error)
+	UnmatchLine(ctx context.Context, lineID string) (model.BankStatementLine, error)
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+const lineColumns = `id, statement_id, line_number, booking_date, value_date, amount, currency, reference, bank_reference,
+	description, counterparty_name, counterparty_account, COALESCE(transaction_id::text, ''), COALESCE(match_method, ''), matched_at`
+
+// unmatchedTransactionsSQL selects the completed deposits and withdrawals no statement line settles.
+// FX conversions move funds between a user's own accounts and never reach the bank, so their
+// debit, the only leg without a parent, is left out.
+const unmatchedTransactionsSQL = `SELECT ` + transaction.EntryColumns + ` FROM transactions t
+	WHERE t.status = 'completed' AND t.parent_id IS NULL AND t.type IN ('deposit', 'withdrawal')
+	AND NOT EXISTS (SELECT 1 FROM fx_conversions c WHERE c.debit_transaction_id = t.id)
+	AND NOT EXISTS (SELECT 1 FROM bank_statement_lines l WHERE l.transaction_id = t.id)`
+
+// InsertStatement stores the statement and its lines, setting their ids and the import time.
+func (s *store) InsertStatement(ctx context.Context, st *model.BankStatement) error {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	st.ID = model.NewUUID()
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO bank_statements (id, format, reference, account, currency, opening_balance, closing_balance, digest)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING imported_at`,
+		st.ID, st.Format, st.Reference, st.Account, st.Currency, st.OpeningBalance, st.ClosingBalance, st.Digest,
+	).Scan(&st.ImportedAt)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return ErrDuplicateStatement
+		}
+		return errors.Wrap(err, "failed to create bank statement")
+	}
+
+	for i := range st.Lines {
+		line := &st.Lines[i]
+		line.ID = model.NewUUID()
+		line.StatementID = st.ID
+
+		var counterparty model.Counterparty
+		if line.Counterparty != nil {
+			counterparty = *line.Counterparty
+		}
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO bank_statement_lines (id, statement_id, line_number, booking_date, value_date, amount, currency,
+				reference, bank_reference, description, counterparty_name, counterparty_account)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			line.ID, st.ID, line.LineNumber, line.BookingDate.Format(time.DateOnly), line.ValueDate.Format(time.DateOnly),
+			line.Amount, line.Currency, line.Reference, line.BankReference, line.Description, counterparty.Name, counterparty.Account,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create statement line %d", line.LineNumber)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit bank statement")
+	}
+	return nil
+}
+
+func (s *store) Statement(ctx context.Context, id string) (model.BankStatement, error) {
+	st := model.BankStatement{ID: id}
+	err := s.db.DB.QueryRowContext(ctx,
+		`SELECT format, reference, account, currency, opening_balance, closing_balance, digest, imported_at
+		FROM bank_statements WHERE id = $1`, id,
+	).Scan(&st.Format, &st.Reference, &st.Account, &st.Currency, &st.OpeningBalance, &st.ClosingBalance, &st.Digest, &st.ImportedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.BankStatement{}, ErrStatementNotFound
+		}
+		return model.BankStatement{}, errors.Wrap(err, "failed to get bank statement")
+	}
+
+	st.Lines, err = s.lines(ctx, `SELECT `+lineColumns+` FROM bank_statement_lines WHERE statement_id = $1 ORDER BY line_number`, id)
+	if err != nil {
+		return model.BankStatement{}, err
+	}
+	return st, nil
+}
+
+// UnmatchedLines lists the unmatched statement lines, oldest booking first.
+func (s *store) UnmatchedLines(ctx context.Context, filter Filter) ([]model.BankStatementLine, error) {
+	query := `SELECT ` + lineColumns + ` FROM bank_statement_lines WHERE transaction_id IS NULL`
+	query, args := filter.apply(query, "booking_date", nil)
+	query += ` ORDER BY booking_date, id`
+	query, args = filter.limit(query, args)
+
+	return s.lines(ctx, query, args...)
+}
+
+// UnmatchedTransactions lists the completed deposits and withdrawals no statement line settles,
+// oldest first.
+func (s *store) UnmatchedTransactions(ctx context.Context, filter Filter) ([]model.Transaction, error) {
+	var args []interface{}
+	query := unmatchedTransactionsSQL
+	if filter.AccountID != "" {
+		args = append(args, filter.AccountID)
+		query += fmt.Sprintf(` AND t.account_id = $%d`, len(args))
+	}
+	query, args = filter.apply(query, "t.created_at", args)
+	query += ` ORDER BY t.created_at, t.id`
+	query, args = filter.limit(query, args)
+
+	return s.transactions(ctx, query, args...)
+}
+
+// Candidates reads the unmatched transactions of the line's type, currency and amount that were
+// created in [from, to) or are named by one of refs, those named by a reference first.
+func (s *store) Candidates(ctx context.Context, line model.BankStatementLine, refs []string, from, to time.Time) ([]model.Transaction, error) {
+	return s.transactions(ctx, unmatchedTransactionsSQL+`
+		AND t.type = $1 AND t.currency = $2 AND t.amount = $3
+		AND ((t.created_at >= $4 AND t.created_at < $5) OR t.id = ANY($6::uuid[]) OR t.reference_id = ANY($6::uuid[]))
+		ORDER BY (t.id = ANY($6::uuid[]) OR t.reference_id = ANY($6::uuid[])) DESC, t.created_at, t.id
+		LIMIT $7`,
+		TransactionType(line), line.Currency, line.Amount.Abs(), from, to, pq.Array(refs), maxCandidates,
+	)
+}
+
+// MatchLine locks the unmatched line and matches it to the transaction once check accepts the pair.
+// Nothing is stored when check fails.
+func (s *store) MatchLine(ctx context.Context, lineID, transactionID, method string, check func(model.BankStatementLine, model.Transaction) error) (model.BankStatementLine, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return model.BankStatementLine{}, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	line, err := scanLine(tx.QueryRowContext(ctx, `SELECT `+lineColumns+` FROM bank_statement_lines WHERE id = $1 FOR UPDATE`, lineID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.BankStatementLine{}, ErrLineNotFound
+		}
+		return model.BankStatementLine{}, errors.Wrap(err, "failed to get statement line")
+	}
+	if line.Matched() {
+		return model.BankStatementLine{}, ErrLineMatched
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+transaction.EntryColumns+` FROM transactions WHERE id = $1`, transactionID)
+	if err != nil {
+		return model.BankStatementLine{}, errors.Wrap(err, "failed to get transaction")
+	}
+	var txns []model.Transaction
+	for rows.Next() {
+		txn, err := transaction.ScanEntry(rows)
+		if err != nil {
+			rows.Close()
+			return model.BankStatementLine{}, errors.Wrap(err, "failed to scan transaction")
+		}
+		txns = append(txns, txn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return model.BankStatementLine{}, errors.Wrap(err, "failed to get transaction")
+	}
+	if len(txns) == 0 {
+		return model.BankStatementLine{}, ErrTransactionNotFound
+	}
+
+	if err := check(line, txns[0]); err != nil {
+		return model.BankStatementLine{}, err
+	}
+
+	line, err = scanLine(tx.QueryRowContext(ctx,
+		`UPDATE bank_statement_lines SET transaction_id = $2, match_method = $3, matched_at = NOW()
+		WHERE id = $1
+		RETURNING `+lineColumns,
+		lineID, transactionID, method,
+	))
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return model.BankStatementLine{}, ErrTransactionMatched
+		}
+		return model.BankStatementLine{}, errors.Wrap(err, "failed to match statement line")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.BankStatementLine{}, errors.Wrap(err, "failed to commit statement line match")
+	}
+	return line, nil
+}
+
+// UnmatchLine clears the line's match so it can be matched again.
+func (s *store) UnmatchLine(ctx context.Context, lineID string) (model.BankStatementLine, error) {
+	line, err := scanLine(s.db.DB.QueryRowContext(ctx,
+		`UPDATE bank_statement_lines SET transaction_id = NULL, match_method = NULL, matched_at = NULL
+		WHERE id = $1 AND transaction_id IS NOT NULL
+		RETURNING `+lineColumns,
+		lineID,
+	))
+	if err == nil {
+		return line, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.BankStatementLine{}, errors.Wrap(err, "failed to unmatch statement line")
+	}
+
+	var exists bool
+	err = s.db.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM bank_statement_lines WHERE id = $1)`, lineID).Scan(&exists)
+	if err != nil {
+		return model.BankStatementLine{}, errors.Wrap(err, "failed to get statement line")
+	}
+	if !exists {
+		return model.BankStatementLine{}, ErrLineNotFound
+	}
+	return model.BankStatementLine{}, ErrLineNotMatched
+}
+
+// apply adds the filter's currency and time range on column to the query's conditions.
+func (f Filter) apply(query, column string, args []interface{}) (string, []interface{}) {
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	if f.Currency != "" {
+		where(`currency = $%d`, f.Currency)
+	}
+	if f.From != nil {
+		where(column+` >= $%d`, *f.From)
+	}
+	if f.To != nil {
+		where(column+` < $%d`, *f.To)
+	}
+	return query, args
+}
+
+func (f Filter) limit(query string, args []interface{}) (string, []interface{}) {
+	if f.Limit <= 0 {
+		return query, args
+	}
+	args = append(args, f.Limit)
+	return query + fmt.Sprintf(` LIMIT $%d`, len(args)), args
+}
+
+func (s *store) lines(ctx context.Context, query string, args ...interface{}) ([]model.BankStatementLine, error) {
+	rows, err := s.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read statement lines")
+	}
+	defer rows.Close()
+
+	var lines []model.BankStatementLine
+	for rows.Next() {
+		line, err := scanLine(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan statement line")
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (s *store) transactions(ctx context.Context, query string, args ...interface{}) ([]model.Transaction, error) {
+	rows, err := s.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read transactions")
+	}
+	defer rows.Close()
+
+	var txns []model.Transaction
+	for rows.Next() {
+		txn, err := transaction.ScanEntry(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan transaction")
+		}
+		txns = append(txns, txn)
+	}
+	return txns, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLine(row rowScanner) (model.BankStatementLine, error) {
+	var line model.BankStatementLine
+	var counterparty model.Counterparty
+	var matchedAt sql.NullTime
+
+	err := row.Scan(&line.ID, &line.StatementID, &line.LineNumber, &line.BookingDate, &line.ValueDate, &line.Amount,
+		&line.Currency, &line.Reference, &line.BankReference, &line.Description, &counterparty.Name, &counterparty.Account,
+		&line.TransactionID, &line.MatchMethod, &matchedAt)
+	if err != nil {
+		return model.BankStatementLine{}, err
+	}
+
+	if counterparty != (model.Counterparty{}) {
+		line.Counterparty = &counterparty
+	}
+	if matchedAt.Valid {
+		line.MatchedAt = &matchedAt.Time
+	}
+	return line, nil
+}
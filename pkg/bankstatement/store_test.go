@@ -0,0 +1,137 @@
+package bankstatement_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/bankstatement"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var lineColumns = []string{"id", "statement_id", "line_number", "booking_date", "value_date", "amount", "currency", "reference",
+	"bank_reference", "description", "counterparty_name", "counterparty_account", "transaction_id", "match_method", "matched_at"}
+
+var entryColumns = []string{"id", "account_id", "type", "amount", "currency", "reference_id", "status", "parent_id",
+	"sequence", "balance_after", "original_amount", "original_currency", "fx_rate",
+	"description", "counterparty_name", "counterparty_account", "metadata", "created_at", "value_date"}
+
+func TestStore_InsertStatement_Duplicate(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := bankstatement.NewStore(&db.DB{DB: sqlDB})
+	st := model.BankStatement{Format: "mt940", Reference: "STMT-1", Digest: "abc"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO bank_statements`).
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	err = store.InsertStatement(context.Background(), &st)
+	assert.ErrorIs(t, err, bankstatement.ErrDuplicateStatement)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Candidates(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := bankstatement.NewStore(&db.DB{DB: sqlDB})
+	line := model.BankStatementLine{BookingDate: day("2026-06-01"), Amount: decimal.RequireFromString("-200.00"), Currency: "EUR"}
+	from, to := bankstatement.Window(line, 48*time.Hour)
+	refs := []string{"a3e1f6c2-7b8d-4e9f-a0b1-c2d3e4f5a6b7"}
+
+	// Debits are settled by withdrawals of the absolute amount that no line settles yet, other than FX conversion debits
+	mock.ExpectQuery(`FROM transactions t .* NOT EXISTS \(SELECT 1 FROM fx_conversions c WHERE c.debit_transaction_id = t.id\)\s+AND NOT EXISTS \(SELECT 1 FROM bank_statement_lines .* AND t.type = \$1 AND t.currency = \$2 AND t.amount = \$3`).
+		WithArgs("withdrawal", "EUR", decimal.RequireFromString("200"), day("2026-05-30"), day("2026-06-04"), pq.Array(refs), 20).
+		WillReturnRows(sqlmock.NewRows(entryColumns).
+			AddRow("txn1", "acc1", "withdrawal", "200", "EUR", "ref1", "completed", "", 3, "50", nil, nil, nil, "", "", "", []byte("{}"), day("2026-06-01"), "2026-06-01"))
+
+	txns, err := store.Candidates(context.Background(), line, refs, from, to)
+	assert.NoError(t, err)
+	assert.Len(t, txns, 1)
+	assert.Equal(t, "txn1", txns[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_MatchLine(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := bankstatement.NewStore(&db.DB{DB: sqlDB})
+	matchedAt := time.Date(2026, 6, 2, 8, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM bank_statement_lines WHERE id = \$1 FOR UPDATE`).
+		WithArgs("line1").
+		WillReturnRows(sqlmock.NewRows(lineColumns).
+			AddRow("line1", "st1", 1, day("2026-06-01"), day("2026-06-01"), "1250.50", "EUR", "", "BNK-0001", "", "", "", "", "", nil))
+	mock.ExpectQuery(`SELECT .* FROM transactions WHERE id = \$1`).
+		WithArgs("txn1").
+		WillReturnRows(sqlmock.NewRows(entryColumns).
+			AddRow("txn1", "acc1", "deposit", "1250.5", "EUR", "ref1", "completed", "", 7, "1300", nil, nil, nil, "", "", "", []byte("{}"), day("2026-06-01"), "2026-06-01"))
+	mock.ExpectQuery(`UPDATE bank_statement_lines SET transaction_id = \$2, match_method = \$3`).
+		WithArgs("line1", "txn1", model.MatchManual).
+		WillReturnRows(sqlmock.NewRows(lineColumns).
+			AddRow("line1", "st1", 1, day("2026-06-01"), day("2026-06-01"), "1250.50", "EUR", "", "BNK-0001", "", "", "", "txn1", "manual", matchedAt))
+	mock.ExpectCommit()
+
+	line, err := store.MatchLine(context.Background(), "line1", "txn1", model.MatchManual, bankstatement.Compatible)
+	assert.NoError(t, err)
+	assert.Equal(t, "txn1", line.TransactionID)
+	assert.Equal(t, model.MatchManual, line.MatchMethod)
+	assert.Equal(t, &matchedAt, line.MatchedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_MatchLine_Mismatch(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := bankstatement.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM bank_statement_lines WHERE id = \$1 FOR UPDATE`).
+		WithArgs("line1").
+		WillReturnRows(sqlmock.NewRows(lineColumns).
+			AddRow("line1", "st1", 1, day("2026-06-01"), day("2026-06-01"), "1250.50", "EUR", "", "", "", "", "", "", "", nil))
+	mock.ExpectQuery(`SELECT .* FROM transactions WHERE id = \$1`).
+		WithArgs("txn1").
+		WillReturnRows(sqlmock.NewRows(entryColumns).
+			AddRow("txn1", "acc1", "deposit", "1200", "EUR", "ref1", "completed", "", 7, "1300", nil, nil, nil, "", "", "", []byte("{}"), day("2026-06-01"), "2026-06-01"))
+	mock.ExpectRollback()
+
+	_, err = store.MatchLine(context.Background(), "line1", "txn1", model.MatchManual, bankstatement.Compatible)
+	assert.ErrorIs(t, err, bankstatement.ErrMatchMismatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_UnmatchLine_NotMatched(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := bankstatement.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectQuery(`UPDATE bank_statement_lines SET transaction_id = NULL, .* AND transaction_id IS NOT NULL`).
+		WithArgs("line1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("line1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	_, err = store.UnmatchLine(context.Background(), "line1")
+	assert.ErrorIs(t, err, bankstatement.ErrLineNotMatched)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,75 @@
+package bankstatement
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	importHandler := kithttp.NewServer(
+		makeImportEndpoint(ms),
+		decodeImportRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	getHandler := kithttp.NewServer(
+		makeGetStatementEndpoint(ms),
+		decodeGetStatementRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	autoMatchHandler := kithttp.NewServer(
+		makeAutoMatchEndpoint(ms),
+		decodeAutoMatchRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	unmatchedLinesHandler := kithttp.NewServer(
+		makeUnmatchedLinesEndpoint(ms),
+		decodeFilterRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	unmatchedTransactionsHandler := kithttp.NewServer(
+		makeUnmatchedTransactionsEndpoint(ms),
+		decodeFilterRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	matchHandler := kithttp.NewServer(
+		makeMatchEndpoint(ms),
+		decodeMatchRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	unmatchHandler := kithttp.NewServer(
+		makeUnmatchEndpoint(ms),
+		decodeUnmatchRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("POST", "/bank-statements", importHandler)
+	r.Method("GET", "/bank-statements/{id}", getHandler)
+	r.Method("POST", "/bank-statements/match", autoMatchHandler)
+	r.Method("GET", "/bank-statements/lines/unmatched", unmatchedLinesHandler)
+	r.Method("GET", "/bank-statements/transactions/unmatched", unmatchedTransactionsHandler)
+	r.Method("PUT", "/bank-statements/lines/{id}/match", matchHandler)
+	r.Method("DELETE", "/bank-statements/lines/{id}/match", unmatchHandler)
+
+	return http.Endpoint{Pattern: "/bank-statements*", Handler: r}
+}
@@ -34,3 +34,22 @@ func (p *KafkaProducer) PublishTransaction(txn model.Transaction) error {
 		Time:  time.Now(),
 	})
 }
+
+// PublishTransactions writes all transactions in one WriteMessages call, letting the writer batch them.
+func (p *KafkaProducer) PublishTransactions(txns []model.Transaction) error {
+	msgs := make([]kafka.Message, 0, len(txns))
+	now := time.Now()
+	for _, txn := range txns {
+		msg, err := json.Marshal(txn)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(txn.ID),
+			Value: msg,
+			Time:  now,
+		})
+	}
+
+	return p.writer.WriteMessages(context.Background(), msgs...)
+}
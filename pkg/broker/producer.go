@@ -4,4 +4,5 @@ import "github.com/mdshahjahanmiah/banking-ledger/model"
 
 type Producer interface {
 	PublishTransaction(txn model.Transaction) error
+	PublishTransactions(txns []model.Transaction) error
 }
@@ -0,0 +1,132 @@
+// Package chart loads the chart of accounts: the bank's own system accounts that the ledger
+// books against alongside customer accounts.
+//
+// The chart is a JSON file listing one account per system role and currency, with its type in
+// the chart (asset, liability, revenue, expense or equity). Sync creates the listed accounts that
+// don't exist yet, so the chart can grow with new currencies and roles; an existing account is
+// never changed, and a chart that disagrees with it is rejected.
+package chart
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidChart      = errors.New("invalid chart of accounts")
+	ErrChartMismatch     = errors.New("chart of accounts does not match the ledger")
+	ErrAccountNotInChart = errors.New("no system account in the chart of accounts")
+)
+
+// Account is a system account of the chart. An empty Type is the role's default and an empty ID
+// is derived from the role and currency.
+type Account struct {
+	ID       string            `json:"id"`
+	Role     model.SystemRole  `json:"role"`
+	Currency string            `json:"currency"`
+	Type     model.AccountType `json:"type"`
+	Name     string            `json:"name"` // Stored as the account's nickname
+}
+
+type Chart struct {
+	Accounts []Account `json:"accounts"`
+}
+
+// Load reads a chart from a JSON file. An empty path yields an empty chart, with no system accounts.
+func Load(path string) (*Chart, error) {
+	if path == "" {
+		return &Chart{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read chart of accounts")
+	}
+
+	var chart Chart
+	if err := json.Unmarshal(data, &chart); err != nil {
+		return nil, errors.Wrap(err, "failed to parse chart of accounts")
+	}
+
+	if err := chart.Validate(); err != nil {
+		return nil, err
+	}
+	return &chart, nil
+}
+
+// Validate checks every account and fills in the default type and id.
+func (c *Chart) Validate() error {
+	seen := make(map[string]bool, len(c.Accounts))
+	for i := range c.Accounts {
+		a := &c.Accounts[i]
+		a.Currency = strings.ToUpper(a.Currency)
+
+		if !a.Role.IsValid() {
+			return errors.Wrapf(ErrInvalidChart, "unknown role %q", a.Role)
+		}
+		if !model.IsKnownCurrency(a.Currency) {
+			return errors.Wrapf(ErrInvalidChart, "%s: unknown currency %q", a.Role, a.Currency)
+		}
+		if a.Type == "" {
+			a.Type = a.Role.DefaultType()
+		}
+		if !a.Type.IsValid() {
+			return errors.Wrapf(ErrInvalidChart, "%s %s: unknown type %q", a.Role, a.Currency, a.Type)
+		}
+		if a.ID == "" {
+			a.ID = AccountID(a.Role, a.Currency)
+		} else if !model.IsValidUUID(a.ID) {
+			return errors.Wrapf(ErrInvalidChart, "%s %s: id must be a UUID", a.Role, a.Currency)
+		}
+		if err := model.ValidateNickname(a.Name); err != nil {
+			return errors.Wrapf(ErrInvalidChart, "%s %s: %v", a.Role, a.Currency, err)
+		}
+
+		key := string(a.Role) + "/" + a.Currency
+		if seen[key] {
+			return errors.Wrapf(ErrInvalidChart, "%s %s is listed more than once", a.Role, a.Currency)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Lookup returns the id of the role's account in currency.
+func (c *Chart) Lookup(role model.SystemRole, currency string) (string, error) {
+	if c != nil {
+		for _, a := range c.Accounts {
+			if a.Role == role && a.Currency == currency {
+				return a.ID, nil
+			}
+		}
+	}
+	return "", errors.Wrapf(ErrAccountNotInChart, "%s %s", role, currency)
+}
+
+// ByCurrency returns the ids of the role's accounts by currency.
+func (c *Chart) ByCurrency(role model.SystemRole) map[string]string {
+	ids := make(map[string]string)
+	if c != nil {
+		for _, a := range c.Accounts {
+			if a.Role == role {
+				ids[a.Currency] = a.ID
+			}
+		}
+	}
+	return ids
+}
+
+// AccountID derives the id of a system account the chart doesn't give one for.
+func AccountID(role model.SystemRole, currency string) string {
+	return model.DeriveUUID("system", string(role), currency)
+}
+
+// UserID is the user id system accounts are stored under, one per role so that the role's
+// accounts are told apart by currency like a customer's.
+func UserID(role model.SystemRole) string {
+	return "system:" + string(role)
+}
@@ -0,0 +1,72 @@
+package chart_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/chart"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chart.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"accounts": [
+		{"role": "fee_revenue", "currency": "usd", "name": "Fee income"},
+		{"role": "settlement", "currency": "USD", "id": "5b1f8a52-95c4-4a3c-9a7a-2f3c1d9d6e01"},
+		{"role": "suspense", "currency": "EUR", "type": "asset"}
+	]}`), 0o600))
+
+	c, err := chart.Load(path)
+	require.NoError(t, err)
+	require.Len(t, c.Accounts, 3)
+
+	// Types default by role and ids are derived from the role and currency
+	assert.Equal(t, "USD", c.Accounts[0].Currency)
+	assert.Equal(t, model.AccountTypeRevenue, c.Accounts[0].Type)
+	assert.Equal(t, chart.AccountID(model.SystemRoleFeeRevenue, "USD"), c.Accounts[0].ID)
+	assert.Equal(t, model.AccountTypeAsset, c.Accounts[1].Type)
+	assert.Equal(t, model.AccountTypeAsset, c.Accounts[2].Type)
+
+	id, err := c.Lookup(model.SystemRoleSettlement, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "5b1f8a52-95c4-4a3c-9a7a-2f3c1d9d6e01", id)
+
+	_, err = c.Lookup(model.SystemRoleSettlement, "EUR")
+	assert.ErrorIs(t, err, chart.ErrAccountNotInChart)
+
+	assert.Equal(t, map[string]string{"EUR": c.Accounts[2].ID}, c.ByCurrency(model.SystemRoleSuspense))
+}
+
+func TestLoad_EmptyPath(t *testing.T) {
+	c, err := chart.Load("")
+	assert.NoError(t, err)
+	assert.Empty(t, c.Accounts)
+}
+
+func TestChart_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		account chart.Account
+	}{
+		{"unknown role", chart.Account{Role: "treasury", Currency: "USD"}},
+		{"unknown currency", chart.Account{Role: model.SystemRoleSuspense, Currency: "XYZ"}},
+		{"unknown type", chart.Account{Role: model.SystemRoleSuspense, Currency: "USD", Type: "contra"}},
+		{"invalid id", chart.Account{Role: model.SystemRoleSuspense, Currency: "USD", ID: "suspense"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &chart.Chart{Accounts: []chart.Account{tt.account}}
+			assert.ErrorIs(t, c.Validate(), chart.ErrInvalidChart)
+		})
+	}
+
+	duplicate := &chart.Chart{Accounts: []chart.Account{
+		{Role: model.SystemRoleSuspense, Currency: "USD"},
+		{Role: model.SystemRoleSuspense, Currency: "usd"},
+	}}
+	assert.ErrorIs(t, duplicate.Validate(), chart.ErrInvalidChart)
+}
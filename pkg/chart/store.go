@@ -0,0 +1,81 @@
+package chart
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/pkg/errors"
+	"log/slog"
+)
+
+type Store interface {
+	Sync(ctx context.Context, chart *Chart) (int, error)
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+// Sync creates the chart's accounts that don't exist yet, empty and active, and returns how many
+// it created. Nothing is created when an existing system account of a role and currency has
+// another id or type than the chart gives, or the chart's id belongs to another account.
+func (s *store) Sync(ctx context.Context, chart *Chart) (int, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	created := 0
+	for _, a := range chart.Accounts {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO accounts (id, user_id, balance, currency, status, tier, nickname, kind, type, system_role)
+			VALUES ($1, $2, 0, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT DO NOTHING`,
+			a.ID, UserID(a.Role), a.Currency, model.AccountStatusActive, model.AccountTierStandard, a.Name,
+			model.AccountKindSystem, a.Type, a.Role,
+		)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to create %s account in %s", a.Role, a.Currency)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return 0, errors.Wrap(err, "failed to create system account")
+		} else if n > 0 {
+			created++
+			continue
+		}
+
+		var id string
+		var accountType model.AccountType
+		err = tx.QueryRowContext(ctx,
+			`SELECT id, type FROM accounts WHERE system_role = $1 AND currency = $2`, a.Role, a.Currency,
+		).Scan(&id, &accountType)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, errors.Wrapf(ErrChartMismatch, "%s %s: account %s already exists in another role", a.Role, a.Currency, a.ID)
+			}
+			return 0, errors.Wrap(err, "failed to get system account")
+		}
+		if id != a.ID {
+			return 0, errors.Wrapf(ErrChartMismatch, "%s %s: account is %s, not %s", a.Role, a.Currency, id, a.ID)
+		}
+		if accountType != a.Type {
+			return 0, errors.Wrapf(ErrChartMismatch, "%s %s: account is of type %s, not %s", a.Role, a.Currency, accountType, a.Type)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "transaction commit failed")
+	}
+	return created, nil
+}
@@ -0,0 +1,73 @@
+package chart_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/chart"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testChart(t *testing.T) *chart.Chart {
+	c := &chart.Chart{Accounts: []chart.Account{
+		{Role: model.SystemRoleSettlement, Currency: "USD"},
+		{Role: model.SystemRoleSuspense, Currency: "USD"},
+	}}
+	require.NoError(t, c.Validate())
+	return c
+}
+
+func TestStore_Sync(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := chart.NewStore(&db.DB{DB: sqlDB})
+	c := testChart(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO accounts .* ON CONFLICT DO NOTHING`).
+		WithArgs(c.Accounts[0].ID, "system:settlement", "USD", model.AccountStatusActive, model.AccountTierStandard, "",
+			model.AccountKindSystem, model.AccountTypeAsset, model.SystemRoleSettlement).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The suspense account exists already and agrees with the chart
+	mock.ExpectExec(`INSERT INTO accounts .* ON CONFLICT DO NOTHING`).
+		WithArgs(c.Accounts[1].ID, "system:suspense", "USD", model.AccountStatusActive, model.AccountTierStandard, "",
+			model.AccountKindSystem, model.AccountTypeLiability, model.SystemRoleSuspense).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT id, type FROM accounts WHERE system_role = \$1 AND currency = \$2`).
+		WithArgs(model.SystemRoleSuspense, "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type"}).AddRow(c.Accounts[1].ID, model.AccountTypeLiability))
+	mock.ExpectCommit()
+
+	created, err := store.Sync(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Sync_Mismatch(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := chart.NewStore(&db.DB{DB: sqlDB})
+	c := testChart(t)
+
+	// The settlement account was created as a liability, which the chart can't change
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO accounts`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT id, type FROM accounts WHERE system_role = \$1 AND currency = \$2`).
+		WithArgs(model.SystemRoleSettlement, "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type"}).AddRow(c.Accounts[0].ID, model.AccountTypeLiability))
+	mock.ExpectRollback()
+
+	_, err = store.Sync(context.Background(), c)
+	assert.ErrorIs(t, err, chart.ErrChartMismatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,16 +2,41 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"github.com/mdshahjahanmiah/explore-go/logging"
 	"os"
+	"time"
 )
 
+// ReconcileConfig configures the ledger service's background reconciliation of the ledger
+// against the audit store.
+type ReconcileConfig struct {
+	Interval time.Duration // 0 disables the runner
+	Window   time.Duration // How far back each run looks
+	Backfill bool          // Whether runs create missing audit records
+}
+
 type Config struct {
-	HttpAddress    string
-	PostgresDSN    string
-	MongoURI       string
-	KafkaBrokerURL string
-	LoggerConfig   logging.LoggerConfig
+	HttpAddress     string
+	PostgresDSN     string
+	MongoURI        string
+	KafkaBrokerURL  string
+	FeeSchedule     string
+	ChartOfAccounts string // Path to the JSON chart of accounts listing the system accounts
+	FXRates         string
+	FXSpread        string
+	FXQuoteTTL      time.Duration
+	ScheduleTick    time.Duration
+	BatchMaxItems   int
+	BankID          string
+	OpeningFunding  string
+	Instance        string // Identifies this process in audit events
+	AuditKey        string // Base64 ed25519 key signing audit checkpoints; empty disables checkpoints
+	AuditInterval   time.Duration
+	Reconcile       ReconcileConfig
+	BankTolerance   time.Duration // How far from a statement line's booking day a transaction can match it
+	LoggerConfig    logging.LoggerConfig
+	Args            []string // Positional arguments left after the flags, used by command line tools
 }
 
 func Load() (Config, error) {
@@ -21,6 +46,23 @@ func Load() (Config, error) {
 	mongoURI := fs.String("mongo.uri", os.Getenv("MONGO_URI"), "MongoDB connection URI")
 	postgresDSN := fs.String("dsn", os.Getenv("POSTGRES_DSN"), "DB address")
 	kafkaBroker := fs.String("kafka.broker", os.Getenv("KAFKA_BROKER_URL"), "Kafka broker URL")
+	feeSchedule := fs.String("fee.schedule", os.Getenv("FEE_SCHEDULE_FILE"), "Path to the JSON fee schedule; empty disables fees")
+	chartOfAccounts := fs.String("chart.accounts", os.Getenv("CHART_OF_ACCOUNTS_FILE"), "Path to the JSON chart of accounts listing the system accounts; empty means none")
+	fxRates := fs.String("fx.rates", os.Getenv("FX_RATES_FILE"), "Path to the JSON exchange rate table")
+	fxSpread := fs.String("fx.spread", "0.5", "Spread in percent applied to the mid rate on FX conversions")
+	fxQuoteTTL := fs.Duration("fx.quote.ttl", 30*time.Second, "How long an FX quote can be executed")
+	scheduleTick := fs.Duration("schedule.interval", time.Minute, "How often due scheduled transactions are queued; 0 disables the runner")
+	batchMaxItems := fs.Int("batch.max.items", 1000, "Maximum number of transactions in a batch submission")
+	bankID := fs.String("bank.id", "000000000", "Bank identifier written to exported statements")
+	instance := fs.String("instance.id", os.Getenv("INSTANCE_ID"), "Identifier recorded in audit events; defaults to the host name and process id")
+	auditKey := fs.String("audit.signing.key", os.Getenv("AUDIT_SIGNING_KEY"), "Base64 ed25519 seed or private key signing audit checkpoints; empty disables checkpoints")
+	auditInterval := fs.Duration("audit.checkpoint.interval", time.Hour, "How often the ledger service signs an audit checkpoint; 0 disables the runner")
+	reconcile := ReconcileConfig{}
+	fs.DurationVar(&reconcile.Interval, "reconcile.interval", 15*time.Minute, "How often the ledger is reconciled against the audit store; 0 disables the runner")
+	fs.DurationVar(&reconcile.Window, "reconcile.window", 24*time.Hour, "How far back each background reconciliation looks")
+	fs.BoolVar(&reconcile.Backfill, "reconcile.backfill", false, "Backfill missing audit records during background reconciliation")
+	bankTolerance := fs.Duration("bank.match.tolerance", 48*time.Hour, "How far from a bank statement line's booking day a ledger transaction can be created and still match it without a reference")
+	openingFunding := fs.String("opening.funding", os.Getenv("OPENING_FUNDING_ACCOUNTS"), "Accounts funding opening balances, as CUR=account_id pairs separated by commas")
 
 	loggerConfig := logging.LoggerConfig{}
 	fs.StringVar(&loggerConfig.CommandHandler, "logger.handler.type", "json", "handler type e.g json, otherwise default will be text type")
@@ -30,12 +72,32 @@ func Load() (Config, error) {
 		return Config{}, err
 	}
 
+	if *instance == "" {
+		host, _ := os.Hostname()
+		*instance = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
 	config := Config{
-		HttpAddress:    *httpAddress,
-		PostgresDSN:    *postgresDSN,
-		MongoURI:       *mongoURI,
-		KafkaBrokerURL: *kafkaBroker,
-		LoggerConfig:   loggerConfig,
+		HttpAddress:     *httpAddress,
+		PostgresDSN:     *postgresDSN,
+		MongoURI:        *mongoURI,
+		KafkaBrokerURL:  *kafkaBroker,
+		FeeSchedule:     *feeSchedule,
+		ChartOfAccounts: *chartOfAccounts,
+		FXRates:         *fxRates,
+		FXSpread:        *fxSpread,
+		FXQuoteTTL:      *fxQuoteTTL,
+		ScheduleTick:    *scheduleTick,
+		BatchMaxItems:   *batchMaxItems,
+		BankID:          *bankID,
+		OpeningFunding:  *openingFunding,
+		Instance:        *instance,
+		AuditKey:        *auditKey,
+		AuditInterval:   *auditInterval,
+		Reconcile:       reconcile,
+		BankTolerance:   *bankTolerance,
+		LoggerConfig:    loggerConfig,
+		Args:            fs.Args(),
 	}
 
 	return config, nil
@@ -0,0 +1,81 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net/http"
+)
+
+type CustomerRequest struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	KYCLevel string `json:"kyc_level"`
+}
+
+type UpdateRequest struct {
+	ID       string  `json:"-"`
+	Name     *string `json:"name"`
+	Status   *string `json:"status"`
+	KYCLevel *string `json:"kyc_level"`
+}
+
+type CustomerIDRequest struct {
+	ID string
+}
+
+func decodeCreateCustomerRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req CustomerRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode customer request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	if req.Name == "" {
+		return nil, eError.NewServiceError(
+			errors.New("name is required"), "name is required", "VALIDATION", http.StatusBadRequest)
+	}
+
+	if req.Type == "" {
+		return nil, eError.NewServiceError(
+			errors.New("type is required"), "type is required", "VALIDATION", http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+func decodeCustomerIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id := chi.URLParam(r, "id")
+	if !model.IsValidUUID(id) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid customer id"), "customer id must be a valid UUID", "INVALID_CUSTOMER_ID", http.StatusBadRequest)
+	}
+
+	return CustomerIDRequest{ID: id}, nil
+}
+
+func decodeUpdateCustomerRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	idReq, err := decodeCustomerIDRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req UpdateRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode update customer request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	req.ID = idReq.(CustomerIDRequest).ID
+	return req, nil
+}
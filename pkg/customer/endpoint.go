@@ -0,0 +1,57 @@
+package customer
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+func makeCreateCustomerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(CustomerRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.CreateCustomer(ctx, CreateCustomerRequest{
+			Name:     req.Name,
+			Type:     model.CustomerType(strings.ToLower(req.Type)),
+			KYCLevel: model.KYCLevel(strings.ToLower(req.KYCLevel)),
+		})
+	}
+}
+
+func makeGetCustomerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(CustomerIDRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+		return s.GetCustomer(ctx, req.ID)
+	}
+}
+
+func makeUpdateCustomerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(UpdateRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		update := UpdateCustomerRequest{Name: req.Name}
+		if req.Status != nil {
+			status := model.CustomerStatus(strings.ToLower(*req.Status))
+			update.Status = &status
+		}
+		if req.KYCLevel != nil {
+			level := model.KYCLevel(strings.ToLower(*req.KYCLevel))
+			update.KYCLevel = &level
+		}
+
+		return s.UpdateCustomer(ctx, req.ID, update)
+	}
+}
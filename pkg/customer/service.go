@@ -0,0 +1,119 @@
+// Package customer manages the customers that own accounts.
+package customer
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrCustomerNotFound  = errors.New("customer not found")
+	ErrCustomerNotActive = errors.New("customer not active")
+)
+
+type Service interface {
+	CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*model.Customer, error)
+	GetCustomer(ctx context.Context, id string) (*model.Customer, error)
+	UpdateCustomer(ctx context.Context, id string, req UpdateCustomerRequest) (*model.Customer, error)
+}
+
+type service struct {
+	logger *logging.Logger
+	store  Store
+}
+
+type CreateCustomerRequest struct {
+	Name     string
+	Type     model.CustomerType
+	KYCLevel model.KYCLevel
+}
+
+// UpdateCustomerRequest changes a customer. Nil fields are left unchanged.
+type UpdateCustomerRequest struct {
+	Name     *string
+	Status   *model.CustomerStatus
+	KYCLevel *model.KYCLevel
+}
+
+func NewService(logger *logging.Logger, database *db.DB) Service {
+	return &service{
+		logger: logger,
+		store:  NewStore(database),
+	}
+}
+
+func (s *service) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*model.Customer, error) {
+	if req.KYCLevel == "" {
+		req.KYCLevel = model.KYCLevelNone
+	}
+
+	c := &model.Customer{
+		ID:       model.NewUUID(),
+		Name:     strings.TrimSpace(req.Name),
+		Type:     req.Type,
+		Status:   model.CustomerStatusActive,
+		KYCLevel: req.KYCLevel,
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, eError.NewServiceError(err, err.Error(), "VALIDATION", http.StatusBadRequest)
+	}
+
+	if err := s.store.Insert(ctx, c); err != nil {
+		s.logger.Error("failed to create customer", "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("customer created", "customer_id", c.ID, "type", c.Type)
+	return c, nil
+}
+
+func (s *service) GetCustomer(ctx context.Context, id string) (*model.Customer, error) {
+	c, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrCustomerNotFound) {
+			return nil, eError.NewServiceError(err, "customer not found", "CUSTOMER_NOT_FOUND", http.StatusNotFound)
+		}
+		s.logger.Error("failed to get customer", "customer_id", id, "error", err)
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *service) UpdateCustomer(ctx context.Context, id string, req UpdateCustomerRequest) (*model.Customer, error) {
+	c, err := s.store.Update(ctx, id, func(c *model.Customer) error {
+		if req.Name != nil {
+			c.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Status != nil {
+			c.Status = *req.Status
+		}
+		if req.KYCLevel != nil {
+			c.KYCLevel = *req.KYCLevel
+		}
+
+		if err := c.Validate(); err != nil {
+			return eError.NewServiceError(err, err.Error(), "VALIDATION", http.StatusBadRequest)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrCustomerNotFound) {
+			return nil, eError.NewServiceError(err, "customer not found", "CUSTOMER_NOT_FOUND", http.StatusNotFound)
+		}
+		var serviceErr eError.ServiceError
+		if !errors.As(err, &serviceErr) {
+			s.logger.Error("failed to update customer", "customer_id", id, "error", err)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("customer updated", "customer_id", id, "status", c.Status, "kyc_level", c.KYCLevel)
+	return c, nil
+}
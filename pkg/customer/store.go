@@ -0,0 +1,140 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/pkg/errors"
+	"log/slog"
+)
+
+type Store interface {
+	Insert(ctx context.Context, c *model.Customer) error
+	Get(ctx context.Context, id string) (*model.Customer, error)
+	Update(ctx context.Context, id string, update func(*model.Customer) error) (*model.Customer, error)
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+const customerColumns = `id, name, type, status, kyc_level, created_at, updated_at`
+
+func (s *store) Insert(ctx context.Context, c *model.Customer) error {
+	err := s.db.DB.QueryRowContext(ctx,
+		`INSERT INTO customers (id, name, type, status, kyc_level)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at`,
+		c.ID, c.Name, c.Type, c.Status, c.KYCLevel,
+	).Scan(&c.CreatedAt, &c.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to create customer")
+	}
+	return nil
+}
+
+func (s *store) Get(ctx context.Context, id string) (*model.Customer, error) {
+	c, err := scanCustomer(s.db.DB.QueryRowContext(ctx, `SELECT `+customerColumns+` FROM customers WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get customer")
+	}
+	return c, nil
+}
+
+// Update locks the customer, applies update to it and stores its name, status and KYC level.
+// Nothing is stored when update fails.
+func (s *store) Update(ctx context.Context, id string, update func(*model.Customer) error) (*model.Customer, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	c, err := scanCustomer(tx.QueryRowContext(ctx, `SELECT `+customerColumns+` FROM customers WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get customer")
+	}
+
+	if err := update(c); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE customers SET name = $1, status = $2, kyc_level = $3, updated_at = NOW() WHERE id = $4 RETURNING updated_at`,
+		c.Name, c.Status, c.KYCLevel, id,
+	).Scan(&c.UpdatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update customer")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "transaction commit failed")
+	}
+	return c, nil
+}
+
+// LockActive takes a share lock on the given customers within tx, so they can't be blocked or
+// closed until it ends, and checks that each exists and is active.
+func LockActive(ctx context.Context, tx *sql.Tx, ids ...string) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id::text, status FROM customers WHERE id = ANY($1::uuid[]) FOR SHARE`, pq.Array(ids),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock customers")
+	}
+	defer rows.Close()
+
+	statuses := make(map[string]model.CustomerStatus, len(ids))
+	for rows.Next() {
+		var id string
+		var status model.CustomerStatus
+		if err := rows.Scan(&id, &status); err != nil {
+			return errors.Wrap(err, "failed to scan customer")
+		}
+		statuses[id] = status
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to lock customers")
+	}
+
+	for _, id := range ids {
+		status, ok := statuses[id]
+		if !ok {
+			return errors.Wrapf(ErrCustomerNotFound, "%s", id)
+		}
+		if status != model.CustomerStatusActive {
+			return errors.Wrapf(ErrCustomerNotActive, "%s is %s", id, status)
+		}
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCustomer(row rowScanner) (*model.Customer, error) {
+	var c model.Customer
+	err := row.Scan(&c.ID, &c.Name, &c.Type, &c.Status, &c.KYCLevel, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,78 @@
+package customer_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/customer"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+var customerColumns = []string{"id", "name", "type", "status", "kyc_level", "created_at", "updated_at"}
+
+func TestStore_Insert(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := customer.NewStore(&db.DB{DB: sqlDB})
+	c := &model.Customer{ID: "cust1", Name: "ACME Ltd", Type: model.CustomerTypeBusiness,
+		Status: model.CustomerStatusActive, KYCLevel: model.KYCLevelBasic}
+
+	now := time.Now()
+	mock.ExpectQuery(`INSERT INTO customers \(id, name, type, status, kyc_level\) .* RETURNING created_at, updated_at`).
+		WithArgs("cust1", "ACME Ltd", model.CustomerTypeBusiness, model.CustomerStatusActive, model.KYCLevelBasic).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+
+	assert.NoError(t, store.Insert(context.Background(), c))
+	assert.Equal(t, now, c.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Update(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := customer.NewStore(&db.DB{DB: sqlDB})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM customers WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cust1").
+		WillReturnRows(sqlmock.NewRows(customerColumns).
+			AddRow("cust1", "Jane Doe", "individual", "active", "basic", now, now))
+	mock.ExpectQuery(`UPDATE customers SET name = \$1, status = \$2, kyc_level = \$3, updated_at = NOW\(\) WHERE id = \$4 RETURNING updated_at`).
+		WithArgs("Jane Doe", model.CustomerStatusBlocked, model.KYCLevelBasic, "cust1").
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+	mock.ExpectCommit()
+
+	updated, err := store.Update(context.Background(), "cust1", func(c *model.Customer) error {
+		c.Status = model.CustomerStatusBlocked
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, model.CustomerStatusBlocked, updated.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Get_NotFound(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := customer.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectQuery(`SELECT .* FROM customers WHERE id = \$1`).
+		WithArgs("cust1").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = store.Get(context.Background(), "cust1")
+	assert.ErrorIs(t, err, customer.ErrCustomerNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
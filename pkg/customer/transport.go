@@ -0,0 +1,43 @@
+package customer
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	createHandler := kithttp.NewServer(
+		makeCreateCustomerEndpoint(ms),
+		decodeCreateCustomerRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	getHandler := kithttp.NewServer(
+		makeGetCustomerEndpoint(ms),
+		decodeCustomerIDRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	updateHandler := kithttp.NewServer(
+		makeUpdateCustomerEndpoint(ms),
+		decodeUpdateCustomerRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("POST", "/customers", createHandler)
+	r.Method("GET", "/customers/{id}", getHandler)
+	r.Method("PATCH", "/customers/{id}", updateHandler)
+
+	return http.Endpoint{Pattern: "/customers*", Handler: r}
+}
@@ -0,0 +1,200 @@
+// Package fee evaluates the fee schedule applied to deposits and withdrawals.
+//
+// A schedule is a list of rules loaded from a JSON file. Every rule that matches
+// the transaction type, currency and account tier produces one fee, which the
+// transaction store posts as a linked entry against the customer account and
+// credits to the revenue account configured for the currency, or else to the
+// chart of accounts' fee revenue account in that currency.
+package fee
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidSchedule         = errors.New("invalid fee schedule")
+	ErrRevenueAccountNotConfig = errors.New("no fee revenue account configured for currency")
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Band is one step of a tiered rule. Amounts up to and including UpTo are
+// charged the band's flat and percentage fee; a zero UpTo means unbounded.
+type Band struct {
+	UpTo    decimal.Decimal `json:"up_to"`
+	Flat    decimal.Decimal `json:"flat"`
+	Percent decimal.Decimal `json:"percent"`
+}
+
+// Rule describes a single fee. Empty Currency or Tier match any value, and accounts of a tier in
+// ExcludeTiers are never charged the fee, e.g. to waive a standard fee for premium accounts.
+// Min and Max cap the computed fee; a zero Max means no upper cap.
+type Rule struct {
+	Name         string          `json:"name"`
+	Type         string          `json:"type"`
+	Currency     string          `json:"currency"`
+	Tier         string          `json:"tier"`
+	ExcludeTiers []string        `json:"exclude_tiers"`
+	Flat         decimal.Decimal `json:"flat"`
+	Percent      decimal.Decimal `json:"percent"`
+	Bands        []Band          `json:"bands"`
+	Min          decimal.Decimal `json:"min"`
+	Max          decimal.Decimal `json:"max"`
+}
+
+// Schedule is the full set of fee rules together with the revenue account
+// that collects fees in each currency.
+type Schedule struct {
+	RevenueAccounts map[string]string `json:"revenue_accounts"`
+	Rules           []Rule            `json:"rules"`
+}
+
+// LoadSchedule reads a schedule from a JSON file. An empty path yields an empty schedule, which charges nothing.
+func LoadSchedule(path string) (*Schedule, error) {
+	if path == "" {
+		return &Schedule{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read fee schedule")
+	}
+
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, errors.Wrap(err, "failed to parse fee schedule")
+	}
+
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// Validate checks that every rule is well formed.
+func (s *Schedule) Validate() error {
+	for _, r := range s.Rules {
+		if r.Name == "" {
+			return errors.Wrap(ErrInvalidSchedule, "rule name is required")
+		}
+		if r.Type == "" {
+			return errors.Wrapf(ErrInvalidSchedule, "rule %s: type is required", r.Name)
+		}
+		if r.Flat.IsNegative() || r.Percent.IsNegative() || r.Min.IsNegative() || r.Max.IsNegative() {
+			return errors.Wrapf(ErrInvalidSchedule, "rule %s: amounts must not be negative", r.Name)
+		}
+		if !r.Max.IsZero() && r.Max.LessThan(r.Min) {
+			return errors.Wrapf(ErrInvalidSchedule, "rule %s: max is lower than min", r.Name)
+		}
+		for i, b := range r.Bands {
+			if b.Flat.IsNegative() || b.Percent.IsNegative() || b.UpTo.IsNegative() {
+				return errors.Wrapf(ErrInvalidSchedule, "rule %s: band amounts must not be negative", r.Name)
+			}
+			if i > 0 && (r.Bands[i-1].UpTo.IsZero() || (!b.UpTo.IsZero() && b.UpTo.LessThanOrEqual(r.Bands[i-1].UpTo))) {
+				return errors.Wrapf(ErrInvalidSchedule, "rule %s: bands must be in ascending order", r.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Calculate returns the fees charged on a transaction, rounded to the currency's minor units.
+// Rules that compute to zero are omitted.
+func (s *Schedule) Calculate(txnType, currency, tier string, amount decimal.Decimal) []model.Fee {
+	if s == nil {
+		return nil
+	}
+
+	var fees []model.Fee
+	for _, r := range s.Rules {
+		if !r.matches(txnType, currency, tier) {
+			continue
+		}
+
+		charge := r.charge(amount).Round(model.CurrencyDecimals(currency))
+		if !charge.IsPositive() {
+			continue
+		}
+
+		fees = append(fees, model.Fee{
+			Rule:     r.Name,
+			Amount:   model.Decimal{Decimal: charge},
+			Currency: currency,
+		})
+	}
+	return fees
+}
+
+// WithDefaultRevenueAccounts collects fees in the given accounts, by currency, for currencies the
+// schedule has no revenue account for.
+func (s *Schedule) WithDefaultRevenueAccounts(accounts map[string]string) *Schedule {
+	for currency, id := range accounts {
+		if s.RevenueAccounts[currency] != "" {
+			continue
+		}
+		if s.RevenueAccounts == nil {
+			s.RevenueAccounts = make(map[string]string)
+		}
+		s.RevenueAccounts[currency] = id
+	}
+	return s
+}
+
+// RevenueAccount returns the account that collects fees in the currency.
+func (s *Schedule) RevenueAccount(currency string) (string, error) {
+	if s != nil {
+		if id, ok := s.RevenueAccounts[currency]; ok && id != "" {
+			return id, nil
+		}
+	}
+	return "", errors.Wrap(ErrRevenueAccountNotConfig, currency)
+}
+
+// Total sums the fee amounts.
+func Total(fees []model.Fee) decimal.Decimal {
+	total := decimal.Zero
+	for _, f := range fees {
+		total = total.Add(f.Amount.Unwrap())
+	}
+	return total
+}
+
+func (r Rule) matches(txnType, currency, tier string) bool {
+	if !strings.EqualFold(r.Type, txnType) {
+		return false
+	}
+	if r.Currency != "" && r.Currency != currency {
+		return false
+	}
+	if r.Tier != "" && r.Tier != tier {
+		return false
+	}
+	return !slices.Contains(r.ExcludeTiers, tier)
+}
+
+func (r Rule) charge(amount decimal.Decimal) decimal.Decimal {
+	charge := r.Flat.Add(amount.Mul(r.Percent).Div(hundred))
+
+	for _, b := range r.Bands {
+		if b.UpTo.IsZero() || amount.LessThanOrEqual(b.UpTo) {
+			charge = charge.Add(b.Flat).Add(amount.Mul(b.Percent).Div(hundred))
+			break
+		}
+	}
+
+	if charge.LessThan(r.Min) {
+		charge = r.Min
+	}
+	if !r.Max.IsZero() && charge.GreaterThan(r.Max) {
+		charge = r.Max
+	}
+	return charge
+}
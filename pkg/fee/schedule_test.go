@@ -0,0 +1,86 @@
+package fee_test
+
+import (
+	"testing"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/fee"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Calculate(t *testing.T) {
+	schedule := &fee.Schedule{
+		Rules: []fee.Rule{
+			{Name: "withdrawal", Type: "withdrawal", ExcludeTiers: []string{"premium"},
+				Percent: decimal.NewFromFloat(1.5), Min: decimal.NewFromInt(1), Max: decimal.NewFromInt(10)},
+			{Name: "jpy-flat", Type: "withdrawal", Currency: "JPY", Flat: decimal.NewFromFloat(99.6)},
+			{Name: "deposit-bands", Type: "deposit", Bands: []fee.Band{
+				{UpTo: decimal.NewFromInt(100), Flat: decimal.NewFromFloat(0.5)},
+				{UpTo: decimal.NewFromInt(1000), Percent: decimal.NewFromFloat(0.25)},
+				{Flat: decimal.NewFromInt(5)},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		txnType  string
+		currency string
+		tier     string
+		amount   string
+		want     map[string]string
+	}{
+		{"percentage within caps", "withdrawal", "USD", "standard", "200", map[string]string{"withdrawal": "3"}},
+		{"minimum applied", "withdrawal", "USD", "standard", "10", map[string]string{"withdrawal": "1"}},
+		{"maximum applied", "withdrawal", "USD", "standard", "5000", map[string]string{"withdrawal": "10"}},
+		{"rounded to minor units", "withdrawal", "USD", "standard", "123.45", map[string]string{"withdrawal": "1.85"}},
+		{"currency specific rule", "withdrawal", "JPY", "standard", "1000", map[string]string{"withdrawal": "10", "jpy-flat": "100"}},
+		{"excluded tier", "withdrawal", "USD", "premium", "200", map[string]string{}},
+		{"excluded tier, other rules apply", "withdrawal", "JPY", "premium", "1000", map[string]string{"jpy-flat": "100"}},
+		{"first band", "deposit", "USD", "standard", "100", map[string]string{"deposit-bands": "0.5"}},
+		{"second band", "deposit", "USD", "standard", "400", map[string]string{"deposit-bands": "1"}},
+		{"open ended band", "deposit", "USD", "standard", "2000", map[string]string{"deposit-bands": "5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fees := schedule.Calculate(tt.txnType, tt.currency, tt.tier, decimal.RequireFromString(tt.amount))
+
+			got := map[string]string{}
+			for _, f := range fees {
+				assert.Equal(t, tt.currency, f.Currency)
+				got[f.Rule] = f.Amount.String()
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	schedule := &fee.Schedule{
+		Rules: []fee.Rule{
+			{Name: "bad-caps", Type: "withdrawal", Min: decimal.NewFromInt(5), Max: decimal.NewFromInt(1)},
+		},
+	}
+	assert.ErrorIs(t, schedule.Validate(), fee.ErrInvalidSchedule)
+
+	var empty *fee.Schedule
+	assert.Empty(t, empty.Calculate("withdrawal", "USD", "standard", decimal.NewFromInt(10)))
+}
+
+func TestSchedule_WithDefaultRevenueAccounts(t *testing.T) {
+	schedule := &fee.Schedule{RevenueAccounts: map[string]string{"USD": "usd-revenue"}}
+	schedule.WithDefaultRevenueAccounts(map[string]string{"USD": "usd-chart", "EUR": "eur-chart"})
+
+	// The schedule's own accounts take precedence
+	usd, err := schedule.RevenueAccount("USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "usd-revenue", usd)
+
+	eur, err := schedule.RevenueAccount("EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, "eur-chart", eur)
+
+	_, err = schedule.RevenueAccount("GBP")
+	assert.ErrorIs(t, err, fee.ErrRevenueAccountNotConfig)
+}
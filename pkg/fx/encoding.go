@@ -0,0 +1,62 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"net/http"
+)
+
+type QuoteRequest struct {
+	FromAccountID string          `json:"from_account_id"`
+	ToAccountID   string          `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+}
+
+type ConversionRequest struct {
+	QuoteID string `json:"quote_id"`
+}
+
+func decodeQuoteRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req QuoteRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode quote request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	if req.FromAccountID == "" || req.ToAccountID == "" {
+		return nil, eError.NewServiceError(
+			errors.New("from_account_id and to_account_id are required"), "from_account_id and to_account_id are required", "MISSING_ACCOUNT_ID", http.StatusBadRequest)
+	}
+
+	if !req.Amount.IsPositive() {
+		return nil, eError.NewServiceError(
+			errors.New("amount must be positive"), "amount must be greater than zero", "INVALID_AMOUNT", http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+func decodeConversionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req ConversionRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode conversion request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	if req.QuoteID == "" {
+		return nil, eError.NewServiceError(
+			errors.New("quote_id is required"), "quote_id is required", "MISSING_QUOTE_ID", http.StatusBadRequest)
+	}
+
+	return req, nil
+}
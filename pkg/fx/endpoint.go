@@ -0,0 +1,35 @@
+package fx
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+func makeQuoteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(QuoteRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.CreateQuote(ctx, CreateQuoteRequest{
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        req.Amount,
+		})
+	}
+}
+
+func makeConversionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(ConversionRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.ExecuteQuote(ctx, req.QuoteID)
+	}
+}
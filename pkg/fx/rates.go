@@ -0,0 +1,80 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ratePrecision is the number of decimal places kept on cross rates.
+const ratePrecision = 10
+
+var ErrRateUnavailable = errors.New("exchange rate unavailable")
+
+// RateProvider returns the mid-market rate for converting one unit of from into to.
+// Implementations backed by a market data feed can be plugged into NewService.
+type RateProvider interface {
+	Rate(ctx context.Context, from, to string) (decimal.Decimal, error)
+}
+
+// StaticProvider serves rates from a fixed table, quoted against a single base currency.
+type StaticProvider struct {
+	Base  string                     `json:"base"`
+	Rates map[string]decimal.Decimal `json:"rates"` // Units of each currency per one unit of Base
+}
+
+// LoadStaticProvider reads a rate table from a JSON file:
+//
+//	{"base": "USD", "rates": {"EUR": "0.92", "GBP": "0.79", "JPY": "157.3"}}
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	if path == "" {
+		return &StaticProvider{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read rate table")
+	}
+
+	var p StaticProvider
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errors.Wrap(err, "failed to parse rate table")
+	}
+
+	for currency, rate := range p.Rates {
+		if !rate.IsPositive() {
+			return nil, errors.Errorf("rate for %s must be positive", currency)
+		}
+	}
+
+	return &p, nil
+}
+
+// Rate derives the cross rate through the base currency.
+func (p *StaticProvider) Rate(_ context.Context, from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+
+	fromRate, ok := p.baseRate(from)
+	if !ok {
+		return decimal.Zero, errors.Wrap(ErrRateUnavailable, from)
+	}
+	toRate, ok := p.baseRate(to)
+	if !ok {
+		return decimal.Zero, errors.Wrap(ErrRateUnavailable, to)
+	}
+
+	return toRate.DivRound(fromRate, ratePrecision), nil
+}
+
+func (p *StaticProvider) baseRate(currency string) (decimal.Decimal, bool) {
+	if currency == p.Base && p.Base != "" {
+		return decimal.NewFromInt(1), true
+	}
+	rate, ok := p.Rates[currency]
+	return rate, ok
+}
@@ -0,0 +1,36 @@
+package fx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/fx"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticProvider_Rate(t *testing.T) {
+	provider := &fx.StaticProvider{
+		Base: "USD",
+		Rates: map[string]decimal.Decimal{
+			"EUR": decimal.RequireFromString("0.8"),
+			"GBP": decimal.RequireFromString("0.5"),
+		},
+	}
+	ctx := context.Background()
+
+	rate, err := provider.Rate(ctx, "USD", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.8", rate.String())
+
+	rate, err = provider.Rate(ctx, "EUR", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.25", rate.String())
+
+	rate, err = provider.Rate(ctx, "EUR", "GBP")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.625", rate.String())
+
+	_, err = provider.Rate(ctx, "USD", "JPY")
+	assert.ErrorIs(t, err, fx.ErrRateUnavailable)
+}
@@ -0,0 +1,196 @@
+// Package fx converts funds between a user's accounts in different currencies.
+//
+// A conversion is two steps: a quote fixes the rate (mid rate from the RateProvider less the
+// configured spread) and both amounts for a short time, and executing the quote debits the
+// source account and credits the target account atomically at that rate. Both are saved to their
+// accounts' audit chains like the transactions the processor posts.
+package fx
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/chart"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/mdshahjahanmiah/explore-go/repository"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrQuoteNotFound    = errors.New("quote not found")
+	ErrQuoteExpired     = errors.New("quote expired")
+	ErrQuoteExecuted    = errors.New("quote already executed")
+	ErrCurrencyMismatch = errors.New("account currency does not match quote")
+
+	ErrPositionNotConfigured = errors.New("no fx position account configured for currency")
+)
+
+var hundred = decimal.NewFromInt(100)
+
+type Service interface {
+	CreateQuote(ctx context.Context, req CreateQuoteRequest) (*model.FXQuote, error)
+	ExecuteQuote(ctx context.Context, quoteID string) (*model.FXConversion, error)
+}
+
+type service struct {
+	config   config.Config
+	logger   *logging.Logger
+	store    Store
+	audit    *audit.Chain
+	provider RateProvider
+	spread   decimal.Decimal
+}
+
+type CreateQuoteRequest struct {
+	FromAccountID string
+	ToAccountID   string
+	Amount        decimal.Decimal // Amount to sell from the source account
+}
+
+func NewService(config config.Config, logger *logging.Logger, database *db.DB, repo *repository.Repository[model.Transaction], provider RateProvider) (Service, error) {
+	spread, err := decimal.NewFromString(config.FXSpread)
+	if err != nil || spread.IsNegative() || spread.GreaterThanOrEqual(hundred) {
+		return nil, errors.Errorf("invalid fx spread %q", config.FXSpread)
+	}
+
+	accounts, err := chart.Load(config.ChartOfAccounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service{
+		config:   config,
+		logger:   logger,
+		store:    NewStore(database).WithPositions(accounts.ByCurrency(model.SystemRoleFXPosition)),
+		audit:    audit.NewChain(repo.Collection),
+		provider: provider,
+		spread:   spread,
+	}, nil
+}
+
+func (s *service) CreateQuote(ctx context.Context, req CreateQuoteRequest) (*model.FXQuote, error) {
+	from, err := s.getAccount(ctx, req.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.getAccount(ctx, req.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.UserID != to.UserID {
+		return nil, eError.NewServiceError(
+			errors.New("accounts belong to different users"), "conversions are only allowed between a user's own accounts", "ACCOUNT_OWNER_MISMATCH", http.StatusBadRequest)
+	}
+	if from.Currency == to.Currency {
+		return nil, eError.NewServiceError(
+			errors.New("accounts share a currency"), "accounts must be in different currencies", "SAME_CURRENCY", http.StatusBadRequest)
+	}
+
+	sellAmount := req.Amount.Round(model.CurrencyDecimals(from.Currency))
+	if !sellAmount.IsPositive() {
+		return nil, eError.NewServiceError(
+			errors.New("amount must be positive"), "amount must be greater than zero", "INVALID_AMOUNT", http.StatusBadRequest)
+	}
+
+	mid, err := s.provider.Rate(ctx, from.Currency, to.Currency)
+	if err != nil {
+		s.logger.Error("failed to get exchange rate", "from", from.Currency, "to", to.Currency, "error", err)
+		if errors.Is(err, ErrRateUnavailable) {
+			return nil, eError.NewServiceError(err, "exchange rate unavailable", "RATE_UNAVAILABLE", http.StatusServiceUnavailable)
+		}
+		return nil, err
+	}
+
+	rate := mid.Mul(hundred.Sub(s.spread)).DivRound(hundred, ratePrecision)
+	// The bought amount is truncated to the target currency's minor units
+	buyAmount := sellAmount.Mul(rate).RoundDown(model.CurrencyDecimals(to.Currency))
+	if !buyAmount.IsPositive() {
+		return nil, eError.NewServiceError(
+			errors.New("converted amount rounds to zero"), "amount is too small to convert", "INVALID_AMOUNT", http.StatusBadRequest)
+	}
+
+	now := time.Now().UTC()
+	quote := &model.FXQuote{
+		ID:            model.NewUUID(),
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		FromCurrency:  from.Currency,
+		ToCurrency:    to.Currency,
+		MidRate:       mid,
+		Spread:        s.spread,
+		Rate:          rate,
+		SellAmount:    sellAmount,
+		BuyAmount:     buyAmount,
+		Status:        model.QuoteStatusOpen,
+		ExpiresAt:     now.Add(s.config.FXQuoteTTL),
+	}
+
+	if err := s.store.InsertQuote(ctx, quote); err != nil {
+		s.logger.Error("failed to create quote", "from_account_id", from.ID, "to_account_id", to.ID, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("fx quote created", "quote_id", quote.ID, "rate", quote.Rate, "sell_amount", quote.SellAmount, "buy_amount", quote.BuyAmount)
+	return quote, nil
+}
+
+func (s *service) ExecuteQuote(ctx context.Context, quoteID string) (*model.FXConversion, error) {
+	conversion, movements, err := s.store.Execute(ctx, quoteID, time.Now().UTC())
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrQuoteNotFound):
+		return nil, eError.NewServiceError(err, "quote not found", "QUOTE_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrQuoteExpired):
+		return nil, eError.NewServiceError(err, "quote has expired", "QUOTE_EXPIRED", http.StatusConflict)
+	case errors.Is(err, ErrQuoteExecuted):
+		return nil, eError.NewServiceError(err, "quote was already executed", "QUOTE_EXECUTED", http.StatusConflict)
+	case errors.Is(err, transaction.ErrInsufficientFunds):
+		return nil, eError.NewServiceError(err, "insufficient funds", "INSUFFICIENT_FUNDS", http.StatusConflict)
+	case errors.Is(err, transaction.ErrAccountNotActive):
+		return nil, eError.NewServiceError(err, "account is not active", "ACCOUNT_NOT_ACTIVE", http.StatusConflict)
+	case errors.Is(err, transaction.ErrAccountNotFound):
+		return nil, eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrPositionNotConfigured):
+		s.logger.Error("fx position accounts configured for one currency only", "quote_id", quoteID, "error", err)
+		return nil, eError.NewServiceError(err, "fx position account not configured", "FX_POSITION_NOT_CONFIGURED", http.StatusConflict)
+	case errors.Is(err, transaction.ErrPeriodClosed):
+		return nil, eError.NewServiceError(err, "the conversion falls in a closed period", "PERIOD_CLOSED", http.StatusConflict)
+	default:
+		s.logger.Error("fx conversion failed", "quote_id", quoteID, "error", err)
+		return nil, err
+	}
+
+	for _, txn := range movements {
+		if err := s.audit.Append(ctx, txn); err != nil {
+			s.logger.Error("Audit failed (non-critical)", "id", txn.ID, "error", err)
+		}
+	}
+
+	s.logger.Info("fx conversion executed", "conversion_id", conversion.ID, "quote_id", quoteID,
+		"from_amount", conversion.FromAmount, "to_amount", conversion.ToAmount)
+	return conversion, nil
+}
+
+func (s *service) getAccount(ctx context.Context, accountID string) (model.Account, error) {
+	account, err := s.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, transaction.ErrAccountNotFound) {
+			return model.Account{}, eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+		}
+		return model.Account{}, err
+	}
+
+	if account.Status != model.AccountStatusActive {
+		return model.Account{}, eError.NewServiceError(
+			transaction.ErrAccountNotActive, "account is not active", "ACCOUNT_NOT_ACTIVE", http.StatusConflict)
+	}
+	return account, nil
+}
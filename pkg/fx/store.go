@@ -0,0 +1,311 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"log/slog"
+	"time"
+)
+
+type Store interface {
+	GetAccount(ctx context.Context, accountID string) (model.Account, error)
+	InsertQuote(ctx context.Context, q *model.FXQuote) error
+	Execute(ctx context.Context, quoteID string, now time.Time) (*model.FXConversion, []model.Transaction, error)
+}
+
+type store struct {
+	db        *db.DB
+	positions map[string]string // FX position account per currency
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+// WithPositions sets the FX position accounts, by currency, that take the other side of conversions.
+func (s *store) WithPositions(positions map[string]string) *store {
+	s.positions = positions
+	return s
+}
+
+func (s *store) GetAccount(ctx context.Context, accountID string) (model.Account, error) {
+	var account model.Account
+	err := s.db.DB.QueryRowContext(ctx,
+		`SELECT id, user_id, balance, currency, status FROM accounts WHERE id = $1`, accountID,
+	).Scan(&account.ID, &account.UserID, &account.Balance, &account.Currency, &account.Status)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Account{}, transaction.ErrAccountNotFound
+		}
+		return model.Account{}, errors.Wrap(err, "failed to get account")
+	}
+	return account, nil
+}
+
+func (s *store) InsertQuote(ctx context.Context, q *model.FXQuote) error {
+	err := s.db.DB.QueryRowContext(ctx,
+		`INSERT INTO fx_quotes
+		(id, from_account_id, to_account_id, from_currency, to_currency, mid_rate, spread, rate, sell_amount, buy_amount, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING created_at`,
+		q.ID, q.FromAccountID, q.ToAccountID, q.FromCurrency, q.ToCurrency, q.MidRate, q.Spread, q.Rate,
+		q.SellAmount, q.BuyAmount, q.Status, q.ExpiresAt,
+	).Scan(&q.CreatedAt)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to create quote")
+	}
+	return nil
+}
+
+// Execute converts funds at the quoted rate. The quote and both accounts are locked, the
+// source account is debited and the target credited, and the quote is consumed, all in
+// one SQL transaction under the processor's ledger period lock and balance rule. When both
+// currencies have an FX position account, the sold amount is credited to the position in the
+// source currency and the bought amount debited from the one in the target currency, so each
+// currency's entries balance; a position account in only one of them is refused. It returns the
+// conversion and its legs on the customer accounts, the debit first.
+func (s *store) Execute(ctx context.Context, quoteID string, now time.Time) (*model.FXConversion, []model.Transaction, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	var q model.FXQuote
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, from_account_id, to_account_id, from_currency, to_currency, rate, spread, sell_amount, buy_amount, status, expires_at
+		FROM fx_quotes WHERE id = $1 FOR UPDATE`, quoteID,
+	).Scan(&q.ID, &q.FromAccountID, &q.ToAccountID, &q.FromCurrency, &q.ToCurrency, &q.Rate, &q.Spread,
+		&q.SellAmount, &q.BuyAmount, &q.Status, &q.ExpiresAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrQuoteNotFound
+		}
+		return nil, nil, errors.Wrap(err, "failed to get quote")
+	}
+
+	if q.Status == model.QuoteStatusExecuted {
+		return nil, nil, ErrQuoteExecuted
+	}
+	if q.Expired(now) {
+		return nil, nil, ErrQuoteExpired
+	}
+
+	// Locking the ledger period like the processor, so the value date can't be closed before this commits
+	valueDate, err := transaction.BookingDate(ctx, tx, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Lock both accounts in a stable order to avoid deadlocks with opposite conversions
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, balance, currency, status, type FROM accounts WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`,
+		q.FromAccountID, q.ToAccountID,
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to lock accounts")
+	}
+
+	accounts := map[string]model.Account{}
+	for rows.Next() {
+		var a model.Account
+		if err := rows.Scan(&a.ID, &a.Balance, &a.Currency, &a.Status, &a.Type); err != nil {
+			rows.Close()
+			return nil, nil, errors.Wrap(err, "failed to scan account")
+		}
+		accounts[a.ID] = a
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to lock accounts")
+	}
+
+	from, okFrom := accounts[q.FromAccountID]
+	to, okTo := accounts[q.ToAccountID]
+	if !okFrom || !okTo {
+		return nil, nil, transaction.ErrAccountNotFound
+	}
+	if from.Status != model.AccountStatusActive || to.Status != model.AccountStatusActive {
+		return nil, nil, transaction.ErrAccountNotActive
+	}
+	if from.Currency != q.FromCurrency || to.Currency != q.ToCurrency {
+		return nil, nil, ErrCurrencyMismatch
+	}
+
+	// Debit-normal accounts go negative as they are debited, like in the processor
+	fromBalance := from.Balance.Sub(q.SellAmount)
+	if fromBalance.IsNegative() && !from.Type.AllowsNegative() {
+		return nil, nil, transaction.ErrInsufficientFunds
+	}
+
+	conversion := &model.FXConversion{
+		ID:                  model.NewUUID(),
+		QuoteID:             q.ID,
+		FromAccountID:       q.FromAccountID,
+		ToAccountID:         q.ToAccountID,
+		DebitTransactionID:  model.DeriveUUID("fx", q.ID, "debit"),
+		CreditTransactionID: model.DeriveUUID("fx", q.ID, "credit"),
+		Rate:                q.Rate,
+		Spread:              q.Spread,
+		FromAmount:          q.SellAmount,
+		FromCurrency:        q.FromCurrency,
+		ToAmount:            q.BuyAmount,
+		ToCurrency:          q.ToCurrency,
+		CreatedAt:           now,
+	}
+
+	toBalance := to.Balance.Add(q.BuyAmount)
+
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, fromBalance, q.FromAccountID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to debit account")
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, toBalance, q.ToAccountID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to credit account")
+	}
+
+	legs := []transaction.Entry{
+		{ID: conversion.DebitTransactionID, AccountID: q.FromAccountID, Type: transaction.TransactionTypeWithdrawal,
+			Amount: q.SellAmount, Currency: q.FromCurrency, BalanceAfter: fromBalance},
+		{ID: conversion.CreditTransactionID, AccountID: q.ToAccountID, Type: transaction.TransactionTypeDeposit,
+			Amount: q.BuyAmount, Currency: q.ToCurrency, ParentID: conversion.DebitTransactionID, BalanceAfter: toBalance},
+	}
+	positionLegs, err := s.positionLegs(ctx, tx, q, conversion)
+	if err != nil {
+		return nil, nil, err
+	}
+	legs = append(legs, positionLegs...)
+
+	var movements []model.Transaction
+	for _, leg := range legs {
+		leg.ReferenceID = model.DeriveUUID(leg.ID, "reference")
+		leg.CreatedAt = now
+		leg.ValueDate = valueDate
+		sequence, err := transaction.InsertEntry(ctx, tx, leg)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to create transaction record")
+		}
+		if leg.ID == conversion.DebitTransactionID || leg.ID == conversion.CreditTransactionID {
+			movements = append(movements, movement(leg, sequence))
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE fx_quotes SET status = $1 WHERE id = $2`, model.QuoteStatusExecuted, q.ID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to consume quote")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO fx_conversions
+		(id, quote_id, from_account_id, to_account_id, debit_transaction_id, credit_transaction_id, rate, spread, from_amount, from_currency, to_amount, to_currency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		conversion.ID, conversion.QuoteID, conversion.FromAccountID, conversion.ToAccountID,
+		conversion.DebitTransactionID, conversion.CreditTransactionID, conversion.Rate, conversion.Spread,
+		conversion.FromAmount, conversion.FromCurrency, conversion.ToAmount, conversion.ToCurrency, conversion.CreatedAt,
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to record conversion")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, errors.Wrap(err, "transaction commit failed")
+	}
+	return conversion, movements, nil
+}
+
+// movement returns the completed transaction an inserted leg records.
+func movement(leg transaction.Entry, sequence int64) model.Transaction {
+	return model.Transaction{
+		ID:           leg.ID,
+		AccountID:    leg.AccountID,
+		Type:         leg.Type,
+		Amount:       model.Decimal{Decimal: leg.Amount},
+		Currency:     leg.Currency,
+		ReferenceID:  leg.ReferenceID,
+		Status:       transaction.TransactionStatusCompleted,
+		ParentID:     leg.ParentID,
+		Sequence:     sequence,
+		BalanceAfter: &model.Decimal{Decimal: leg.BalanceAfter},
+		ValueDate:    leg.ValueDate,
+		CreatedAt:    leg.CreatedAt,
+	}
+}
+
+// positionLegs locks the position accounts of the quote's currencies, updates their balances and
+// returns their entries, none when neither currency has a position account. With a position
+// account in only one currency the conversion couldn't balance, so it is refused. They are locked
+// after the customer accounts, which are never position accounts, so lock order stays stable.
+func (s *store) positionLegs(ctx context.Context, tx *sql.Tx, q model.FXQuote, conversion *model.FXConversion) ([]transaction.Entry, error) {
+	fromID, toID := s.positions[q.FromCurrency], s.positions[q.ToCurrency]
+	switch {
+	case fromID == "" && toID == "":
+		return nil, nil
+	case fromID == "":
+		return nil, errors.Wrap(ErrPositionNotConfigured, q.FromCurrency)
+	case toID == "":
+		return nil, errors.Wrap(ErrPositionNotConfigured, q.ToCurrency)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, balance, currency, type FROM accounts WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`, fromID, toID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock fx position accounts")
+	}
+
+	positions := map[string]model.Account{}
+	for rows.Next() {
+		var a model.Account
+		if err := rows.Scan(&a.ID, &a.Balance, &a.Currency, &a.Type); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "failed to scan fx position account")
+		}
+		positions[a.ID] = a
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to lock fx position accounts")
+	}
+
+	from, okFrom := positions[fromID]
+	to, okTo := positions[toID]
+	if !okFrom || !okTo {
+		return nil, errors.Wrap(transaction.ErrAccountNotFound, "fx position account")
+	}
+	if from.Currency != q.FromCurrency || to.Currency != q.ToCurrency {
+		return nil, errors.Wrap(ErrCurrencyMismatch, "fx position account")
+	}
+
+	fromBalance := from.Balance.Add(q.SellAmount)
+	toBalance := to.Balance.Sub(q.BuyAmount)
+	if toBalance.IsNegative() && !to.Type.AllowsNegative() {
+		return nil, errors.Wrap(transaction.ErrInsufficientFunds, "fx position account")
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, fromBalance, fromID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to credit fx position account")
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, toBalance, toID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to debit fx position account")
+	}
+
+	return []transaction.Entry{
+		{ID: model.DeriveUUID("fx", q.ID, "position", "credit"), AccountID: fromID, Type: transaction.TransactionTypeDeposit,
+			Amount: q.SellAmount, Currency: q.FromCurrency, ParentID: conversion.DebitTransactionID, BalanceAfter: fromBalance},
+		{ID: model.DeriveUUID("fx", q.ID, "position", "debit"), AccountID: toID, Type: transaction.TransactionTypeWithdrawal,
+			Amount: q.BuyAmount, Currency: q.ToCurrency, ParentID: conversion.DebitTransactionID, BalanceAfter: toBalance},
+	}, nil
+}
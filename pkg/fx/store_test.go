@@ -0,0 +1,260 @@
+package fx_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/fx"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var quoteColumns = []string{"id", "from_account_id", "to_account_id", "from_currency", "to_currency", "rate", "spread", "sell_amount", "buy_amount", "status", "expires_at"}
+
+func TestStore_Execute_Success(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := fx.NewStore(&db.DB{DB: sqlDB})
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM fx_quotes WHERE id = \$1 FOR UPDATE`).
+		WithArgs("quote1").
+		WillReturnRows(sqlmock.NewRows(quoteColumns).
+			AddRow("quote1", "usd", "eur", "USD", "EUR", "0.9", "0.5", "100", "90", model.QuoteStatusOpen, now.Add(time.Minute)))
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("usd", "eur").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "type"}).
+			AddRow("eur", "0", "EUR", model.AccountStatusActive, model.AccountTypeLiability).
+			AddRow("usd", "150", "USD", model.AccountStatusActive, model.AccountTypeLiability))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).
+		WithArgs("50", "usd").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).
+		WithArgs("90", "eur").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO transactions .* RETURNING sequence`).
+		WithArgs(sqlmock.AnyArg(), "usd", "100", transaction.TransactionTypeWithdrawal, sqlmock.AnyArg(), "USD", transaction.TransactionStatusCompleted, "", "50", now, nil, nil, nil, "", "", "", "{}", now.Format(time.DateOnly)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(4))
+	mock.ExpectQuery(`INSERT INTO transactions .* RETURNING sequence`).
+		WithArgs(sqlmock.AnyArg(), "eur", "90", transaction.TransactionTypeDeposit, sqlmock.AnyArg(), "EUR", transaction.TransactionStatusCompleted, sqlmock.AnyArg(), "90", now, nil, nil, nil, "", "", "", "{}", now.Format(time.DateOnly)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectExec(`UPDATE fx_quotes SET status = \$1 WHERE id = \$2`).
+		WithArgs(model.QuoteStatusExecuted, "quote1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO fx_conversions`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	conversion, movements, err := store.Execute(context.Background(), "quote1", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "quote1", conversion.QuoteID)
+	assert.Equal(t, "90", conversion.ToAmount.String())
+
+	// Both customer legs are returned for their accounts' audit chains as they were booked
+	require.Len(t, movements, 2)
+	debit, credit := movements[0], movements[1]
+	assert.Equal(t, conversion.DebitTransactionID, debit.ID)
+	assert.Equal(t, "usd", debit.AccountID)
+	assert.Equal(t, transaction.TransactionTypeWithdrawal, debit.Type)
+	assert.Equal(t, "50", debit.BalanceAfter.String())
+	assert.Equal(t, int64(4), debit.Sequence)
+	assert.Equal(t, now.Format(time.DateOnly), debit.ValueDate)
+	assert.Equal(t, conversion.CreditTransactionID, credit.ID)
+	assert.Equal(t, "eur", credit.AccountID)
+	assert.Equal(t, transaction.TransactionTypeDeposit, credit.Type)
+	assert.Equal(t, conversion.DebitTransactionID, credit.ParentID)
+	assert.Equal(t, "90", credit.BalanceAfter.String())
+	assert.Equal(t, int64(1), credit.Sequence)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Execute_Positions(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := fx.NewStore(&db.DB{DB: sqlDB}).WithPositions(map[string]string{"USD": "pos-usd", "EUR": "pos-eur"})
+	now := time.Now().UTC()
+	entry := []driver.Value{sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), now, nil, nil, nil, "", "", "", "{}", now.Format(time.DateOnly)}
+	leg := func(accountID, amount, txnType, currency, balance string) []driver.Value {
+		args := make([]driver.Value, len(entry))
+		copy(args, entry)
+		args[1], args[2], args[3], args[5], args[8] = accountID, amount, txnType, currency, balance
+		return args
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM fx_quotes WHERE id = \$1 FOR UPDATE`).
+		WithArgs("quote1").
+		WillReturnRows(sqlmock.NewRows(quoteColumns).
+			AddRow("quote1", "usd", "eur", "USD", "EUR", "0.9", "0.5", "100", "90", model.QuoteStatusOpen, now.Add(time.Minute)))
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("usd", "eur").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "type"}).
+			AddRow("eur", "0", "EUR", model.AccountStatusActive, model.AccountTypeLiability).
+			AddRow("usd", "150", "USD", model.AccountStatusActive, model.AccountTypeLiability))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).WithArgs("50", "usd").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).WithArgs("90", "eur").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// The position takes the sold dollars and gives the bought euros, going short in euros
+	mock.ExpectQuery(`SELECT id, balance, currency, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("pos-usd", "pos-eur").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "type"}).
+			AddRow("pos-eur", "10", "EUR", model.AccountTypeAsset).
+			AddRow("pos-usd", "0", "USD", model.AccountTypeAsset))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).WithArgs("100", "pos-usd").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).WithArgs("-80", "pos-eur").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(leg("usd", "100", transaction.TransactionTypeWithdrawal, "USD", "50")...).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(4))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(leg("eur", "90", transaction.TransactionTypeDeposit, "EUR", "90")...).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(leg("pos-usd", "100", transaction.TransactionTypeDeposit, "USD", "100")...).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(7))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(leg("pos-eur", "90", transaction.TransactionTypeWithdrawal, "EUR", "-80")...).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(3))
+	mock.ExpectExec(`UPDATE fx_quotes SET status = \$1 WHERE id = \$2`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO fx_conversions`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, movements, err := store.Execute(context.Background(), "quote1", now)
+	assert.NoError(t, err)
+	assert.Len(t, movements, 2) // position legs are linked to the debit
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Execute_ExpiredQuote(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := fx.NewStore(&db.DB{DB: sqlDB})
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM fx_quotes WHERE id = \$1 FOR UPDATE`).
+		WithArgs("quote1").
+		WillReturnRows(sqlmock.NewRows(quoteColumns).
+			AddRow("quote1", "usd", "eur", "USD", "EUR", "0.9", "0.5", "100", "90", model.QuoteStatusOpen, now.Add(-time.Second)))
+	mock.ExpectRollback()
+
+	_, _, err = store.Execute(context.Background(), "quote1", now)
+	assert.ErrorIs(t, err, fx.ErrQuoteExpired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Execute_InsufficientFunds(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := fx.NewStore(&db.DB{DB: sqlDB})
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM fx_quotes WHERE id = \$1 FOR UPDATE`).
+		WithArgs("quote1").
+		WillReturnRows(sqlmock.NewRows(quoteColumns).
+			AddRow("quote1", "usd", "eur", "USD", "EUR", "0.9", "0.5", "100", "90", model.QuoteStatusOpen, now.Add(time.Minute)))
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("usd", "eur").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "type"}).
+			AddRow("eur", "0", "EUR", model.AccountStatusActive, model.AccountTypeLiability).
+			AddRow("usd", "99.99", "USD", model.AccountStatusActive, model.AccountTypeLiability))
+	mock.ExpectRollback()
+
+	_, _, err = store.Execute(context.Background(), "quote1", now)
+	assert.ErrorIs(t, err, transaction.ErrInsufficientFunds)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Execute_ClosedPeriod(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := fx.NewStore(&db.DB{DB: sqlDB})
+	now := time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM fx_quotes WHERE id = \$1 FOR UPDATE`).
+		WithArgs("quote1").
+		WillReturnRows(sqlmock.NewRows(quoteColumns).
+			AddRow("quote1", "usd", "eur", "USD", "EUR", "0.9", "0.5", "100", "90", model.QuoteStatusOpen, now.Add(time.Minute)))
+
+	// March was closed while the quote was open, so the conversion is booked on the first open day
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("usd", "eur").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "type"}).
+			AddRow("eur", "0", "EUR", model.AccountStatusActive, model.AccountTypeLiability).
+			AddRow("usd", "150", "USD", model.AccountStatusActive, model.AccountTypeLiability))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).WithArgs("50", "usd").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).WithArgs("90", "eur").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(sqlmock.AnyArg(), "usd", "100", transaction.TransactionTypeWithdrawal, sqlmock.AnyArg(), "USD", transaction.TransactionStatusCompleted, "", "50", now, nil, nil, nil, "", "", "", "{}", "2026-04-01").
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(4))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(sqlmock.AnyArg(), "eur", "90", transaction.TransactionTypeDeposit, sqlmock.AnyArg(), "EUR", transaction.TransactionStatusCompleted, sqlmock.AnyArg(), "90", now, nil, nil, nil, "", "", "", "{}", "2026-04-01").
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectExec(`UPDATE fx_quotes SET status = \$1 WHERE id = \$2`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO fx_conversions`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, _, err = store.Execute(context.Background(), "quote1", now)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Execute_OnePosition(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	// Without a euro position the dollars sold can't be balanced
+	store := fx.NewStore(&db.DB{DB: sqlDB}).WithPositions(map[string]string{"USD": "pos-usd"})
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM fx_quotes WHERE id = \$1 FOR UPDATE`).
+		WithArgs("quote1").
+		WillReturnRows(sqlmock.NewRows(quoteColumns).
+			AddRow("quote1", "usd", "eur", "USD", "EUR", "0.9", "0.5", "100", "90", model.QuoteStatusOpen, now.Add(time.Minute)))
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("usd", "eur").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "type"}).
+			AddRow("eur", "0", "EUR", model.AccountStatusActive, model.AccountTypeLiability).
+			AddRow("usd", "150", "USD", model.AccountStatusActive, model.AccountTypeLiability))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).WithArgs("50", "usd").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1`).WithArgs("90", "eur").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	_, _, err = store.Execute(context.Background(), "quote1", now)
+	assert.ErrorIs(t, err, fx.ErrPositionNotConfigured)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,35 @@
+package fx
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	quoteHandler := kithttp.NewServer(
+		makeQuoteEndpoint(ms),
+		decodeQuoteRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	conversionHandler := kithttp.NewServer(
+		makeConversionEndpoint(ms),
+		decodeConversionRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("POST", "/fx/quotes", quoteHandler)
+	r.Method("POST", "/fx/conversions", conversionHandler)
+
+	return http.Endpoint{Pattern: "/fx/*", Handler: r}
+}
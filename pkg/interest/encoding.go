@@ -0,0 +1,65 @@
+package interest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"net/http"
+)
+
+type RatePlanRequest struct {
+	Name       string          `json:"name"`
+	Currency   string          `json:"currency"`
+	AnnualRate decimal.Decimal `json:"annual_rate"`
+	DayCount   int             `json:"day_count"`
+}
+
+type AssignPlanRequest struct {
+	PlanID    string
+	AccountID string
+}
+
+func decodeCreatePlanRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req RatePlanRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode rate plan request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	if req.Name == "" {
+		return nil, eError.NewServiceError(
+			errors.New("name is required"), "name is required", "VALIDATION", http.StatusBadRequest)
+	}
+
+	if req.Currency == "" {
+		return nil, eError.NewServiceError(
+			errors.New("currency is required"), "currency is required", "MISSING_CURRENCY", http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+func decodeListPlansRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeAssignPlanRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := AssignPlanRequest{
+		PlanID:    chi.URLParam(r, "id"),
+		AccountID: chi.URLParam(r, "account_id"),
+	}
+
+	if req.PlanID == "" || req.AccountID == "" {
+		return nil, eError.NewServiceError(
+			errors.New("plan and account ids are required"), "missing id in path", "MISSING_ACCOUNT_ID", http.StatusBadRequest)
+	}
+
+	return req, nil
+}
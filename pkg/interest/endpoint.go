@@ -0,0 +1,51 @@
+package interest
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+type AssignPlanResponse struct {
+	AccountID string `json:"account_id"`
+	PlanID    string `json:"plan_id"`
+}
+
+func makeCreatePlanEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(RatePlanRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.CreatePlan(ctx, CreatePlanRequest{
+			Name:       req.Name,
+			Currency:   req.Currency,
+			AnnualRate: req.AnnualRate,
+			DayCount:   req.DayCount,
+		})
+	}
+}
+
+func makeListPlansEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return s.ListPlans(ctx)
+	}
+}
+
+func makeAssignPlanEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(AssignPlanRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		if err := s.AssignPlan(ctx, req.AccountID, req.PlanID); err != nil {
+			return nil, err
+		}
+
+		return AssignPlanResponse{AccountID: req.AccountID, PlanID: req.PlanID}, nil
+	}
+}
@@ -0,0 +1,169 @@
+// Package interest accrues daily interest on accounts assigned to a rate plan and
+// posts the accrued amount to the account once a month.
+//
+// Accrual and posting are both safe to rerun: a day is accrued at most once per
+// account, and each monthly interest entry is credited and marks the accruals it covers
+// posted in the same SQL transaction, under a reference ID derived from the account
+// and month.
+package interest
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPlanNotFound     = errors.New("rate plan not found")
+	ErrDuplicatePlan    = errors.New("rate plan already exists")
+	ErrCurrencyMismatch = errors.New("rate plan currency does not match account currency")
+)
+
+type Service interface {
+	CreatePlan(ctx context.Context, req CreatePlanRequest) (*model.RatePlan, error)
+	ListPlans(ctx context.Context) ([]model.RatePlan, error)
+	AssignPlan(ctx context.Context, accountID, planID string) error
+	AccrueDaily(ctx context.Context, date time.Time) (int, error)
+	PostMonthly(ctx context.Context, month time.Time) (int, error)
+}
+
+type service struct {
+	config config.Config
+	logger *logging.Logger
+	store  Store
+}
+
+type CreatePlanRequest struct {
+	Name       string
+	Currency   string
+	AnnualRate decimal.Decimal
+	DayCount   int
+}
+
+func NewService(config config.Config, logger *logging.Logger, database *db.DB) Service {
+	return &service{
+		config: config,
+		logger: logger,
+		store:  NewStore(database),
+	}
+}
+
+func (s *service) CreatePlan(ctx context.Context, req CreatePlanRequest) (*model.RatePlan, error) {
+	if req.DayCount == 0 {
+		req.DayCount = 365
+	}
+
+	plan := &model.RatePlan{
+		ID:         model.NewUUID(),
+		Name:       req.Name,
+		Currency:   strings.ToUpper(req.Currency),
+		AnnualRate: req.AnnualRate,
+		DayCount:   req.DayCount,
+	}
+
+	if err := plan.Validate(); err != nil {
+		return nil, eError.NewServiceError(err, "invalid rate plan", "VALIDATION", http.StatusBadRequest)
+	}
+
+	if err := s.store.InsertPlan(ctx, plan); err != nil {
+		s.logger.Error("failed to create rate plan", "name", plan.Name, "error", err)
+		if errors.Is(err, ErrDuplicatePlan) {
+			return nil, eError.NewServiceError(err, "rate plan already exists", "DUPLICATE_RATE_PLAN", http.StatusConflict)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("rate plan created", "plan_id", plan.ID, "name", plan.Name)
+	return plan, nil
+}
+
+func (s *service) ListPlans(ctx context.Context) ([]model.RatePlan, error) {
+	return s.store.ListPlans(ctx)
+}
+
+func (s *service) AssignPlan(ctx context.Context, accountID, planID string) error {
+	err := s.store.AssignPlan(ctx, accountID, planID)
+	switch {
+	case err == nil:
+		s.logger.Info("rate plan assigned", "account_id", accountID, "plan_id", planID)
+		return nil
+	case errors.Is(err, transaction.ErrAccountNotFound):
+		return eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrPlanNotFound):
+		return eError.NewServiceError(err, "rate plan not found", "RATE_PLAN_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrCurrencyMismatch):
+		return eError.NewServiceError(err, "rate plan currency does not match account", "CURRENCY_MISMATCH", http.StatusConflict)
+	default:
+		s.logger.Error("failed to assign rate plan", "account_id", accountID, "plan_id", planID, "error", err)
+		return err
+	}
+}
+
+// AccrueDaily accrues interest for the UTC calendar day containing date.
+func (s *service) AccrueDaily(ctx context.Context, date time.Time) (int, error) {
+	day := truncateDay(date)
+
+	count, err := s.store.Accrue(ctx, day)
+	if err != nil {
+		s.logger.Error("interest accrual failed", "date", day.Format(time.DateOnly), "error", err)
+		return 0, err
+	}
+
+	s.logger.Info("interest accrued", "date", day.Format(time.DateOnly), "accounts", count)
+	return count, nil
+}
+
+// PostMonthly credits all interest accrued up to the end of month as one interest entry per account.
+// The sum of the accruals is rounded half-even to the currency's minor units and the remainder
+// carried into the next month; amounts that round to zero stay unposted and roll into the next
+// month whole. A failed posting is logged and left pending.
+func (s *service) PostMonthly(ctx context.Context, month time.Time) (int, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	through := start.AddDate(0, 1, -1)
+	period := start.Format("2006-01")
+
+	pending, err := s.store.PendingPostings(ctx, through)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, p := range pending {
+		posting := Posting{
+			AccountID:   p.AccountID,
+			Through:     through,
+			ID:          model.DeriveUUID("interest", p.AccountID, period, "transaction"),
+			ReferenceID: model.DeriveUUID("interest", p.AccountID, period),
+		}
+
+		amount, err := s.store.Post(ctx, posting)
+		if err != nil {
+			if !errors.Is(err, transaction.ErrDuplicateTransaction) {
+				s.logger.Error("interest posting failed", "account_id", p.AccountID, "period", period, "error", err)
+			}
+			continue
+		}
+		if !amount.IsPositive() {
+			continue
+		}
+
+		posted++
+		s.logger.Info("interest posted", "account_id", p.AccountID, "period", period, "amount", amount, "transaction_id", posting.ID)
+	}
+
+	return posted, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,187 @@
+package interest_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/interest"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var june = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func newService(t *testing.T) (interest.Service, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	logger := &logging.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	return interest.NewService(config.Config{}, logger, &db.DB{DB: sqlDB}), mock
+}
+
+func TestService_AccrueDaily(t *testing.T) {
+	tests := []struct {
+		name     string
+		balance  string
+		rate     string
+		dayCount int
+		want     string
+	}{
+		// 1000 * 5% / 365 = 0.13698630136986..., half-even to 10 places
+		{"actual/365", "1000", "5", 365, "0.1369863014"},
+		// 1000 * 5% / 360 = 0.13888888888888...
+		{"actual/360", "1000", "5", 360, "0.1388888889"},
+		// 0.73 * 1% / 365 = 0.00002, exact
+		{"small balance", "0.73", "1", 365, "0.00002"},
+		{"overdrawn earns nothing", "-50", "5", 365, "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newService(t)
+			day := time.Date(2026, 6, 15, 18, 30, 0, 0, time.UTC)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT a.id, p.id, p.annual_rate, p.day_count`).
+				WithArgs(time.Date(2026, 6, 16, 0, 0, 0, 0, time.UTC), "2026-06-15").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "plan_id", "annual_rate", "day_count", "balance"}).
+					AddRow("acc1", "plan1", tt.rate, tt.dayCount, tt.balance))
+			mock.ExpectExec(`INSERT INTO interest_accruals`).
+				WithArgs("acc1", "2026-06-15", "plan1", decimal.RequireFromString(tt.balance), decimal.RequireFromString(tt.rate), decimal.RequireFromString(tt.want)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			count, err := service.AccrueDaily(context.Background(), day)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, count)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// expectPosting expects the start of acc1's June posting up to reading its unposted accruals.
+func expectPosting(mock sqlmock.Sqlmock, accrued string) {
+	mock.ExpectQuery(`SELECT i.account_id, a.currency, SUM\(i.amount\)`).
+		WithArgs("2026-06-30").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "sum"}).AddRow("acc1", "USD", accrued))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT balance, currency, status FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "currency", "status"}).AddRow("100", "USD", model.AccountStatusActive))
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+		WithArgs(model.DeriveUUID("interest", "acc1", "2026-06")).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM interest_accruals`).
+		WithArgs("acc1", "2026-06-30").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(accrued))
+}
+
+func TestService_PostMonthly_Rounding(t *testing.T) {
+	tests := []struct {
+		name      string
+		accrued   string
+		posted    string
+		remainder string
+	}{
+		{"rounds down", "3.0149999999", "3.01", "0.0049999999"},
+		{"rounds up", "3.0150000001", "3.02", "-0.0049999999"},
+		// Exact halves go to the even cent
+		{"half to even down", "3.125", "3.12", "0.005"},
+		{"half to even up", "3.135", "3.14", "-0.005"},
+		{"no remainder", "3.14", "3.14", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newService(t)
+			id := model.DeriveUUID("interest", "acc1", "2026-06", "transaction")
+			posted := decimal.RequireFromString(tt.posted)
+			balance := decimal.NewFromInt(100).Add(posted)
+
+			expectPosting(mock, tt.accrued)
+			mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+				WithArgs(balance, "acc1").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).
+				WithArgs(id, "acc1", posted, transaction.TransactionTypeInterest, model.DeriveUUID("interest", "acc1", "2026-06"), "USD",
+					transaction.TransactionStatusCompleted, "", balance, sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(5))
+			mock.ExpectExec(`UPDATE interest_accruals SET posted_transaction_id = \$1`).
+				WithArgs(id, "acc1", "2026-06-30").
+				WillReturnResult(sqlmock.NewResult(0, 30))
+			if tt.remainder != "" {
+				// The remainder is carried to the first day of July
+				mock.ExpectExec(`INSERT INTO interest_accruals .* 'carry'`).
+					WithArgs("2026-07-01", decimal.RequireFromString(tt.remainder), "acc1", id).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectCommit()
+
+			count, err := service.PostMonthly(context.Background(), june)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, count)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestService_PostMonthly_RoundsToZero(t *testing.T) {
+	service, mock := newService(t)
+
+	// Less than half a cent stays unposted and rolls into July whole
+	expectPosting(mock, "0.0049999999")
+	mock.ExpectRollback()
+
+	count, err := service.PostMonthly(context.Background(), june)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_PostMonthly_Rerun(t *testing.T) {
+	service, mock := newService(t)
+
+	// A completed run left nothing unposted
+	mock.ExpectQuery(`SELECT i.account_id, a.currency, SUM\(i.amount\)`).
+		WithArgs("2026-06-30").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "sum"}))
+
+	count, err := service.PostMonthly(context.Background(), june)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// A concurrent run posted the month after this one read the pending accruals
+	mock.ExpectQuery(`SELECT i.account_id, a.currency, SUM\(i.amount\)`).
+		WithArgs("2026-06-30").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "sum"}).AddRow("acc1", "USD", "3.14"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT balance, currency, status FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "currency", "status"}).AddRow("103.14", "USD", model.AccountStatusActive))
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+		WithArgs(model.DeriveUUID("interest", "acc1", "2026-06")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(model.DeriveUUID("interest", "acc1", "2026-06", "transaction")))
+	mock.ExpectRollback()
+
+	count, err = service.PostMonthly(context.Background(), june)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,325 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"time"
+)
+
+// PendingPosting is the unposted interest accrued on an account.
+type PendingPosting struct {
+	AccountID string
+	Currency  string
+	Amount    decimal.Decimal
+}
+
+type Store interface {
+	InsertPlan(ctx context.Context, p *model.RatePlan) error
+	ListPlans(ctx context.Context) ([]model.RatePlan, error)
+	AssignPlan(ctx context.Context, accountID, planID string) error
+	Accrue(ctx context.Context, date time.Time) (int, error)
+	PendingPostings(ctx context.Context, through time.Time) ([]PendingPosting, error)
+	Post(ctx context.Context, posting Posting) (decimal.Decimal, error)
+}
+
+// Posting is one account's credit of the interest accrued through a date.
+type Posting struct {
+	AccountID   string
+	Through     time.Time // Last accrual date included
+	ID          string
+	ReferenceID string
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+func (s *store) InsertPlan(ctx context.Context, p *model.RatePlan) error {
+	err := s.db.DB.QueryRowContext(ctx,
+		`INSERT INTO interest_rate_plans (id, name, currency, annual_rate, day_count)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`,
+		p.ID, p.Name, p.Currency, p.AnnualRate, p.DayCount,
+	).Scan(&p.CreatedAt)
+
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return ErrDuplicatePlan
+		}
+		return errors.Wrap(err, "failed to create rate plan")
+	}
+	return nil
+}
+
+func (s *store) ListPlans(ctx context.Context) ([]model.RatePlan, error) {
+	rows, err := s.db.DB.QueryContext(ctx,
+		`SELECT id, name, currency, annual_rate, day_count, created_at FROM interest_rate_plans ORDER BY name`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list rate plans")
+	}
+	defer rows.Close()
+
+	var plans []model.RatePlan
+	for rows.Next() {
+		var p model.RatePlan
+		if err := rows.Scan(&p.ID, &p.Name, &p.Currency, &p.AnnualRate, &p.DayCount, &p.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan rate plan")
+		}
+		plans = append(plans, p)
+	}
+	return plans, rows.Err()
+}
+
+func (s *store) AssignPlan(ctx context.Context, accountID, planID string) error {
+	var accountCurrency, planCurrency string
+	err := s.db.DB.QueryRowContext(ctx, `SELECT currency FROM accounts WHERE id = $1`, accountID).Scan(&accountCurrency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return transaction.ErrAccountNotFound
+		}
+		return errors.Wrap(err, "failed to get account")
+	}
+
+	err = s.db.DB.QueryRowContext(ctx, `SELECT currency FROM interest_rate_plans WHERE id = $1`, planID).Scan(&planCurrency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPlanNotFound
+		}
+		return errors.Wrap(err, "failed to get rate plan")
+	}
+
+	if accountCurrency != planCurrency {
+		return ErrCurrencyMismatch
+	}
+
+	_, err = s.db.DB.ExecContext(ctx,
+		`INSERT INTO account_interest_plans (account_id, plan_id) VALUES ($1, $2)
+		ON CONFLICT (account_id) DO UPDATE SET plan_id = EXCLUDED.plan_id, assigned_at = NOW()`,
+		accountID, planID,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to assign rate plan")
+	}
+	return nil
+}
+
+// Accrue records one day of interest for every active account on a rate plan. The end-of-day
+// balance is the current balance less the entries booked with a later value date, as in the
+// period close, read from a single snapshot. Days that were already accrued are left untouched,
+// so reruns are harmless.
+func (s *store) Accrue(ctx context.Context, date time.Time) (int, error) {
+	dayEnd := date.AddDate(0, 0, 1)
+
+	tx, err := s.db.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT a.id, p.id, p.annual_rate, p.day_count,
+			a.balance - COALESCE((
+				SELECT SUM(`+transaction.SignedAmountSQL+`) FROM transactions t
+				WHERE t.account_id = a.id AND t.status = 'completed' AND t.value_date > $2
+			), 0)
+		FROM accounts a
+		JOIN account_interest_plans ap ON ap.account_id = a.id
+		JOIN interest_rate_plans p ON p.id = ap.plan_id
+		WHERE a.status = 'active' AND a.created_at < $1`,
+		dayEnd, date.Format(time.DateOnly),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read end-of-day balances")
+	}
+
+	var accruals []model.InterestAccrual
+	for rows.Next() {
+		var plan model.RatePlan
+		var accrual model.InterestAccrual
+		if err := rows.Scan(&accrual.AccountID, &plan.ID, &plan.AnnualRate, &plan.DayCount, &accrual.Balance); err != nil {
+			rows.Close()
+			return 0, errors.Wrap(err, "failed to scan end-of-day balance")
+		}
+		accrual.PlanID = plan.ID
+		accrual.Date = date
+		accrual.Rate = plan.AnnualRate
+		accrual.Amount = plan.DailyInterest(accrual.Balance)
+		accruals = append(accruals, accrual)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrap(err, "failed to read end-of-day balances")
+	}
+
+	inserted := 0
+	for _, a := range accruals {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO interest_accruals (account_id, accrual_date, plan_id, balance, rate, amount)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (account_id, accrual_date, kind) DO NOTHING`,
+			a.AccountID, a.Date.Format(time.DateOnly), a.PlanID, a.Balance, a.Rate, a.Amount,
+		)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to record accrual")
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			inserted++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "transaction commit failed")
+	}
+	return inserted, nil
+}
+
+// PendingPostings sums the unposted accruals up to and including the given date per account.
+func (s *store) PendingPostings(ctx context.Context, through time.Time) ([]PendingPosting, error) {
+	rows, err := s.db.DB.QueryContext(ctx,
+		`SELECT i.account_id, a.currency, SUM(i.amount)
+		FROM interest_accruals i
+		JOIN accounts a ON a.id = i.account_id
+		WHERE i.posted_transaction_id IS NULL AND i.accrual_date <= $1
+		GROUP BY i.account_id, a.currency
+		ORDER BY i.account_id`,
+		through.Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read pending interest")
+	}
+	defer rows.Close()
+
+	var postings []PendingPosting
+	for rows.Next() {
+		var p PendingPosting
+		if err := rows.Scan(&p.AccountID, &p.Currency, &p.Amount); err != nil {
+			return nil, errors.Wrap(err, "failed to scan pending interest")
+		}
+		postings = append(postings, p)
+	}
+	return postings, rows.Err()
+}
+
+// Post credits the unposted interest accrued on the account through posting.Through, rounded
+// half-even to the currency's minor units, as an interest entry and marks the accruals posted, in
+// one SQL transaction. The rounding remainder, positive or negative, is carried into the next
+// posting as an accrual dated the day after posting.Through. It returns the amount credited, zero
+// when it rounds to zero, in which case the accruals stay unposted and roll into the next posting.
+func (s *store) Post(ctx context.Context, posting Posting) (decimal.Decimal, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return decimal.Zero, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	now := time.Now().UTC()
+	valueDate, err := transaction.BookingDate(ctx, tx, now)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	// The account lock serialises concurrent postings to the account
+	var account model.Account
+	err = tx.QueryRowContext(ctx,
+		`SELECT balance, currency, status FROM accounts WHERE id = $1 FOR UPDATE`, posting.AccountID,
+	).Scan(&account.Balance, &account.Currency, &account.Status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, transaction.ErrAccountNotFound
+		}
+		return decimal.Zero, errors.Wrap(err, "failed to get account")
+	}
+	if account.Status != model.AccountStatusActive {
+		return decimal.Zero, transaction.ErrAccountNotActive
+	}
+
+	var existingID string
+	err = tx.QueryRowContext(ctx, `SELECT id FROM transactions WHERE reference_id = $1`, posting.ReferenceID).Scan(&existingID)
+	if err == nil {
+		return decimal.Zero, transaction.ErrDuplicateTransaction
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero, errors.Wrap(err, "failed to check existing transactions")
+	}
+
+	var accrued decimal.Decimal
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(amount), 0) FROM interest_accruals
+		WHERE account_id = $1 AND accrual_date <= $2 AND posted_transaction_id IS NULL`,
+		posting.AccountID, posting.Through.Format(time.DateOnly),
+	).Scan(&accrued)
+	if err != nil {
+		return decimal.Zero, errors.Wrap(err, "failed to read pending interest")
+	}
+
+	amount := accrued.RoundBank(model.CurrencyDecimals(account.Currency))
+	if !amount.IsPositive() {
+		return decimal.Zero, nil
+	}
+
+	balance := account.Balance.Add(amount)
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, balance, posting.AccountID)
+	if err != nil {
+		return decimal.Zero, errors.Wrap(err, "failed to update account balance")
+	}
+
+	_, err = transaction.InsertEntry(ctx, tx, transaction.Entry{
+		ID:           posting.ID,
+		AccountID:    posting.AccountID,
+		Type:         transaction.TransactionTypeInterest,
+		Amount:       amount,
+		Currency:     account.Currency,
+		ReferenceID:  posting.ReferenceID,
+		BalanceAfter: balance,
+		ValueDate:    valueDate,
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return decimal.Zero, errors.Wrap(err, "failed to create transaction record")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE interest_accruals SET posted_transaction_id = $1
+		WHERE account_id = $2 AND accrual_date <= $3 AND posted_transaction_id IS NULL`,
+		posting.ID, posting.AccountID, posting.Through.Format(time.DateOnly),
+	)
+	if err != nil {
+		return decimal.Zero, errors.Wrap(err, "failed to mark accruals as posted")
+	}
+
+	if remainder := accrued.Sub(amount); !remainder.IsZero() {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO interest_accruals (account_id, accrual_date, plan_id, balance, rate, amount, kind)
+			SELECT account_id, $1, plan_id, 0, 0, $2, 'carry' FROM interest_accruals
+			WHERE account_id = $3 AND posted_transaction_id = $4
+			ORDER BY accrual_date DESC LIMIT 1`,
+			posting.Through.AddDate(0, 0, 1).Format(time.DateOnly), remainder, posting.AccountID, posting.ID,
+		)
+		if err != nil {
+			return decimal.Zero, errors.Wrap(err, "failed to carry interest remainder")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return decimal.Zero, errors.Wrap(err, "transaction commit failed")
+	}
+	return amount, nil
+}
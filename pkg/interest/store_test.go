@@ -0,0 +1,49 @@
+package interest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/interest"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Accrue(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := interest.NewStore(&db.DB{DB: sqlDB})
+	day := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+
+	// Accounts opened by midnight accrue, on their balance less the entries valued after the day,
+	// so back-valued entries count from their value date rather than the day they were keyed in
+	mock.ExpectQuery(`SELECT a.id, p.id, p.annual_rate, p.day_count,\s+a.balance - COALESCE\(\(\s+SELECT SUM\(.*\) FROM transactions t\s+WHERE t.account_id = a.id AND t.status = 'completed' AND t.value_date > \$2`).
+		WithArgs(day.AddDate(0, 0, 1), "2026-06-30").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "plan_id", "annual_rate", "day_count", "balance"}).
+			AddRow("acc1", "plan1", "3.65", 365, "1000").
+			AddRow("acc2", "plan1", "3.65", 365, "0"))
+
+	// 1000 * 3.65% / 365 = 0.1 per day
+	mock.ExpectExec(`INSERT INTO interest_accruals .* ON CONFLICT \(account_id, accrual_date, kind\) DO NOTHING`).
+		WithArgs("acc1", "2026-06-30", "plan1", decimal.NewFromInt(1000), decimal.RequireFromString("3.65"), decimal.RequireFromString("0.1")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Already accrued on a previous run
+	mock.ExpectExec(`INSERT INTO interest_accruals`).
+		WithArgs("acc2", "2026-06-30", "plan1", decimal.Zero, decimal.RequireFromString("3.65"), decimal.Zero).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectCommit()
+
+	count, err := store.Accrue(context.Background(), day)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,43 @@
+package interest
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	createPlanHandler := kithttp.NewServer(
+		makeCreatePlanEndpoint(ms),
+		decodeCreatePlanRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	listPlansHandler := kithttp.NewServer(
+		makeListPlansEndpoint(ms),
+		decodeListPlansRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	assignPlanHandler := kithttp.NewServer(
+		makeAssignPlanEndpoint(ms),
+		decodeAssignPlanRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("POST", "/interest/plans", createPlanHandler)
+	r.Method("GET", "/interest/plans", listPlansHandler)
+	r.Method("PUT", "/interest/plans/{id}/accounts/{account_id}", assignPlanHandler)
+
+	return http.Endpoint{Pattern: "/interest/*", Handler: r}
+}
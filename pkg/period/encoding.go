@@ -0,0 +1,63 @@
+package period
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultCloseLimit = 31
+	MaxCloseLimit     = 366
+)
+
+type CloseRequest struct {
+	Date string `json:"date"` // YYYY-MM-DD
+}
+
+func decodeCloseRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req CloseRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode close request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	return parseDate(req.Date)
+}
+
+// decodeStatusRequest reads the number of closes to list.
+func decodeStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return DefaultCloseLimit, nil
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 || n > MaxCloseLimit {
+		return nil, eError.NewServiceError(
+			errors.New("invalid limit"), fmt.Sprintf("limit must be an integer between 1 and %d", MaxCloseLimit), "INVALID_LIMIT", http.StatusBadRequest)
+	}
+	return n, nil
+}
+
+func decodeBalancesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return parseDate(chi.URLParam(r, "date"))
+}
+
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, eError.NewServiceError(err, "date must be in YYYY-MM-DD format", "INVALID_DATE", http.StatusBadRequest)
+	}
+	return date, nil
+}
@@ -0,0 +1,43 @@
+package period
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+func makeCloseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		date, ok := request.(time.Time)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.Close(ctx, date)
+	}
+}
+
+func makeStatusEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		limit, ok := request.(int)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.Status(ctx, limit)
+	}
+}
+
+func makeBalancesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		date, ok := request.(time.Time)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.Balances(ctx, date)
+	}
+}
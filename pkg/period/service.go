@@ -0,0 +1,103 @@
+// Package period closes business dates so nothing can be booked into them afterwards.
+//
+// Every ledger entry carries a value date, the business date it is booked on. Closing a date
+// closes it and every earlier date, and snapshots each account's closing balance for it. The
+// transaction processor refuses transactions back-valued into a closed period and books those
+// without an explicit value date on the first open day.
+package period
+
+import (
+	"context"
+	"fmt"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+type Service interface {
+	Close(ctx context.Context, date time.Time) (model.PeriodClose, error)
+	Status(ctx context.Context, limit int) (Status, error)
+	Balances(ctx context.Context, date time.Time) ([]model.ClosingBalance, error)
+}
+
+// Status is the last closed business date and the most recent closes, latest first.
+type Status struct {
+	ClosedThrough string              `json:"closed_through,omitempty"`
+	Closes        []model.PeriodClose `json:"closes"`
+}
+
+type service struct {
+	config config.Config
+	logger *logging.Logger
+	store  Store
+}
+
+func NewService(config config.Config, logger *logging.Logger, database *db.DB) Service {
+	return &service{
+		config: config,
+		logger: logger,
+		store:  NewStore(database),
+	}
+}
+
+// Close closes the UTC business date containing date. Only dates that have ended can be closed,
+// so transactions created today are never pushed into tomorrow.
+func (s *service) Close(ctx context.Context, date time.Time) (model.PeriodClose, error) {
+	day := truncateDay(date)
+	if !day.Before(truncateDay(time.Now())) {
+		return model.PeriodClose{}, eError.NewServiceError(
+			ErrDateNotEnded, "only business dates before today can be closed", "DATE_NOT_ENDED", http.StatusUnprocessableEntity)
+	}
+
+	closed, err := s.store.Close(ctx, day)
+	if err != nil {
+		if errors.Is(err, ErrAlreadyClosed) {
+			return model.PeriodClose{}, eError.NewServiceError(
+				err, fmt.Sprintf("%s is already closed", day.Format(time.DateOnly)), "PERIOD_ALREADY_CLOSED", http.StatusConflict)
+		}
+		s.logger.Error("period close failed", "date", day.Format(time.DateOnly), "error", err)
+		return model.PeriodClose{}, err
+	}
+
+	s.logger.Info("business date closed", "date", closed.Date, "accounts", closed.Accounts)
+	return closed, nil
+}
+
+func (s *service) Status(ctx context.Context, limit int) (Status, error) {
+	closedThrough, err := s.store.ClosedThrough(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+
+	closes, err := s.store.Closes(ctx, limit)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Closes: closes}
+	if !closedThrough.IsZero() {
+		status.ClosedThrough = closedThrough.Format(time.DateOnly)
+	}
+	return status, nil
+}
+
+func (s *service) Balances(ctx context.Context, date time.Time) ([]model.ClosingBalance, error) {
+	balances, err := s.store.Balances(ctx, truncateDay(date))
+	if err != nil {
+		if errors.Is(err, ErrCloseNotFound) {
+			return nil, eError.NewServiceError(err, "no closing balances for this date", "CLOSE_NOT_FOUND", http.StatusNotFound)
+		}
+		return nil, err
+	}
+	return balances, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,161 @@
+package period
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrAlreadyClosed = errors.New("business date already closed")
+	ErrDateNotEnded  = errors.New("business date has not ended")
+	ErrCloseNotFound = errors.New("business date close not found")
+)
+
+type Store interface {
+	Close(ctx context.Context, date time.Time) (model.PeriodClose, error)
+	ClosedThrough(ctx context.Context) (time.Time, error)
+	Closes(ctx context.Context, limit int) ([]model.PeriodClose, error)
+	Balances(ctx context.Context, date time.Time) ([]model.ClosingBalance, error)
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+// closingBalancesSQL snapshots every account's balance at the end of $1: its current balance less
+// the entries booked with a later value date.
+const closingBalancesSQL = `INSERT INTO period_closing_balances (close_date, account_id, currency, balance)
+	SELECT $1, a.id, a.currency, a.balance - COALESCE((
+		SELECT SUM(` + transaction.SignedAmountSQL + `) FROM transactions t WHERE t.account_id = a.id AND t.value_date > $1
+	), 0)
+	FROM accounts a`
+
+// Close closes the business date and every date before it, and snapshots the closing balances.
+// Locking the ledger period waits for the transactions posting entries, which share-lock it in
+// transaction.InsertEntry, and holds back new ones until the close commits, so no entry can land
+// on or before date once it is closed.
+func (s *store) Close(ctx context.Context, date time.Time) (model.PeriodClose, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return model.PeriodClose{}, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	var closedThrough sql.NullTime
+	err = tx.QueryRowContext(ctx, `SELECT closed_through FROM ledger_period FOR UPDATE`).Scan(&closedThrough)
+	if err != nil {
+		return model.PeriodClose{}, errors.Wrap(err, "failed to lock ledger period")
+	}
+	if closedThrough.Valid && !date.After(closedThrough.Time) {
+		return model.PeriodClose{}, errors.Wrapf(ErrAlreadyClosed, "closed through %s", closedThrough.Time.Format(time.DateOnly))
+	}
+
+	closed := model.PeriodClose{Date: date.Format(time.DateOnly)}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO period_closes (close_date) VALUES ($1) RETURNING closed_at`, closed.Date,
+	).Scan(&closed.ClosedAt)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return model.PeriodClose{}, ErrAlreadyClosed
+		}
+		return model.PeriodClose{}, errors.Wrap(err, "failed to record close")
+	}
+
+	result, err := tx.ExecContext(ctx, closingBalancesSQL, closed.Date)
+	if err != nil {
+		return model.PeriodClose{}, errors.Wrap(err, "failed to snapshot closing balances")
+	}
+	accounts, err := result.RowsAffected()
+	if err != nil {
+		return model.PeriodClose{}, errors.Wrap(err, "failed to count closing balances")
+	}
+	closed.Accounts = int(accounts)
+
+	if _, err := tx.ExecContext(ctx, `UPDATE ledger_period SET closed_through = $1`, closed.Date); err != nil {
+		return model.PeriodClose{}, errors.Wrap(err, "failed to advance ledger period")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.PeriodClose{}, errors.Wrap(err, "close commit failed")
+	}
+	return closed, nil
+}
+
+// ClosedThrough returns the last closed business date, the zero time if no date was ever closed.
+func (s *store) ClosedThrough(ctx context.Context) (time.Time, error) {
+	var closedThrough sql.NullTime
+	err := s.db.DB.QueryRowContext(ctx, `SELECT closed_through FROM ledger_period`).Scan(&closedThrough)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, errors.Wrap(err, "failed to get closed period")
+	}
+	return closedThrough.Time, nil
+}
+
+// Closes lists the most recent closes, latest first.
+func (s *store) Closes(ctx context.Context, limit int) ([]model.PeriodClose, error) {
+	rows, err := s.db.DB.QueryContext(ctx,
+		`SELECT to_char(c.close_date, 'YYYY-MM-DD'), c.closed_at,
+			(SELECT COUNT(*) FROM period_closing_balances b WHERE b.close_date = c.close_date)
+		FROM period_closes c ORDER BY c.close_date DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list closes")
+	}
+	defer rows.Close()
+
+	closes := []model.PeriodClose{}
+	for rows.Next() {
+		var c model.PeriodClose
+		if err := rows.Scan(&c.Date, &c.ClosedAt, &c.Accounts); err != nil {
+			return nil, errors.Wrap(err, "failed to scan close")
+		}
+		closes = append(closes, c)
+	}
+	return closes, rows.Err()
+}
+
+// Balances returns the closing balances snapshotted when date was closed. Dates closed along with a
+// later one have no snapshot of their own and are reported as not found.
+func (s *store) Balances(ctx context.Context, date time.Time) ([]model.ClosingBalance, error) {
+	day := date.Format(time.DateOnly)
+
+	var exists bool
+	err := s.db.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM period_closes WHERE close_date = $1)`, day).Scan(&exists)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get close")
+	}
+	if !exists {
+		return nil, ErrCloseNotFound
+	}
+
+	rows, err := s.db.DB.QueryContext(ctx,
+		`SELECT account_id, currency, balance FROM period_closing_balances WHERE close_date = $1 ORDER BY currency, account_id`, day)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list closing balances")
+	}
+	defer rows.Close()
+
+	balances := []model.ClosingBalance{}
+	for rows.Next() {
+		b := model.ClosingBalance{Date: day}
+		if err := rows.Scan(&b.AccountID, &b.Currency, &b.Balance); err != nil {
+			return nil, errors.Wrap(err, "failed to scan closing balance")
+		}
+		balances = append(balances, b)
+	}
+	return balances, rows.Err()
+}
@@ -0,0 +1,101 @@
+package period_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/period"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Close(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := period.NewStore(&db.DB{DB: sqlDB})
+	closedAt := time.Date(2026, 6, 1, 0, 5, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectQuery(`INSERT INTO period_closes \(close_date\) VALUES \(\$1\) RETURNING closed_at`).
+		WithArgs("2026-05-31").
+		WillReturnRows(sqlmock.NewRows([]string{"closed_at"}).AddRow(closedAt))
+	// Entries booked after the closed date are taken back out of the current balance
+	mock.ExpectExec(`INSERT INTO period_closing_balances .* a.balance - COALESCE\(\(\s*SELECT SUM\(.*\) FROM transactions t WHERE t.account_id = a.id AND t.value_date > \$1`).
+		WithArgs("2026-05-31").
+		WillReturnResult(sqlmock.NewResult(0, 42))
+	mock.ExpectExec(`UPDATE ledger_period SET closed_through = \$1`).
+		WithArgs("2026-05-31").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	closed, err := store.Close(context.Background(), time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-05-31", closed.Date)
+	assert.Equal(t, 42, closed.Accounts)
+	assert.Equal(t, closedAt, closed.ClosedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Close_AlreadyClosed(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := period.NewStore(&db.DB{DB: sqlDB})
+
+	// Closing the 31st closed every earlier date along with it
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectRollback()
+
+	_, err = store.Close(context.Background(), time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, period.ErrAlreadyClosed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Balances(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := period.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM period_closes WHERE close_date = \$1\)`).
+		WithArgs("2026-05-31").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`SELECT account_id, currency, balance FROM period_closing_balances WHERE close_date = \$1`).
+		WithArgs("2026-05-31").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "balance"}).
+			AddRow("acc1", "EUR", "1250.50").
+			AddRow("acc2", "USD", "0"))
+
+	balances, err := store.Balances(context.Background(), time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, balances, 2)
+	assert.Equal(t, "2026-05-31", balances[0].Date)
+	assert.Equal(t, "1250.5", balances[0].Balance.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Balances_NotFound(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := period.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("2026-05-30").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	_, err = store.Balances(context.Background(), time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, period.ErrCloseNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
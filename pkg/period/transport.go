@@ -0,0 +1,43 @@
+package period
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	closeHandler := kithttp.NewServer(
+		makeCloseEndpoint(ms),
+		decodeCloseRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	statusHandler := kithttp.NewServer(
+		makeStatusEndpoint(ms),
+		decodeStatusRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	balancesHandler := kithttp.NewServer(
+		makeBalancesEndpoint(ms),
+		decodeBalancesRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("POST", "/periods/close", closeHandler)
+	r.Method("GET", "/periods", statusHandler)
+	r.Method("GET", "/periods/{date}/balances", balancesHandler)
+
+	return http.Endpoint{Pattern: "/periods*", Handler: r}
+}
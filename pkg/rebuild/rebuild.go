@@ -0,0 +1,236 @@
+// Package rebuild recomputes account balances from the Postgres ledger.
+//
+// An account's balance is replayed from its completed entries in sequence order: the balance
+// before the first entry (zero unless the account predates opening balance entries) plus every
+// entry's movement. An account without entries has nothing to replay and is left alone.
+//
+// In dry-run mode the replayed balances are only compared with accounts.balance. In apply mode
+// each account is rebuilt under its row lock, which also holds off new entries, and every
+// correction is journalled in balance_corrections with the balance it replaced and the reason given.
+package rebuild
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"log/slog"
+)
+
+var ErrAccountNotFound = errors.New("account not found")
+
+// Options selects what a run rebuilds.
+type Options struct {
+	AccountID string // Empty rebuilds every account
+	Apply     bool   // Correct balances instead of only reporting them
+	Reason    string // Journalled with each correction
+}
+
+// Diff is an account whose stored balance differs from its replayed balance.
+type Diff struct {
+	AccountID string
+	Currency  string
+	Stored    decimal.Decimal
+	Replayed  decimal.Decimal
+	Entries   int   // Entries replayed
+	Through   int64 // Sequence of the last entry replayed
+	Corrected bool
+}
+
+// Adjustment is the change that brings the stored balance to the replayed one.
+func (d Diff) Adjustment() decimal.Decimal {
+	return d.Replayed.Sub(d.Stored)
+}
+
+// Summary counts what a run checked and changed.
+type Summary struct {
+	Accounts  int
+	Entries   int
+	Diffs     int
+	Corrected int
+}
+
+type Rebuilder struct {
+	db        *db.DB
+	batchSize int
+}
+
+func NewRebuilder(db *db.DB, batchSize int) *Rebuilder {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &Rebuilder{db: db, batchSize: batchSize}
+}
+
+// Run rebuilds the selected accounts, calling report for each account whose balance differs.
+func (r *Rebuilder) Run(ctx context.Context, opts Options, report func(Diff)) (Summary, error) {
+	if opts.Apply && opts.Reason == "" {
+		return Summary{}, errors.New("a reason is required to apply corrections")
+	}
+
+	var summary Summary
+	rebuild := func(accountID string) error {
+		diff, err := r.rebuildAccount(ctx, accountID, opts)
+		if err != nil {
+			return errors.Wrapf(err, "account %s", accountID)
+		}
+
+		summary.Accounts++
+		summary.Entries += diff.Entries
+		if !diff.Stored.Equal(diff.Replayed) {
+			summary.Diffs++
+			if diff.Corrected {
+				summary.Corrected++
+			}
+			report(diff)
+		}
+		return nil
+	}
+
+	if opts.AccountID != "" {
+		return summary, rebuild(opts.AccountID)
+	}
+
+	after := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		ids, err := r.accountIDs(ctx, after)
+		if err != nil {
+			return summary, err
+		}
+		for _, id := range ids {
+			if err := rebuild(id); err != nil {
+				return summary, err
+			}
+		}
+
+		if len(ids) < r.batchSize {
+			return summary, nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+func (r *Rebuilder) accountIDs(ctx context.Context, after string) ([]string, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT id::text FROM accounts WHERE id::text > $1 ORDER BY id::text LIMIT $2`, after, r.batchSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read accounts")
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "failed to scan account")
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// rebuildAccount replays one account in its own transaction: a read-only snapshot for a dry run,
+// or under the account's row lock when applying.
+func (r *Rebuilder) rebuildAccount(ctx context.Context, accountID string, opts Options) (Diff, error) {
+	var txOpts *sql.TxOptions
+	if !opts.Apply {
+		txOpts = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+
+	tx, err := r.db.DB.BeginTx(ctx, txOpts)
+	if err != nil {
+		return Diff{}, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	lock := ""
+	if opts.Apply {
+		lock = " FOR UPDATE"
+	}
+
+	diff := Diff{AccountID: accountID}
+	err = tx.QueryRowContext(ctx, `SELECT currency, balance FROM accounts WHERE id = $1`+lock, accountID).
+		Scan(&diff.Currency, &diff.Stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Diff{}, ErrAccountNotFound
+	}
+	if err != nil {
+		return Diff{}, errors.Wrap(err, "failed to read account")
+	}
+
+	if err := replay(ctx, tx, &diff); err != nil {
+		return Diff{}, err
+	}
+
+	if !opts.Apply || diff.Stored.Equal(diff.Replayed) {
+		return diff, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, diff.Replayed, accountID)
+	if err != nil {
+		return Diff{}, errors.Wrap(err, "failed to correct balance")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO balance_corrections (id, account_id, previous_balance, corrected_balance, adjustment, through_sequence, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		model.NewUUID(), accountID, diff.Stored, diff.Replayed, diff.Adjustment(), diff.Through, opts.Reason)
+	if err != nil {
+		return Diff{}, errors.Wrap(err, "failed to journal balance correction")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Diff{}, errors.Wrap(err, "failed to commit balance correction")
+	}
+	diff.Corrected = true
+	return diff, nil
+}
+
+// replay folds the account's completed entries in sequence order into diff.Replayed.
+func replay(ctx context.Context, tx *sql.Tx, diff *Diff) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT sequence, `+transaction.SignedAmountSQL+`, balance_after
+		FROM transactions
+		WHERE account_id = $1 AND status = 'completed'
+		ORDER BY sequence`,
+		diff.AccountID,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to read transactions")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movement, balanceAfter decimal.Decimal
+		if err := rows.Scan(&diff.Through, &movement, &balanceAfter); err != nil {
+			return errors.Wrap(err, "failed to scan transaction")
+		}
+
+		// Accounts from before opening balance entries start from the balance the first entry found
+		if diff.Entries == 0 {
+			diff.Replayed = balanceAfter.Sub(movement)
+		}
+		diff.Replayed = diff.Replayed.Add(movement)
+		diff.Entries++
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to read transactions")
+	}
+
+	// Without entries there is nothing to replay; the stored balance is the initial balance
+	if diff.Entries == 0 {
+		diff.Replayed = diff.Stored
+	}
+	return nil
+}
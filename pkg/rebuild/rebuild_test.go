@@ -0,0 +1,92 @@
+package rebuild_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/rebuild"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var entryColumns = []string{"sequence", "movement", "balance_after"}
+
+func TestRebuilder_DryRun(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	mock.ExpectQuery(`SELECT id::text FROM accounts WHERE id::text > \$1`).
+		WithArgs("", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("acc1").AddRow("acc2"))
+
+	// acc1 is consistent
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currency, balance FROM accounts WHERE id = \$1$`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance"}).AddRow("USD", "70"))
+	mock.ExpectQuery(`SELECT sequence, .* FROM transactions`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows(entryColumns).AddRow(1, "100", "100").AddRow(2, "-30", "70"))
+	mock.ExpectRollback()
+
+	// acc2 predates opening balance entries and its stored balance drifted
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currency, balance FROM accounts WHERE id = \$1$`).
+		WithArgs("acc2").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance"}).AddRow("EUR", "999"))
+	mock.ExpectQuery(`SELECT sequence, .* FROM transactions`).
+		WithArgs("acc2").
+		WillReturnRows(sqlmock.NewRows(entryColumns).AddRow(1, "20", "70").AddRow(2, "-5", "65"))
+	mock.ExpectRollback()
+
+	var diffs []rebuild.Diff
+	summary, err := rebuild.NewRebuilder(&db.DB{DB: sqlDB}, 10).Run(context.Background(), rebuild.Options{}, func(d rebuild.Diff) {
+		diffs = append(diffs, d)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, rebuild.Summary{Accounts: 2, Entries: 4, Diffs: 1}, summary)
+	assert.Len(t, diffs, 1)
+	assert.Equal(t, "acc2", diffs[0].AccountID)
+	assert.True(t, decimal.NewFromInt(65).Equal(diffs[0].Replayed))
+	assert.True(t, decimal.NewFromInt(-934).Equal(diffs[0].Adjustment()))
+	assert.False(t, diffs[0].Corrected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRebuilder_Apply(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currency, balance FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance"}).AddRow("USD", "50"))
+	mock.ExpectQuery(`SELECT sequence, .* FROM transactions`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows(entryColumns).AddRow(1, "100", "100").AddRow(2, "-30", "70"))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs("70", "acc1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO balance_corrections`).
+		WithArgs(sqlmock.AnyArg(), "acc1", "50", "70", "20", int64(2), "INC-42 manual balance edit").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var diffs []rebuild.Diff
+	summary, err := rebuild.NewRebuilder(&db.DB{DB: sqlDB}, 10).Run(context.Background(),
+		rebuild.Options{AccountID: "acc1", Apply: true, Reason: "INC-42 manual balance edit"},
+		func(d rebuild.Diff) { diffs = append(diffs, d) })
+	assert.NoError(t, err)
+	assert.Equal(t, rebuild.Summary{Accounts: 1, Entries: 2, Diffs: 1, Corrected: 1}, summary)
+	assert.True(t, diffs[0].Corrected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRebuilder_ApplyRequiresReason(t *testing.T) {
+	_, err := rebuild.NewRebuilder(&db.DB{}, 10).Run(context.Background(), rebuild.Options{Apply: true}, func(rebuild.Diff) {})
+	assert.Error(t, err)
+}
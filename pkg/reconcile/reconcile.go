@@ -0,0 +1,268 @@
+// Package reconcile compares the completed entries of the Postgres ledger with the audit records
+// in MongoDB and backfills audit records that were never saved.
+//
+// The processor saves the audit record after the ledger commit and only logs a failed save, so
+// the audit store can lag behind the ledger. Each completed entry that is not posted on behalf of
+// another transaction, other than interest postings, must have a completed audit record with the
+// same id, account, type, amount, currency and reference. Completed audit records without a ledger
+// entry are reported too.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindMissing  Kind = "missing"  // ledger entry without a completed audit record
+	KindMismatch Kind = "mismatch" // audit record differs from the ledger entry
+	KindOrphan   Kind = "orphan"   // completed audit record without a ledger entry
+)
+
+// Finding is one difference between the ledger and the audit store.
+type Finding struct {
+	Kind          Kind
+	TransactionID string
+	AccountID     string
+	Detail        string
+	Backfilled    bool
+}
+
+func (f Finding) String() string {
+	s := fmt.Sprintf("%s transaction=%s account=%s", f.Kind, f.TransactionID, f.AccountID)
+	if f.Detail != "" {
+		s += " " + f.Detail
+	}
+	if f.Backfilled {
+		s += " (backfilled)"
+	}
+	return s
+}
+
+// Window selects the transactions created in [From, To).
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+// Summary counts what a run checked and found.
+type Summary struct {
+	Entries    int // Ledger entries checked
+	Records    int // Completed audit records checked
+	Missing    int
+	Mismatched int
+	Orphaned   int
+	Backfilled int
+}
+
+// Findings is the number of differences found.
+func (s Summary) Findings() int {
+	return s.Missing + s.Mismatched + s.Orphaned
+}
+
+type Reconciler struct {
+	store     Store
+	records   *mongo.Collection
+	chain     *audit.Chain
+	batchSize int
+}
+
+// NewReconciler compares the ledger with the audit records in records, reading batchSize
+// transactions at a time. Missing records are backfilled through chain.
+func NewReconciler(store Store, records *mongo.Collection, chain *audit.Chain, batchSize int) *Reconciler {
+	return &Reconciler{store: store, records: records, chain: chain, batchSize: batchSize}
+}
+
+// Run reconciles the transactions created in the window, reporting each finding as it is found.
+// With backfill, missing audit records are created from the ledger entries.
+func (r *Reconciler) Run(ctx context.Context, window Window, backfill bool, report func(Finding)) (Summary, error) {
+	var summary Summary
+
+	var after Position
+	for {
+		entries, err := r.store.CompletedEntries(ctx, window.From, window.To, after, r.batchSize)
+		if err != nil {
+			return summary, err
+		}
+		if len(entries) == 0 {
+			break
+		}
+		summary.Entries += len(entries)
+
+		if err := r.checkEntries(ctx, entries, backfill, &summary, report); err != nil {
+			return summary, err
+		}
+
+		last := entries[len(entries)-1]
+		after = Position{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	if err := r.checkOrphans(ctx, window, &summary, report); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// checkEntries compares a page of ledger entries with their completed audit records.
+func (r *Reconciler) checkEntries(ctx context.Context, entries []model.Transaction, backfill bool, summary *Summary, report func(Finding)) error {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+
+	cursor, err := r.records.Find(ctx, bson.M{"id": bson.M{"$in": ids}, "status": transaction.TransactionStatusCompleted})
+	if err != nil {
+		return errors.Wrap(err, "failed to read audit records")
+	}
+	defer cursor.Close(ctx)
+
+	var records []model.Transaction
+	if err := cursor.All(ctx, &records); err != nil {
+		return errors.Wrap(err, "failed to decode audit records")
+	}
+
+	audited := make(map[string]model.Transaction, len(records))
+	for _, rec := range records {
+		audited[rec.ID] = rec
+	}
+
+	for _, entry := range entries {
+		rec, ok := audited[entry.ID]
+		if ok {
+			if diff := Compare(entry, rec); diff != "" {
+				summary.Mismatched++
+				report(Finding{Kind: KindMismatch, TransactionID: entry.ID, AccountID: entry.AccountID, Detail: diff})
+			}
+			continue
+		}
+
+		finding := Finding{Kind: KindMissing, TransactionID: entry.ID, AccountID: entry.AccountID}
+		summary.Missing++
+		if backfill {
+			if err := r.chain.Append(ctx, entry); err != nil {
+				return errors.Wrapf(err, "failed to backfill audit record %s", entry.ID)
+			}
+			finding.Backfilled = true
+			summary.Backfilled++
+		}
+		report(finding)
+	}
+	return nil
+}
+
+// checkOrphans looks up the ledger entries of the completed audit records created in the window.
+func (r *Reconciler) checkOrphans(ctx context.Context, window Window, summary *Summary, report func(Finding)) error {
+	filter := bson.M{
+		"status":    transaction.TransactionStatusCompleted,
+		"createdat": bson.M{"$gte": window.From, "$lt": window.To},
+	}
+	opts := options.Find().SetProjection(bson.M{"id": 1, "accountid": 1}).SetBatchSize(int32(r.batchSize))
+
+	cursor, err := r.records.Find(ctx, filter, opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to read audit records")
+	}
+	defer cursor.Close(ctx)
+
+	batch := make([]model.Transaction, 0, r.batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		var ids []string
+		for _, rec := range batch {
+			if model.IsValidUUID(rec.ID) {
+				ids = append(ids, rec.ID)
+			}
+		}
+		existing, err := r.store.ExistingIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, rec := range batch {
+			if !existing[rec.ID] {
+				summary.Orphaned++
+				report(Finding{Kind: KindOrphan, TransactionID: rec.ID, AccountID: rec.AccountID})
+			}
+		}
+		summary.Records += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var rec model.Transaction
+		if err := cursor.Decode(&rec); err != nil {
+			return errors.Wrap(err, "failed to decode audit record")
+		}
+		batch = append(batch, rec)
+
+		if len(batch) == r.batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return errors.Wrap(err, "failed to read audit records")
+	}
+	return flush()
+}
+
+// Compare describes how an audit record differs from its ledger entry, or returns "" when they agree.
+func Compare(entry, record model.Transaction) string {
+	var diff string
+	field := func(name string, ledger, stored interface{}) {
+		if diff != "" {
+			diff += " "
+		}
+		diff += fmt.Sprintf("%s: ledger=%v audit=%v", name, ledger, stored)
+	}
+
+	if entry.AccountID != record.AccountID {
+		field("account_id", entry.AccountID, record.AccountID)
+	}
+	if entry.Type != record.Type {
+		field("type", entry.Type, record.Type)
+	}
+	if !entry.Amount.Equal(record.Amount.Decimal) {
+		field("amount", entry.Amount, record.Amount)
+	}
+	if entry.Currency != record.Currency {
+		field("currency", entry.Currency, record.Currency)
+	}
+	if entry.ReferenceID != record.ReferenceID {
+		field("reference_id", entry.ReferenceID, record.ReferenceID)
+	}
+	return diff
+}
+
+// EnsureIndexes creates the audit collection indexes the reconciler looks records up through.
+func EnsureIndexes(ctx context.Context, records *mongo.Collection) error {
+	_, err := records.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("transaction_status"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "createdat", Value: 1}},
+			Options: options.Index().SetName("status_created"),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create reconciliation indexes")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package reconcile_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/audit"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/reconcile"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// pages serves the ledger entries one page per call.
+type pages struct {
+	entries  [][]model.Transaction
+	existing map[string]bool
+}
+
+func (p *pages) CompletedEntries(context.Context, time.Time, time.Time, reconcile.Position, int) ([]model.Transaction, error) {
+	if len(p.entries) == 0 {
+		return nil, nil
+	}
+	page := p.entries[0]
+	p.entries = p.entries[1:]
+	return page, nil
+}
+
+func (p *pages) ExistingIDs(context.Context, []string) (map[string]bool, error) {
+	return p.existing, nil
+}
+
+func document(t *testing.T, txn model.Transaction) bson.D {
+	data, err := bson.Marshal(txn)
+	require.NoError(t, err)
+	var doc bson.D
+	require.NoError(t, bson.Unmarshal(data, &doc))
+	return doc
+}
+
+func TestCompare(t *testing.T) {
+	entry := model.Transaction{ID: "txn1", AccountID: "acc1", Type: "deposit", Currency: "USD", ReferenceID: "ref1",
+		Amount: model.Decimal{Decimal: decimal.RequireFromString("10.50")}}
+
+	// Decimal representation does not matter
+	record := entry
+	record.Amount = model.Decimal{Decimal: decimal.RequireFromString("10.5")}
+	assert.Empty(t, reconcile.Compare(entry, record))
+
+	record.Amount = model.Decimal{Decimal: decimal.NewFromInt(105)}
+	record.Currency = "EUR"
+	assert.Equal(t, "amount: ledger=10.5 audit=105 currency: ledger=USD audit=EUR", reconcile.Compare(entry, record))
+}
+
+func TestReconciler_Run_FXConversion(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("debit is audited", func(mt *mtest.T) {
+		created := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+		id := model.DeriveUUID("fx", "quote1", "debit")
+		debit := model.Transaction{ID: id, AccountID: "usd", Type: transaction.TransactionTypeWithdrawal,
+			Amount: model.Decimal{Decimal: decimal.NewFromInt(100)}, Currency: "USD", ReferenceID: model.DeriveUUID(id, "reference"),
+			Status: transaction.TransactionStatusCompleted, CreatedAt: created}
+
+		// The debit has no parent, so the ledger returns it like a processed transaction
+		ledger := &pages{entries: [][]model.Transaction{{debit}}, existing: map[string]bool{id: true}}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "ledger.audit", mtest.FirstBatch, document(t, debit)),
+			mtest.CreateCursorResponse(0, "ledger.audit", mtest.FirstBatch, document(t, debit)),
+		)
+
+		var findings []reconcile.Finding
+		reconciler := reconcile.NewReconciler(ledger, mt.Coll, audit.NewChain(mt.Coll), 100)
+		summary, err := reconciler.Run(context.Background(), reconcile.Window{From: created.Truncate(24 * time.Hour), To: created.Add(time.Hour)}, false,
+			func(f reconcile.Finding) { findings = append(findings, f) })
+
+		assert.NoError(t, err)
+		assert.Empty(t, findings)
+		assert.Equal(t, 1, summary.Entries)
+		assert.Equal(t, 1, summary.Records)
+	})
+}
@@ -0,0 +1,117 @@
+package reconcile
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// metrics are published under "reconcile" on /debug/vars. Counters add up over all runs of the
+// process; last_* describe the latest run.
+var metrics = expvar.NewMap("reconcile")
+
+// Record adds a finished run to the published metrics.
+func Record(summary Summary, started time.Time, err error) {
+	metrics.Add("runs", 1)
+	if err != nil {
+		metrics.Add("failures", 1)
+	}
+	metrics.Add("entries", int64(summary.Entries))
+	metrics.Add("records", int64(summary.Records))
+	metrics.Add("missing", int64(summary.Missing))
+	metrics.Add("mismatched", int64(summary.Mismatched))
+	metrics.Add("orphaned", int64(summary.Orphaned))
+	metrics.Add("backfilled", int64(summary.Backfilled))
+
+	last := func(name string, value int64) {
+		v := new(expvar.Int)
+		v.Set(value)
+		metrics.Set(name, v)
+	}
+	last("last_run_unix", started.Unix())
+	last("last_run_ms", time.Since(started).Milliseconds())
+	last("last_findings", int64(summary.Findings()))
+}
+
+// Runner periodically reconciles the transactions created in the trailing window. It implements
+// di.StartCloser so the ledger service starts and stops it with the HTTP server. The window ends
+// lag before now, leaving the processor time to save the audit record of a fresh entry.
+type Runner struct {
+	reconciler *Reconciler
+	logger     *logging.Logger
+	interval   time.Duration
+	window     time.Duration
+	lag        time.Duration
+	backfill   bool
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewRunner(reconciler *Reconciler, logger *logging.Logger, interval, window time.Duration, backfill bool) *Runner {
+	return &Runner{reconciler: reconciler, logger: logger, interval: interval, window: window, lag: time.Minute, backfill: backfill}
+}
+
+func (r *Runner) Start() error {
+	if r.interval <= 0 {
+		r.logger.Info("reconciliation runner disabled")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			r.run(ctx)
+		}
+	}()
+
+	r.logger.Info("reconciliation runner started", "interval", r.interval, "window", r.window, "backfill", r.backfill)
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context) {
+	started := time.Now()
+	to := started.UTC().Add(-r.lag)
+	window := Window{From: to.Add(-r.window), To: to}
+
+	summary, err := r.reconciler.Run(ctx, window, r.backfill, func(f Finding) {
+		r.logger.Warn("reconciliation finding", "kind", f.Kind, "transaction_id", f.TransactionID,
+			"account_id", f.AccountID, "detail", f.Detail, "backfilled", f.Backfilled)
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	Record(summary, started, err)
+
+	if err != nil {
+		r.logger.Error("reconciliation failed", "error", err)
+		return
+	}
+	r.logger.Info("reconciliation completed", "entries", summary.Entries, "records", summary.Records,
+		"missing", summary.Missing, "mismatched", summary.Mismatched, "orphaned", summary.Orphaned,
+		"backfilled", summary.Backfilled)
+}
+
+func (r *Runner) Close() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	r.logger.Info("reconciliation runner stopped")
+}
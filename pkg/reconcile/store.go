@@ -0,0 +1,77 @@
+package reconcile
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type Store interface {
+	CompletedEntries(ctx context.Context, from, to time.Time, after Position, limit int) ([]model.Transaction, error)
+	ExistingIDs(ctx context.Context, ids []string) (map[string]bool, error)
+}
+
+// Position is the last entry of a page, ordered by creation time and id.
+type Position struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+// CompletedEntries reads a page of the completed entries created in [from, to) that have their own
+// audit record: those the processor posts, opening balances and FX conversion debits. Entries posted
+// on behalf of another transaction (fees, transfer credits, funding and FX legs) are covered by their
+// parent's audit record, and interest postings are never audited, so both are left out.
+func (s *store) CompletedEntries(ctx context.Context, from, to time.Time, after Position, limit int) ([]model.Transaction, error) {
+	rows, err := s.db.DB.QueryContext(ctx,
+		`SELECT `+transaction.EntryColumns+` FROM transactions
+		WHERE status = $1 AND parent_id IS NULL AND type <> 'interest' AND created_at >= $2 AND created_at < $3
+			AND (created_at, id) > ($4, COALESCE(NULLIF($5, '')::uuid, '00000000-0000-0000-0000-000000000000'))
+		ORDER BY created_at, id
+		LIMIT $6`,
+		transaction.TransactionStatusCompleted, from, to, after.CreatedAt, after.ID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read ledger entries")
+	}
+	defer rows.Close()
+
+	var entries []model.Transaction
+	for rows.Next() {
+		txn, err := transaction.ScanEntry(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan ledger entry")
+		}
+		entries = append(entries, txn)
+	}
+	return entries, rows.Err()
+}
+
+// ExistingIDs reports which of the transaction ids have a ledger entry.
+func (s *store) ExistingIDs(ctx context.Context, ids []string) (map[string]bool, error) {
+	rows, err := s.db.DB.QueryContext(ctx, `SELECT id::text FROM transactions WHERE id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to look up ledger entries")
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool, len(ids))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "failed to scan ledger entry id")
+		}
+		existing[id] = true
+	}
+	return existing, rows.Err()
+}
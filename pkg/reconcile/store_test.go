@@ -0,0 +1,57 @@
+package reconcile_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/reconcile"
+	"github.com/stretchr/testify/assert"
+)
+
+var entryColumns = []string{"id", "account_id", "type", "amount", "currency", "reference_id", "status", "parent_id",
+	"sequence", "balance_after", "original_amount", "original_currency", "fx_rate",
+	"description", "counterparty_name", "counterparty_account", "metadata", "created_at", "value_date"}
+
+func TestStore_CompletedEntries(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := reconcile.NewStore(&db.DB{DB: sqlDB})
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	after := reconcile.Position{CreatedAt: from.Add(time.Hour), ID: "txn1"}
+
+	// Interest postings have no audit record and are left out like legs posted on behalf of a parent
+	mock.ExpectQuery(`SELECT .* FROM transactions\s+WHERE status = \$1 AND parent_id IS NULL AND type <> 'interest' AND created_at >= \$2 AND created_at < \$3\s+AND \(created_at, id\) > .*ORDER BY created_at, id\s+LIMIT \$6`).
+		WithArgs("completed", from, to, after.CreatedAt, "txn1", 100).
+		WillReturnRows(sqlmock.NewRows(entryColumns).
+			AddRow("txn2", "acc1", "deposit", "25", "USD", "ref2", "completed", "", 4, "125", nil, nil, nil, "", "", "", []byte(`{}`), from.Add(2*time.Hour), from.Format(time.DateOnly)))
+
+	entries, err := store.CompletedEntries(context.Background(), from, to, after, 100)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "txn2", entries[0].ID)
+	assert.Equal(t, int64(4), entries[0].Sequence)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_ExistingIDs(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := reconcile.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectQuery(`SELECT id::text FROM transactions WHERE id = ANY\(\$1::uuid\[\]\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("txn1"))
+
+	existing, err := store.ExistingIDs(context.Background(), []string{"txn1", "txn2"})
+	assert.NoError(t, err)
+	assert.True(t, existing["txn1"])
+	assert.False(t, existing["txn2"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
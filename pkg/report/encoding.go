@@ -0,0 +1,143 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultVolumeDays = 30  // Days covered by the daily volume when from is not given
+	MaxVolumeDays     = 366 // Most days a daily volume may cover
+)
+
+type TrialBalanceRequest struct {
+	AsOf     *time.Time
+	Currency string
+	Format   string
+}
+
+type VolumeRequest struct {
+	From     time.Time
+	To       time.Time
+	Currency string
+	Format   string
+}
+
+// ReportResponse is a report to be rendered in Format, as Name.csv when that is CSV.
+type ReportResponse struct {
+	Report interface{ WriteCSV(w io.Writer) error }
+	Name   string
+	Format string
+}
+
+func decodeTrialBalanceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	req := TrialBalanceRequest{Currency: strings.ToUpper(query.Get("currency"))}
+
+	var err error
+	if req.Format, err = decodeFormat(r); err != nil {
+		return nil, err
+	}
+	if err := checkCurrency(req.Currency); err != nil {
+		return nil, err
+	}
+
+	if v := query.Get("as_of"); v != "" {
+		asOf, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, eError.NewServiceError(err, "as_of must be a date in YYYY-MM-DD format", "INVALID_AS_OF", http.StatusBadRequest)
+		}
+		req.AsOf = &asOf
+	}
+
+	return req, nil
+}
+
+// decodeVolumeRequest reads from and to (YYYY-MM-DD, inclusive), by default the last DefaultVolumeDays
+// days through today.
+func decodeVolumeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	req := VolumeRequest{To: today, Currency: strings.ToUpper(query.Get("currency"))}
+
+	var err error
+	if req.Format, err = decodeFormat(r); err != nil {
+		return nil, err
+	}
+	if err := checkCurrency(req.Currency); err != nil {
+		return nil, err
+	}
+
+	if v := query.Get("to"); v != "" {
+		if req.To, err = time.Parse(time.DateOnly, v); err != nil {
+			return nil, eError.NewServiceError(err, "to must be a date in YYYY-MM-DD format", "INVALID_DATE_RANGE", http.StatusBadRequest)
+		}
+	}
+	req.From = req.To.AddDate(0, 0, 1-DefaultVolumeDays)
+	if v := query.Get("from"); v != "" {
+		if req.From, err = time.Parse(time.DateOnly, v); err != nil {
+			return nil, eError.NewServiceError(err, "from must be a date in YYYY-MM-DD format", "INVALID_DATE_RANGE", http.StatusBadRequest)
+		}
+	}
+
+	if req.To.Before(req.From) {
+		return nil, eError.NewServiceError(
+			errors.New("to is before from"), "to must not be before from", "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+	if req.To.Sub(req.From) >= MaxVolumeDays*24*time.Hour {
+		return nil, eError.NewServiceError(
+			errors.New("date range too long"), fmt.Sprintf("from and to must not be more than %d days apart", MaxVolumeDays), "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+// decodeFormat reads the format parameter, falling back to CSV when the Accept header asks for it.
+func decodeFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	switch format {
+	case FormatJSON, FormatCSV:
+		return format, nil
+	case "":
+		for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+			if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == "text/csv" {
+				return FormatCSV, nil
+			}
+		}
+		return FormatJSON, nil
+	}
+	return "", eError.NewServiceError(
+		errors.Errorf("unsupported format %q", format), "format must be json or csv", "UNSUPPORTED_FORMAT", http.StatusBadRequest)
+}
+
+func checkCurrency(currency string) error {
+	if currency != "" && !model.IsKnownCurrency(currency) {
+		return eError.NewServiceError(
+			errors.Errorf("unknown currency %q", currency), "currency must be a supported ISO 4217 code", "MISSING_CURRENCY", http.StatusBadRequest)
+	}
+	return nil
+}
+
+func encodeReportResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp, ok := response.(ReportResponse)
+	if !ok {
+		return kithttp.EncodeJSONResponse(ctx, w, response)
+	}
+	if resp.Format != FormatCSV {
+		return kithttp.EncodeJSONResponse(ctx, w, resp.Report)
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": resp.Name + ".csv"}))
+	w.WriteHeader(http.StatusOK)
+	return resp.Report.WriteCSV(w)
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+func makeTrialBalanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(TrialBalanceRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		tb, err := s.TrialBalance(ctx, req.AsOf, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		name := "trial-balance"
+		if tb.AsOf != "" {
+			name += "-" + tb.AsOf
+		}
+		return ReportResponse{Report: tb, Name: name, Format: req.Format}, nil
+	}
+}
+
+func makeVolumeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(VolumeRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		dv, err := s.DailyVolume(ctx, req.From, req.To, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		name := "daily-volume-" + req.From.Format(time.DateOnly) + "-" + req.To.Format(time.DateOnly)
+		return ReportResponse{Report: dv, Name: name, Format: req.Format}, nil
+	}
+}
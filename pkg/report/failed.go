@@ -0,0 +1,65 @@
+package report
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// volumeKey identifies a row of the daily volume.
+type volumeKey struct {
+	Date     string `bson:"date"`
+	Currency string `bson:"currency"`
+	Type     string `bson:"type"`
+}
+
+// failedCounts counts the transactions submitted from one UTC day to another, inclusive, that
+// failed, by day, currency and type. Failed transactions never reach Postgres, so they are read
+// from the audit records; a transaction is counted once, and not at all if any of its records
+// completed, which leaves out redeliveries of completed transactions.
+func failedCounts(ctx context.Context, records *mongo.Collection, from, to time.Time, currency string) (map[volumeKey]int, error) {
+	match := bson.M{"createdat": bson.M{"$gte": from, "$lt": to.AddDate(0, 0, 1)}}
+	if currency != "" {
+		match["currency"] = currency
+	}
+
+	cursor, err := records.Aggregate(ctx, bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":       "$id",
+			"currency":  bson.M{"$first": "$currency"},
+			"type":      bson.M{"$first": "$type"},
+			"createdat": bson.M{"$min": "$createdat"},
+			"completed": bson.M{"$max": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", transaction.TransactionStatusCompleted}}, 1, 0}}},
+		}},
+		bson.M{"$match": bson.M{"completed": 0}},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{
+				"date":     bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$createdat"}},
+				"currency": "$currency",
+				"type":     "$type",
+			},
+			"count": bson.M{"$sum": 1},
+		}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count failed transactions")
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[volumeKey]int)
+	for cursor.Next(ctx) {
+		var row struct {
+			Key   volumeKey `bson:"_id"`
+			Count int       `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, errors.Wrap(err, "failed to decode failed transaction count")
+		}
+		counts[row.Key] = row.Count
+	}
+	return counts, cursor.Err()
+}
@@ -0,0 +1,103 @@
+// Package report summarises the ledger for finance: a trial balance of the account balances and
+// the entries behind them, and the daily volume booked per currency and transaction type.
+//
+// Amounts are summed as exact decimals in Postgres and rendered without rounding, in JSON as
+// strings and in CSV as plain numbers.
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// TrialBalanceRow totals the accounts of one currency, kind, type and status. Credits and Debits sum the
+// entries booked to them; Difference is Balance less their net, zero when the balances agree
+// with the ledger.
+type TrialBalanceRow struct {
+	Currency   string          `json:"currency"`
+	Kind       string          `json:"kind,omitempty"`   // Customer or system, empty on the per-currency totals
+	Type       string          `json:"type,omitempty"`   // Type in the chart of accounts, empty on the per-currency totals
+	Status     string          `json:"status,omitempty"` // Empty on the per-currency totals
+	Accounts   int             `json:"accounts"`
+	Credits    decimal.Decimal `json:"credits"` // Deposits and opening balances
+	Debits     decimal.Decimal `json:"debits"`  // Withdrawals, transfers and fees
+	Balance    decimal.Decimal `json:"balance"`
+	Difference decimal.Decimal `json:"difference"`
+}
+
+// TrialBalance is the ledger at the end of AsOf, or now if AsOf is empty.
+type TrialBalance struct {
+	AsOf        string            `json:"as_of,omitempty"` // Business date, YYYY-MM-DD
+	GeneratedAt time.Time         `json:"generated_at"`
+	Rows        []TrialBalanceRow `json:"rows"`
+	Totals      []TrialBalanceRow `json:"totals"` // One per currency, across kinds, types and statuses
+}
+
+// VolumeRow is the volume booked on one business date in one currency and transaction type, and
+// the number of transactions of that type submitted that day that failed.
+type VolumeRow struct {
+	Date     string          `json:"date"`
+	Currency string          `json:"currency"`
+	Type     string          `json:"type"`
+	Count    int             `json:"count"`
+	Amount   decimal.Decimal `json:"amount"`
+	Failed   int             `json:"failed"`
+}
+
+// DailyVolume covers the business dates From to To, inclusive.
+type DailyVolume struct {
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	Currency    string      `json:"currency,omitempty"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Rows        []VolumeRow `json:"rows"`
+}
+
+// WriteCSV renders the rows followed by the per-currency totals, which have an empty kind, type
+// and status.
+func (tb TrialBalance) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"currency", "kind", "type", "status", "accounts", "credits", "debits", "balance", "difference"}); err != nil {
+		return err
+	}
+
+	for _, rows := range [][]TrialBalanceRow{tb.Rows, tb.Totals} {
+		for _, r := range rows {
+			err := cw.Write([]string{r.Currency, r.Kind, r.Type, r.Status, strconv.Itoa(r.Accounts),
+				r.Credits.String(), r.Debits.String(), r.Balance.String(), r.Difference.String()})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteCSV renders one row per date, currency and type.
+func (dv DailyVolume) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"date", "currency", "type", "count", "amount", "failed"}); err != nil {
+		return err
+	}
+
+	for _, r := range dv.Rows {
+		err := cw.Write([]string{r.Date, r.Currency, r.Type, strconv.Itoa(r.Count), r.Amount.String(), strconv.Itoa(r.Failed)})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
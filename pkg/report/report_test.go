@@ -0,0 +1,55 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/report"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestTotals(t *testing.T) {
+	rows := []report.TrialBalanceRow{
+		{Currency: "USD", Status: "active", Accounts: 2, Credits: dec("100.10"), Debits: dec("0.10"), Balance: dec("100")},
+		{Currency: "EUR", Status: "active", Accounts: 1, Credits: dec("0.1"), Debits: dec("0"), Balance: dec("0.1")},
+		{Currency: "USD", Status: "closed", Accounts: 1, Credits: dec("5"), Debits: dec("5"), Balance: dec("0.01")},
+	}
+
+	totals := report.Totals(rows)
+	assert.Len(t, totals, 2)
+	assert.Equal(t, "EUR", totals[0].Currency)
+	assert.Equal(t, "USD", totals[1].Currency)
+	assert.Equal(t, 3, totals[1].Accounts)
+	assert.Equal(t, "105.1", totals[1].Credits.String())
+	assert.Equal(t, "100.01", totals[1].Balance.String())
+
+	// A balance the entries don't account for shows as a difference
+	assert.True(t, totals[0].Difference.IsZero())
+	assert.Equal(t, "0.01", totals[1].Difference.String())
+}
+
+func TestTrialBalance_WriteCSV(t *testing.T) {
+	row := report.TrialBalanceRow{Currency: "EUR", Kind: "customer", Type: "liability", Status: "active", Accounts: 1, Credits: dec("0.1"), Debits: dec("0"), Balance: dec("0.1")}
+	tb := report.TrialBalance{Rows: []report.TrialBalanceRow{row}, Totals: report.Totals([]report.TrialBalanceRow{row})}
+
+	var buf bytes.Buffer
+	assert.NoError(t, tb.WriteCSV(&buf))
+	assert.Equal(t, "currency,kind,type,status,accounts,credits,debits,balance,difference\n"+
+		"EUR,customer,liability,active,1,0.1,0,0.1,0\n"+
+		"EUR,,,,1,0.1,0,0.1,0\n", buf.String())
+}
+
+func TestDailyVolume_WriteCSV(t *testing.T) {
+	dv := report.DailyVolume{Rows: []report.VolumeRow{
+		{Date: "2026-06-01", Currency: "EUR", Type: "deposit", Count: 2, Amount: dec("1250.505"), Failed: 1},
+	}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, dv.WriteCSV(&buf))
+	assert.Equal(t, "date,currency,type,count,amount,failed\n2026-06-01,EUR,deposit,2,1250.505,1\n", buf.String())
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sort"
+	"time"
+)
+
+type Service interface {
+	TrialBalance(ctx context.Context, asOf *time.Time, currency string) (TrialBalance, error)
+	DailyVolume(ctx context.Context, from, to time.Time, currency string) (DailyVolume, error)
+}
+
+type service struct {
+	logger  *logging.Logger
+	store   Store
+	records *mongo.Collection
+}
+
+// NewService reads the ledger from database and failed transactions from the audit records.
+func NewService(logger *logging.Logger, database *db.DB, records *mongo.Collection) Service {
+	return &service{
+		logger:  logger,
+		store:   NewStore(database),
+		records: records,
+	}
+}
+
+// TrialBalance totals the ledger at the end of the business date asOf, or now if it is nil.
+func (s *service) TrialBalance(ctx context.Context, asOf *time.Time, currency string) (TrialBalance, error) {
+	rows, err := s.store.TrialBalance(ctx, asOf, currency)
+	if err != nil {
+		s.logger.Error("trial balance failed", "error", err)
+		return TrialBalance{}, err
+	}
+
+	tb := TrialBalance{GeneratedAt: time.Now().UTC(), Rows: rows, Totals: Totals(rows)}
+	if asOf != nil {
+		tb.AsOf = asOf.Format(time.DateOnly)
+	}
+	for i := range tb.Rows {
+		tb.Rows[i].Difference = difference(tb.Rows[i])
+	}
+	return tb, nil
+}
+
+// DailyVolume totals the volume booked on each business date from from to to, inclusive, with the
+// failed transactions submitted on each day.
+func (s *service) DailyVolume(ctx context.Context, from, to time.Time, currency string) (DailyVolume, error) {
+	rows, err := s.store.Volumes(ctx, from, to, currency)
+	if err != nil {
+		s.logger.Error("daily volume failed", "error", err)
+		return DailyVolume{}, err
+	}
+
+	failed, err := failedCounts(ctx, s.records, from, to, currency)
+	if err != nil {
+		s.logger.Error("failed transaction count failed", "error", err)
+		return DailyVolume{}, err
+	}
+
+	return DailyVolume{
+		From:        from.Format(time.DateOnly),
+		To:          to.Format(time.DateOnly),
+		Currency:    currency,
+		GeneratedAt: time.Now().UTC(),
+		Rows:        mergeFailed(rows, failed),
+	}, nil
+}
+
+// Totals sums trial balance rows per currency, in currency order.
+func Totals(rows []TrialBalanceRow) []TrialBalanceRow {
+	totals := []TrialBalanceRow{}
+	index := make(map[string]int)
+	for _, r := range rows {
+		i, ok := index[r.Currency]
+		if !ok {
+			i = len(totals)
+			index[r.Currency] = i
+			totals = append(totals, TrialBalanceRow{Currency: r.Currency})
+		}
+
+		t := &totals[i]
+		t.Accounts += r.Accounts
+		t.Credits = t.Credits.Add(r.Credits)
+		t.Debits = t.Debits.Add(r.Debits)
+		t.Balance = t.Balance.Add(r.Balance)
+	}
+
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+	for i := range totals {
+		totals[i].Difference = difference(totals[i])
+	}
+	return totals
+}
+
+func difference(r TrialBalanceRow) decimal.Decimal {
+	return r.Balance.Sub(r.Credits.Sub(r.Debits))
+}
+
+// mergeFailed adds the failed counts to the volume rows, with a row of no volume for a day,
+// currency and type that only has failures.
+func mergeFailed(rows []VolumeRow, failed map[volumeKey]int) []VolumeRow {
+	for i, r := range rows {
+		key := volumeKey{Date: r.Date, Currency: r.Currency, Type: r.Type}
+		rows[i].Failed = failed[key]
+		delete(failed, key)
+	}
+
+	for key, count := range failed {
+		rows = append(rows, VolumeRow{Date: key.Date, Currency: key.Currency, Type: key.Type, Failed: count})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Type < b.Type
+	})
+	return rows
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type Store interface {
+	TrialBalance(ctx context.Context, asOf *time.Time, currency string) ([]TrialBalanceRow, error)
+	Volumes(ctx context.Context, from, to time.Time, currency string) ([]VolumeRow, error)
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+// trialBalanceSQL totals accounts by currency, kind, type and status. With a date in $1 only accounts opened
+// by its end count, entries booked after it are left out and taken back out of the balances;
+// with NULL every account and entry counts. $2 restricts the currency when not empty. Credits and
+// debits split the entries by the sign of their movement.
+const trialBalanceSQL = `SELECT a.currency, a.kind, a.type, a.status, COUNT(*),
+		COALESCE(SUM(e.credits), 0), COALESCE(SUM(e.debits), 0), SUM(a.balance - COALESCE(e.later, 0))
+	FROM accounts a
+	LEFT JOIN (
+		SELECT account_id,
+			SUM(CASE WHEN $1::date IS NULL OR value_date <= $1::date THEN GREATEST(` + transaction.SignedAmountSQL + `, 0) ELSE 0 END) AS credits,
+			SUM(CASE WHEN $1::date IS NULL OR value_date <= $1::date THEN GREATEST(-(` + transaction.SignedAmountSQL + `), 0) ELSE 0 END) AS debits,
+			SUM(CASE WHEN value_date > $1::date THEN ` + transaction.SignedAmountSQL + ` ELSE 0 END) AS later
+		FROM transactions GROUP BY account_id
+	) e ON e.account_id = a.id
+	WHERE ($1::date IS NULL OR a.created_at < $1::date + 1) AND ($2 = '' OR a.currency = $2)
+	GROUP BY a.currency, a.kind, a.type, a.status
+	ORDER BY a.currency, a.kind, a.type, a.status`
+
+func (s *store) TrialBalance(ctx context.Context, asOf *time.Time, currency string) ([]TrialBalanceRow, error) {
+	var date interface{}
+	if asOf != nil {
+		date = asOf.Format(time.DateOnly)
+	}
+
+	rows, err := s.db.DB.QueryContext(ctx, trialBalanceSQL, date, currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to total account balances")
+	}
+	defer rows.Close()
+
+	result := []TrialBalanceRow{}
+	for rows.Next() {
+		var r TrialBalanceRow
+		if err := rows.Scan(&r.Currency, &r.Kind, &r.Type, &r.Status, &r.Accounts, &r.Credits, &r.Debits, &r.Balance); err != nil {
+			return nil, errors.Wrap(err, "failed to scan trial balance")
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// Volumes totals the entries booked from one business date to another, inclusive. Entries posted
+// on behalf of another transaction are left out, except fees, so a transfer counts once rather
+// than again as the deposit crediting its target.
+func (s *store) Volumes(ctx context.Context, from, to time.Time, currency string) ([]VolumeRow, error) {
+	rows, err := s.db.DB.QueryContext(ctx,
+		`SELECT to_char(value_date, 'YYYY-MM-DD'), currency, type, COUNT(*), SUM(amount)
+		FROM transactions
+		WHERE value_date >= $1 AND value_date <= $2 AND (parent_id IS NULL OR type = 'fee') AND ($3 = '' OR currency = $3)
+		GROUP BY value_date, currency, type
+		ORDER BY value_date, currency, type`,
+		from.Format(time.DateOnly), to.Format(time.DateOnly), currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to total daily volume")
+	}
+	defer rows.Close()
+
+	result := []VolumeRow{}
+	for rows.Next() {
+		var r VolumeRow
+		if err := rows.Scan(&r.Date, &r.Currency, &r.Type, &r.Count, &r.Amount); err != nil {
+			return nil, errors.Wrap(err, "failed to scan daily volume")
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,88 @@
+package report_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/report"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/stretchr/testify/assert"
+)
+
+var trialBalanceColumns = []string{"currency", "kind", "type", "status", "count", "credits", "debits", "balance"}
+
+func TestStore_TrialBalance(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := report.NewStore(&db.DB{DB: sqlDB})
+
+	// Credits and debits are the positive and negative movements
+	signed := regexp.QuoteMeta(transaction.SignedAmountSQL)
+	mock.ExpectQuery(`SELECT a.currency, a.kind, a.type, a.status, COUNT\(\*\), .* FROM accounts a LEFT JOIN .*`+
+		`GREATEST\(`+signed+`, 0\) ELSE 0 END\) AS credits, .* GREATEST\(-\(`+signed+`\), 0\) ELSE 0 END\) AS debits, .*`+
+		`GROUP BY a.currency, a.kind, a.type, a.status`).
+		WithArgs(nil, "").
+		WillReturnRows(sqlmock.NewRows(trialBalanceColumns).
+			AddRow("EUR", "customer", "liability", "active", 3, "1000.10", "250.05", "750.05").
+			AddRow("EUR", "system", "asset", "active", 1, "0", "750.05", "-750.05").
+			AddRow("USD", "customer", "liability", "closed", 1, "10", "10", "0"))
+
+	rows, err := store.TrialBalance(context.Background(), nil, "")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "active", rows[0].Status)
+	assert.Equal(t, "system", rows[1].Kind)
+	assert.Equal(t, "-750.05", rows[1].Balance.String())
+	assert.Equal(t, 3, rows[0].Accounts)
+	assert.Equal(t, "750.05", rows[0].Balance.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_TrialBalance_AsOf(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := report.NewStore(&db.DB{DB: sqlDB})
+	asOf := time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)
+
+	// Entries booked after the date are taken back out of the balances
+	mock.ExpectQuery(`SUM\(CASE WHEN value_date > \$1::date THEN .* ELSE 0 END\) AS later .* WHERE \(\$1::date IS NULL OR a.created_at < \$1::date \+ 1\) AND \(\$2 = '' OR a.currency = \$2\)`).
+		WithArgs("2026-05-31", "EUR").
+		WillReturnRows(sqlmock.NewRows(trialBalanceColumns))
+
+	rows, err := store.TrialBalance(context.Background(), &asOf, "EUR")
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Volumes(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := report.NewStore(&db.DB{DB: sqlDB})
+
+	// Transfer credits and fee revenue are posted on behalf of another transaction and not counted again
+	mock.ExpectQuery(`FROM transactions WHERE value_date >= \$1 AND value_date <= \$2 AND \(parent_id IS NULL OR type = 'fee'\) AND \(\$3 = '' OR currency = \$3\) GROUP BY value_date, currency, type`).
+		WithArgs("2026-06-01", "2026-06-02", "").
+		WillReturnRows(sqlmock.NewRows([]string{"date", "currency", "type", "count", "sum"}).
+			AddRow("2026-06-01", "EUR", "deposit", 2, "0.30").
+			AddRow("2026-06-02", "EUR", "withdrawal", 1, "12.5"))
+
+	rows, err := store.Volumes(context.Background(), time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC), "")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "2026-06-01", rows[0].Date)
+	assert.Equal(t, "deposit", rows[0].Type)
+	assert.Equal(t, 2, rows[0].Count)
+	assert.Equal(t, "0.3", rows[0].Amount.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,35 @@
+package report
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	trialBalanceHandler := kithttp.NewServer(
+		makeTrialBalanceEndpoint(ms),
+		decodeTrialBalanceRequest,
+		encodeReportResponse,
+		opts...,
+	)
+
+	volumeHandler := kithttp.NewServer(
+		makeVolumeEndpoint(ms),
+		decodeVolumeRequest,
+		encodeReportResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("GET", "/reports/trial-balance", trialBalanceHandler)
+	r.Method("GET", "/reports/daily-volume", volumeHandler)
+
+	return http.Endpoint{Pattern: "/reports/*", Handler: r}
+}
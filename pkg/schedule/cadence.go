@@ -0,0 +1,96 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+)
+
+// FirstOccurrence returns the first occurrence on or after the schedule's start date, or false
+// if the schedule has no occurrence before its end date.
+func FirstOccurrence(s model.Schedule) (time.Time, bool) {
+	start := day(s.StartDate)
+
+	var first time.Time
+	switch s.Cadence {
+	case model.CadenceOnce, model.CadenceWeekly:
+		first = start
+	case model.CadenceMonthly:
+		first = dayInMonth(start.Year(), start.Month(), s.DayOfMonth)
+		if first.Before(start) {
+			first = dayInMonth(start.Year(), start.Month()+1, s.DayOfMonth)
+		}
+	case model.CadenceEndOfMonth:
+		first = lastDayOfMonth(start.Year(), start.Month())
+	default:
+		return time.Time{}, false
+	}
+
+	return first, withinEnd(s, first)
+}
+
+// NextOccurrence returns the occurrence following after, or false once the schedule is exhausted.
+func NextOccurrence(s model.Schedule, after time.Time) (time.Time, bool) {
+	after = day(after)
+
+	var next time.Time
+	switch s.Cadence {
+	case model.CadenceWeekly:
+		next = after.AddDate(0, 0, 7)
+	case model.CadenceMonthly:
+		next = dayInMonth(after.Year(), after.Month()+1, s.DayOfMonth)
+	case model.CadenceEndOfMonth:
+		next = lastDayOfMonth(after.Year(), after.Month()+1)
+	default:
+		return time.Time{}, false
+	}
+
+	return next, withinEnd(s, next)
+}
+
+// Upcoming lists up to n occurrences starting at the schedule's next run date.
+func Upcoming(s model.Schedule, n int) []time.Time {
+	if s.NextRunDate == nil || s.Status == model.ScheduleStatusCancelled || s.Status == model.ScheduleStatusCompleted {
+		return nil
+	}
+
+	dates := make([]time.Time, 0, n)
+	next, ok := day(*s.NextRunDate), true
+	for ok && len(dates) < n {
+		dates = append(dates, next)
+		next, ok = NextOccurrence(s, next)
+	}
+	return dates
+}
+
+// occurrenceOnOrAfter skips occurrences before from, used when a paused schedule resumes.
+func occurrenceOnOrAfter(s model.Schedule, from time.Time) (time.Time, bool) {
+	next, ok := FirstOccurrence(s)
+	for ok && next.Before(from) {
+		next, ok = NextOccurrence(s, next)
+	}
+	return next, ok
+}
+
+func withinEnd(s model.Schedule, t time.Time) bool {
+	return s.EndDate == nil || !t.After(day(*s.EndDate))
+}
+
+// dayInMonth returns the given day of the month, clamped to the month's last day.
+// Months outside 1-12 roll over into the adjacent years.
+func dayInMonth(year int, month time.Month, dayOfMonth int) time.Time {
+	last := lastDayOfMonth(year, month)
+	if dayOfMonth > last.Day() {
+		return last
+	}
+	return time.Date(last.Year(), last.Month(), dayOfMonth, 0, 0, 0, 0, time.UTC)
+}
+
+func lastDayOfMonth(year int, month time.Month) time.Time {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+}
+
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,78 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestUpcoming_MonthlyClampsToMonthEnd(t *testing.T) {
+	s := model.Schedule{Cadence: model.CadenceMonthly, DayOfMonth: 31, StartDate: date(2024, 1, 15), Status: model.ScheduleStatusActive}
+
+	first, ok := schedule.FirstOccurrence(s)
+	assert.True(t, ok)
+	assert.Equal(t, date(2024, 1, 31), first)
+
+	s.NextRunDate = &first
+	assert.Equal(t, []time.Time{
+		date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31), date(2024, 4, 30),
+	}, schedule.Upcoming(s, 4))
+}
+
+func TestFirstOccurrence_MonthlyDayAlreadyPassed(t *testing.T) {
+	s := model.Schedule{Cadence: model.CadenceMonthly, DayOfMonth: 10, StartDate: date(2024, 12, 20)}
+
+	first, ok := schedule.FirstOccurrence(s)
+	assert.True(t, ok)
+	assert.Equal(t, date(2025, 1, 10), first)
+}
+
+func TestUpcoming_EndOfMonth(t *testing.T) {
+	s := model.Schedule{Cadence: model.CadenceEndOfMonth, StartDate: date(2023, 1, 5), Status: model.ScheduleStatusActive}
+
+	first, ok := schedule.FirstOccurrence(s)
+	assert.True(t, ok)
+
+	s.NextRunDate = &first
+	assert.Equal(t, []time.Time{
+		date(2023, 1, 31), date(2023, 2, 28), date(2023, 3, 31),
+	}, schedule.Upcoming(s, 3))
+}
+
+func TestUpcoming_WeeklyStopsAtEndDate(t *testing.T) {
+	end := date(2024, 3, 15)
+	s := model.Schedule{Cadence: model.CadenceWeekly, StartDate: date(2024, 3, 1), EndDate: &end, Status: model.ScheduleStatusActive}
+
+	first, ok := schedule.FirstOccurrence(s)
+	assert.True(t, ok)
+
+	s.NextRunDate = &first
+	assert.Equal(t, []time.Time{
+		date(2024, 3, 1), date(2024, 3, 8), date(2024, 3, 15),
+	}, schedule.Upcoming(s, 10))
+}
+
+func TestNextOccurrence_Once(t *testing.T) {
+	s := model.Schedule{Cadence: model.CadenceOnce, StartDate: date(2024, 6, 1)}
+
+	first, ok := schedule.FirstOccurrence(s)
+	assert.True(t, ok)
+	assert.Equal(t, date(2024, 6, 1), first)
+
+	_, ok = schedule.NextOccurrence(s, first)
+	assert.False(t, ok)
+}
+
+func TestUpcoming_CancelledScheduleHasNone(t *testing.T) {
+	next := date(2024, 6, 1)
+	s := model.Schedule{Cadence: model.CadenceWeekly, StartDate: next, NextRunDate: &next, Status: model.ScheduleStatusCancelled}
+
+	assert.Empty(t, schedule.Upcoming(s, 3))
+}
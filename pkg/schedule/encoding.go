@@ -0,0 +1,107 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultUpcomingCount = 5
+
+type ScheduleRequest struct {
+	AccountID       string          `json:"account_id"`
+	TargetAccountID string          `json:"target_account_id"`
+	Type            string          `json:"type"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        string          `json:"currency"`
+	Cadence         string          `json:"cadence"`
+	DayOfMonth      int             `json:"day_of_month"`
+	StartDate       string          `json:"start_date"` // YYYY-MM-DD
+	EndDate         string          `json:"end_date"`   // YYYY-MM-DD, optional
+}
+
+type ScheduleIDRequest struct {
+	ID string
+}
+
+type UpcomingRequest struct {
+	ID    string
+	Count int
+}
+
+func decodeCreateScheduleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req ScheduleRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode schedule request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	if req.AccountID == "" {
+		return nil, eError.NewServiceError(
+			errors.New("account_id is required"), "account_id is required", "MISSING_ACCOUNT_ID", http.StatusBadRequest)
+	}
+
+	if !req.Amount.IsPositive() {
+		return nil, eError.NewServiceError(
+			errors.New("amount must be positive"), "amount must be greater than zero", "INVALID_AMOUNT", http.StatusBadRequest)
+	}
+
+	if req.Currency == "" {
+		return nil, eError.NewServiceError(
+			errors.New("currency is required"), "currency is required", "MISSING_CURRENCY", http.StatusBadRequest)
+	}
+
+	if _, err := time.Parse(time.DateOnly, req.StartDate); err != nil {
+		return nil, eError.NewServiceError(
+			errors.New("start_date must be YYYY-MM-DD"), "invalid start_date", "VALIDATION", http.StatusBadRequest)
+	}
+
+	if req.EndDate != "" {
+		if _, err := time.Parse(time.DateOnly, req.EndDate); err != nil {
+			return nil, eError.NewServiceError(
+				errors.New("end_date must be YYYY-MM-DD"), "invalid end_date", "VALIDATION", http.StatusBadRequest)
+		}
+	}
+
+	return req, nil
+}
+
+func decodeScheduleIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		return nil, eError.NewServiceError(
+			errors.New("schedule id missing in path"), "missing schedule id in path", "MISSING_SCHEDULE_ID", http.StatusBadRequest)
+	}
+
+	return ScheduleIDRequest{ID: id}, nil
+}
+
+func decodeUpcomingRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		return nil, eError.NewServiceError(
+			errors.New("schedule id missing in path"), "missing schedule id in path", "MISSING_SCHEDULE_ID", http.StatusBadRequest)
+	}
+
+	count := defaultUpcomingCount
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			return nil, eError.NewServiceError(
+				errors.New("count must be between 1 and 100"), "invalid count", "VALIDATION", http.StatusBadRequest)
+		}
+		count = n
+	}
+
+	return UpcomingRequest{ID: id, Count: count}, nil
+}
@@ -0,0 +1,91 @@
+package schedule
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+type UpcomingResponse struct {
+	ScheduleID string   `json:"schedule_id"`
+	Dates      []string `json:"dates"`
+}
+
+func makeCreateScheduleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(ScheduleRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		// Dates were validated by the decoder
+		start, _ := time.Parse(time.DateOnly, req.StartDate)
+		var end *time.Time
+		if req.EndDate != "" {
+			t, _ := time.Parse(time.DateOnly, req.EndDate)
+			end = &t
+		}
+
+		return s.CreateSchedule(ctx, CreateScheduleRequest{
+			AccountID:       req.AccountID,
+			TargetAccountID: req.TargetAccountID,
+			Type:            req.Type,
+			Amount:          req.Amount,
+			Currency:        req.Currency,
+			Cadence:         model.Cadence(req.Cadence),
+			DayOfMonth:      req.DayOfMonth,
+			StartDate:       start,
+			EndDate:         end,
+		})
+	}
+}
+
+func makeGetScheduleEndpoint(s Service) endpoint.Endpoint {
+	return makeScheduleIDEndpoint(s.GetSchedule)
+}
+
+func makePauseScheduleEndpoint(s Service) endpoint.Endpoint {
+	return makeScheduleIDEndpoint(s.PauseSchedule)
+}
+
+func makeResumeScheduleEndpoint(s Service) endpoint.Endpoint {
+	return makeScheduleIDEndpoint(s.ResumeSchedule)
+}
+
+func makeCancelScheduleEndpoint(s Service) endpoint.Endpoint {
+	return makeScheduleIDEndpoint(s.CancelSchedule)
+}
+
+func makeScheduleIDEndpoint(fn func(ctx context.Context, id string) (*model.Schedule, error)) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(ScheduleIDRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+		return fn(ctx, req.ID)
+	}
+}
+
+func makeUpcomingEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(UpcomingRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		dates, err := s.UpcomingExecutions(ctx, req.ID, req.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := UpcomingResponse{ScheduleID: req.ID, Dates: make([]string, 0, len(dates))}
+		for _, d := range dates {
+			resp.Dates = append(resp.Dates, d.Format(time.DateOnly))
+		}
+		return resp, nil
+	}
+}
@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mdshahjahanmiah/explore-go/logging"
+)
+
+// Runner periodically queues due occurrences. It implements di.StartCloser so the ledger
+// service starts and stops it with the HTTP server. Several runners can share a database;
+// each due schedule is claimed by one of them.
+type Runner struct {
+	service  Service
+	logger   *logging.Logger
+	interval time.Duration
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewRunner(service Service, logger *logging.Logger, interval time.Duration) *Runner {
+	return &Runner{service: service, logger: logger, interval: interval}
+}
+
+func (r *Runner) Start() error {
+	if r.interval <= 0 {
+		r.logger.Info("schedule runner disabled")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			count, err := r.service.RunDue(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				r.logger.Error("schedule run failed", "handled", count, "error", err)
+			} else if count > 0 {
+				r.logger.Info("scheduled transactions queued", "count", count)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	r.logger.Info("schedule runner started", "interval", r.interval)
+	return nil
+}
+
+func (r *Runner) Close() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	r.logger.Info("schedule runner stopped")
+}
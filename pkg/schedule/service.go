@@ -0,0 +1,279 @@
+// Package schedule stores standing orders and queues their occurrences as transactions.
+//
+// Each occurrence goes through transaction.Service.CreateTransaction with a reference ID
+// derived from the schedule and occurrence date. If the runner stops after queueing an
+// occurrence but before recording it, the occurrence is queued again with the same
+// reference ID and the processor rejects it as a duplicate, so it never executes twice.
+package schedule
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+)
+
+type Service interface {
+	CreateSchedule(ctx context.Context, req CreateScheduleRequest) (*model.Schedule, error)
+	GetSchedule(ctx context.Context, id string) (*model.Schedule, error)
+	PauseSchedule(ctx context.Context, id string) (*model.Schedule, error)
+	ResumeSchedule(ctx context.Context, id string) (*model.Schedule, error)
+	CancelSchedule(ctx context.Context, id string) (*model.Schedule, error)
+	UpcomingExecutions(ctx context.Context, id string, count int) ([]time.Time, error)
+	RunDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type service struct {
+	config       config.Config
+	logger       *logging.Logger
+	store        Store
+	transactions transaction.Service
+}
+
+type CreateScheduleRequest struct {
+	AccountID       string
+	TargetAccountID string
+	Type            string
+	Amount          decimal.Decimal
+	Currency        string
+	Cadence         model.Cadence
+	DayOfMonth      int
+	StartDate       time.Time
+	EndDate         *time.Time
+}
+
+func NewService(config config.Config, logger *logging.Logger, database *db.DB, transactions transaction.Service) Service {
+	return &service{
+		config:       config,
+		logger:       logger,
+		store:        NewStore(database),
+		transactions: transactions,
+	}
+}
+
+func (s *service) CreateSchedule(ctx context.Context, req CreateScheduleRequest) (*model.Schedule, error) {
+	sc := &model.Schedule{
+		ID:              model.NewUUID(),
+		AccountID:       req.AccountID,
+		TargetAccountID: req.TargetAccountID,
+		Type:            strings.ToLower(req.Type),
+		Amount:          req.Amount,
+		Currency:        strings.ToUpper(req.Currency),
+		Cadence:         req.Cadence,
+		DayOfMonth:      req.DayOfMonth,
+		StartDate:       day(req.StartDate),
+		EndDate:         req.EndDate,
+		Status:          model.ScheduleStatusActive,
+	}
+
+	if sc.Cadence == model.CadenceMonthly && sc.DayOfMonth == 0 {
+		sc.DayOfMonth = sc.StartDate.Day()
+	}
+
+	if err := sc.Validate(); err != nil {
+		return nil, eError.NewServiceError(err, "invalid schedule", "VALIDATION", http.StatusBadRequest)
+	}
+
+	if sc.StartDate.Before(day(time.Now())) {
+		return nil, eError.NewServiceError(
+			errors.New("start_date is in the past"), "start_date must be today or later", "VALIDATION", http.StatusBadRequest)
+	}
+
+	first, ok := FirstOccurrence(*sc)
+	if !ok {
+		return nil, eError.NewServiceError(
+			errors.New("no occurrence before end_date"), "schedule has no occurrence before end_date", "VALIDATION", http.StatusBadRequest)
+	}
+	sc.NextRunDate = &first
+
+	if err := s.store.Insert(ctx, sc); err != nil {
+		s.logger.Error("failed to create schedule", "account_id", sc.AccountID, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("schedule created", "schedule_id", sc.ID, "account_id", sc.AccountID, "cadence", sc.Cadence, "next_run_date", first.Format(time.DateOnly))
+	return sc, nil
+}
+
+func (s *service) GetSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	sc, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrScheduleNotFound) {
+			return nil, eError.NewServiceError(err, "schedule not found", "SCHEDULE_NOT_FOUND", http.StatusNotFound)
+		}
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (s *service) PauseSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	sc, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.Status != model.ScheduleStatusActive {
+		return nil, invalidTransition(sc, model.ScheduleStatusPaused)
+	}
+
+	return s.updateStatus(ctx, sc, model.ScheduleStatusPaused, sc.NextRunDate)
+}
+
+// ResumeSchedule reactivates a paused schedule. Occurrences that fell due while it was paused are skipped.
+func (s *service) ResumeSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	sc, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.Status != model.ScheduleStatusPaused {
+		return nil, invalidTransition(sc, model.ScheduleStatusActive)
+	}
+
+	next, ok := occurrenceOnOrAfter(*sc, day(time.Now()))
+	if !ok {
+		return s.updateStatus(ctx, sc, model.ScheduleStatusCompleted, nil)
+	}
+	return s.updateStatus(ctx, sc, model.ScheduleStatusActive, &next)
+}
+
+func (s *service) CancelSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	sc, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.Status == model.ScheduleStatusCancelled || sc.Status == model.ScheduleStatusCompleted {
+		return nil, invalidTransition(sc, model.ScheduleStatusCancelled)
+	}
+
+	return s.updateStatus(ctx, sc, model.ScheduleStatusCancelled, nil)
+}
+
+func (s *service) UpcomingExecutions(ctx context.Context, id string, count int) ([]time.Time, error) {
+	sc, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.Status != model.ScheduleStatusActive {
+		return []time.Time{}, nil
+	}
+	return Upcoming(*sc, count), nil
+}
+
+// RunDue queues every occurrence due on or before now's date, one occurrence at a time, and
+// returns how many occurrences were handled. A schedule that fell behind catches up in the same call.
+func (s *service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	today := day(now)
+	handled := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return handled, err
+		}
+
+		found, err := s.store.RunNextDue(ctx, today, func(sc model.Schedule) (Advance, error) {
+			return s.runOccurrence(ctx, sc)
+		})
+		if err != nil {
+			return handled, err
+		}
+		if !found {
+			return handled, nil
+		}
+		handled++
+	}
+}
+
+// runOccurrence queues the schedule's due occurrence. Rejections by CreateTransaction are recorded
+// as failed executions and the schedule moves on; other errors leave the occurrence due.
+func (s *service) runOccurrence(ctx context.Context, sc model.Schedule) (Advance, error) {
+	occurrence := day(*sc.NextRunDate)
+	execution := model.ScheduleExecution{
+		ScheduleID:     sc.ID,
+		OccurrenceDate: occurrence,
+		ReferenceID:    model.DeriveUUID("schedule", sc.ID, occurrence.Format(time.DateOnly)),
+		Status:         model.ScheduleExecutionQueued,
+	}
+
+	txn, err := s.transactions.CreateTransaction(ctx, model.Transaction{
+		AccountID:       sc.AccountID,
+		TargetAccountID: sc.TargetAccountID,
+		Type:            sc.Type,
+		Amount:          model.Decimal{Decimal: sc.Amount},
+		Currency:        sc.Currency,
+		ReferenceID:     execution.ReferenceID,
+	})
+
+	switch {
+	case err == nil:
+		execution.TransactionID = txn.ID
+	case isRejection(err):
+		execution.Status = model.ScheduleExecutionFailed
+		execution.Error = err.Error()
+		s.logger.Warn("scheduled transaction rejected", "schedule_id", sc.ID, "occurrence", occurrence.Format(time.DateOnly), "error", err)
+	default:
+		s.logger.Error("failed to queue scheduled transaction", "schedule_id", sc.ID, "occurrence", occurrence.Format(time.DateOnly), "error", err)
+		return Advance{}, err
+	}
+
+	adv := Advance{Execution: execution, Status: model.ScheduleStatusActive}
+	if next, ok := NextOccurrence(sc, occurrence); ok {
+		adv.NextRunDate = &next
+	} else {
+		adv.Status = model.ScheduleStatusCompleted
+	}
+
+	s.logger.Info("scheduled transaction queued", "schedule_id", sc.ID, "occurrence", occurrence.Format(time.DateOnly),
+		"reference_id", execution.ReferenceID, "status", execution.Status)
+	return adv, nil
+}
+
+func (s *service) updateStatus(ctx context.Context, sc *model.Schedule, status model.ScheduleStatus, next *time.Time) (*model.Schedule, error) {
+	if err := s.store.UpdateStatus(ctx, sc.ID, status, next); err != nil {
+		s.logger.Error("failed to update schedule", "schedule_id", sc.ID, "status", status, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("schedule updated", "schedule_id", sc.ID, "from", sc.Status, "to", status)
+	sc.Status = status
+	sc.NextRunDate = next
+	return sc, nil
+}
+
+// isRejection reports whether CreateTransaction refused the transaction itself, as opposed to
+// failing to queue it.
+func isRejection(err error) bool {
+	var serviceErr eError.ServiceError
+	if errors.As(err, &serviceErr) {
+		return true
+	}
+
+	for _, validationErr := range []error{
+		model.ErrInvalidTransactionID, model.ErrInvalidAccountID, model.ErrInvalidTargetAccountID,
+		model.ErrInvalidReferenceID, model.ErrInvalidAmount, model.ErrInvalidTransactionType, model.ErrInvalidCurrency,
+	} {
+		if errors.Is(err, validationErr) {
+			return true
+		}
+	}
+	return false
+}
+
+func invalidTransition(sc *model.Schedule, to model.ScheduleStatus) error {
+	return eError.NewServiceError(
+		errors.Errorf("schedule is %s", sc.Status), "schedule cannot become "+string(to), "INVALID_SCHEDULE_STATUS", http.StatusConflict)
+}
@@ -0,0 +1,175 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/pkg/errors"
+	"log/slog"
+	"time"
+)
+
+// Advance is the outcome of running one occurrence: the execution to record and the
+// schedule's next run date and status.
+type Advance struct {
+	Execution   model.ScheduleExecution
+	NextRunDate *time.Time
+	Status      model.ScheduleStatus
+}
+
+type Store interface {
+	Insert(ctx context.Context, s *model.Schedule) error
+	Get(ctx context.Context, id string) (*model.Schedule, error)
+	UpdateStatus(ctx context.Context, id string, status model.ScheduleStatus, nextRunDate *time.Time) error
+	RunNextDue(ctx context.Context, today time.Time, run func(model.Schedule) (Advance, error)) (bool, error)
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+const scheduleColumns = `id, account_id, COALESCE(target_account_id::text, ''), type, amount, currency, cadence,
+	COALESCE(day_of_month, 0), start_date, end_date, next_run_date, status, created_at, updated_at`
+
+func (s *store) Insert(ctx context.Context, sc *model.Schedule) error {
+	var target, dayOfMonth interface{}
+	if sc.TargetAccountID != "" {
+		target = sc.TargetAccountID
+	}
+	if sc.DayOfMonth != 0 {
+		dayOfMonth = sc.DayOfMonth
+	}
+
+	err := s.db.DB.QueryRowContext(ctx,
+		`INSERT INTO schedules
+		(id, account_id, target_account_id, type, amount, currency, cadence, day_of_month, start_date, end_date, next_run_date, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING created_at, updated_at`,
+		sc.ID, sc.AccountID, target, sc.Type, sc.Amount, sc.Currency, sc.Cadence, dayOfMonth,
+		dateArg(&sc.StartDate), dateArg(sc.EndDate), dateArg(sc.NextRunDate), sc.Status,
+	).Scan(&sc.CreatedAt, &sc.UpdatedAt)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to create schedule")
+	}
+	return nil
+}
+
+func (s *store) Get(ctx context.Context, id string) (*model.Schedule, error) {
+	sc, err := scanSchedule(s.db.DB.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get schedule")
+	}
+	return sc, nil
+}
+
+func (s *store) UpdateStatus(ctx context.Context, id string, status model.ScheduleStatus, nextRunDate *time.Time) error {
+	_, err := s.db.DB.ExecContext(ctx,
+		`UPDATE schedules SET status = $1, next_run_date = $2, updated_at = NOW() WHERE id = $3`,
+		status, dateArg(nextRunDate), id,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update schedule")
+	}
+	return nil
+}
+
+// RunNextDue locks one due schedule, skipping schedules locked by other runners, and hands it to run.
+// The execution run returns is recorded and the schedule advanced in the same transaction; when run
+// fails nothing changes and the occurrence is retried later. It reports whether a schedule was due.
+func (s *store) RunNextDue(ctx context.Context, today time.Time, run func(model.Schedule) (Advance, error)) (bool, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	sc, err := scanSchedule(tx.QueryRowContext(ctx,
+		`SELECT `+scheduleColumns+` FROM schedules
+		WHERE status = 'active' AND next_run_date <= $1
+		ORDER BY next_run_date
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`,
+		today.Format(time.DateOnly),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "failed to claim due schedule")
+	}
+
+	adv, err := run(*sc)
+	if err != nil {
+		return true, err
+	}
+
+	e := adv.Execution
+	var transactionID, execErr interface{}
+	if e.TransactionID != "" {
+		transactionID = e.TransactionID
+	}
+	if e.Error != "" {
+		execErr = e.Error
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO schedule_executions (schedule_id, occurrence_date, transaction_id, reference_id, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (schedule_id, occurrence_date) DO NOTHING`,
+		sc.ID, e.OccurrenceDate.Format(time.DateOnly), transactionID, e.ReferenceID, e.Status, execErr,
+	)
+	if err != nil {
+		return true, errors.Wrap(err, "failed to record execution")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE schedules SET status = $1, next_run_date = $2, updated_at = NOW() WHERE id = $3`,
+		adv.Status, dateArg(adv.NextRunDate), sc.ID,
+	)
+	if err != nil {
+		return true, errors.Wrap(err, "failed to advance schedule")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return true, errors.Wrap(err, "transaction commit failed")
+	}
+	return true, nil
+}
+
+func scanSchedule(row *sql.Row) (*model.Schedule, error) {
+	var sc model.Schedule
+	var endDate, nextRunDate sql.NullTime
+	err := row.Scan(&sc.ID, &sc.AccountID, &sc.TargetAccountID, &sc.Type, &sc.Amount, &sc.Currency, &sc.Cadence,
+		&sc.DayOfMonth, &sc.StartDate, &endDate, &nextRunDate, &sc.Status, &sc.CreatedAt, &sc.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if endDate.Valid {
+		sc.EndDate = &endDate.Time
+	}
+	if nextRunDate.Valid {
+		sc.NextRunDate = &nextRunDate.Time
+	}
+	return &sc, nil
+}
+
+func dateArg(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.Format(time.DateOnly)
+}
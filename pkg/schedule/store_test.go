@@ -0,0 +1,109 @@
+package schedule_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+)
+
+var scheduleRow = []string{"id", "account_id", "target_account_id", "type", "amount", "currency", "cadence",
+	"day_of_month", "start_date", "end_date", "next_run_date", "status", "created_at", "updated_at"}
+
+func TestStore_RunNextDue(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := schedule.NewStore(&db.DB{DB: sqlDB})
+	today := date(2024, 2, 29)
+	next := date(2024, 3, 31)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM schedules .* FOR UPDATE SKIP LOCKED`).
+		WithArgs("2024-02-29").
+		WillReturnRows(sqlmock.NewRows(scheduleRow).
+			AddRow("sch1", "acc1", "", "withdrawal", "25", "USD", "monthly", 31, date(2024, 1, 31), nil, today, "active", time.Now(), time.Now()))
+
+	mock.ExpectExec(`INSERT INTO schedule_executions .* ON CONFLICT \(schedule_id, occurrence_date\) DO NOTHING`).
+		WithArgs("sch1", "2024-02-29", "txn1", "ref1", model.ScheduleExecutionQueued, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE schedules SET status = \$1, next_run_date = \$2`).
+		WithArgs(model.ScheduleStatusActive, "2024-03-31", "sch1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	found, err := store.RunNextDue(context.Background(), today, func(sc model.Schedule) (schedule.Advance, error) {
+		assert.Equal(t, "sch1", sc.ID)
+		assert.Equal(t, 31, sc.DayOfMonth)
+		return schedule.Advance{
+			Execution: model.ScheduleExecution{
+				ScheduleID:     sc.ID,
+				OccurrenceDate: *sc.NextRunDate,
+				TransactionID:  "txn1",
+				ReferenceID:    "ref1",
+				Status:         model.ScheduleExecutionQueued,
+			},
+			NextRunDate: &next,
+			Status:      model.ScheduleStatusActive,
+		}, nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_RunNextDue_RunFails(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := schedule.NewStore(&db.DB{DB: sqlDB})
+	today := date(2024, 2, 29)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM schedules`).
+		WithArgs("2024-02-29").
+		WillReturnRows(sqlmock.NewRows(scheduleRow).
+			AddRow("sch1", "acc1", "acc2", "transfer", "25", "USD", "once", 0, today, nil, today, "active", time.Now(), time.Now()))
+
+	// The occurrence stays due for the next run
+	mock.ExpectRollback()
+
+	queueErr := errors.New("broker unavailable")
+	found, err := store.RunNextDue(context.Background(), today, func(sc model.Schedule) (schedule.Advance, error) {
+		return schedule.Advance{}, queueErr
+	})
+	assert.ErrorIs(t, err, queueErr)
+	assert.True(t, found)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_RunNextDue_NothingDue(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := schedule.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM schedules`).
+		WillReturnRows(sqlmock.NewRows(scheduleRow))
+	mock.ExpectRollback()
+
+	found, err := store.RunNextDue(context.Background(), date(2024, 2, 29), func(sc model.Schedule) (schedule.Advance, error) {
+		t.Fatal("run called without a due schedule")
+		return schedule.Advance{}, nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	createHandler := kithttp.NewServer(
+		makeCreateScheduleEndpoint(ms),
+		decodeCreateScheduleRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	getHandler := kithttp.NewServer(
+		makeGetScheduleEndpoint(ms),
+		decodeScheduleIDRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	pauseHandler := kithttp.NewServer(
+		makePauseScheduleEndpoint(ms),
+		decodeScheduleIDRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	resumeHandler := kithttp.NewServer(
+		makeResumeScheduleEndpoint(ms),
+		decodeScheduleIDRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	cancelHandler := kithttp.NewServer(
+		makeCancelScheduleEndpoint(ms),
+		decodeScheduleIDRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	upcomingHandler := kithttp.NewServer(
+		makeUpcomingEndpoint(ms),
+		decodeUpcomingRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("POST", "/schedules", createHandler)
+	r.Method("GET", "/schedules/{id}", getHandler)
+	r.Method("POST", "/schedules/{id}/pause", pauseHandler)
+	r.Method("POST", "/schedules/{id}/resume", resumeHandler)
+	r.Method("POST", "/schedules/{id}/cancel", cancelHandler)
+	r.Method("GET", "/schedules/{id}/upcoming", upcomingHandler)
+
+	return http.Endpoint{Pattern: "/schedules*", Handler: r}
+}
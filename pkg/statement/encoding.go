@@ -0,0 +1,125 @@
+package statement
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type StatementRequest struct {
+	AccountID string
+	From      time.Time
+	To        time.Time
+	Format    string
+}
+
+type BalanceRequest struct {
+	AccountID string
+	AsOf      time.Time
+}
+
+type StatementResponse struct {
+	Statement model.Statement
+	Format    string
+	Export    Export
+}
+
+// acceptFormats maps media types in the Accept header to statement formats.
+var acceptFormats = map[string]string{
+	"application/json":                      FormatJSON,
+	"text/csv":                              FormatCSV,
+	"application/x-ofx":                     FormatOFX,
+	"application/vnd.intu.ofx":              FormatOFX,
+	"application/xml":                       FormatCamt053,
+	"text/xml":                              FormatCamt053,
+	"application/vnd.iso20022.camt.053+xml": FormatCamt053,
+}
+
+func decodeStatementRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accountID := chi.URLParam(r, "id")
+	if !model.IsValidUUID(accountID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid account id"), "account id must be a valid UUID", "MISSING_ACCOUNT_ID", http.StatusBadRequest)
+	}
+
+	from, err := time.Parse(time.DateOnly, r.URL.Query().Get("from"))
+	if err != nil {
+		return nil, eError.NewServiceError(err, "from must be a date in YYYY-MM-DD format", "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+
+	to, err := time.Parse(time.DateOnly, r.URL.Query().Get("to"))
+	if err != nil {
+		return nil, eError.NewServiceError(err, "to must be a date in YYYY-MM-DD format", "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = negotiateFormat(r.Header.Get("Accept"))
+	} else if format != FormatJSON && !IsExportFormat(format) {
+		return nil, eError.NewServiceError(
+			errors.Errorf("unsupported format %q", format), "format must be json, csv, ofx or camt053", "UNSUPPORTED_FORMAT", http.StatusBadRequest)
+	}
+
+	return StatementRequest{AccountID: accountID, From: from, To: to, Format: format}, nil
+}
+
+// negotiateFormat picks the first media type in the Accept header that has a statement format,
+// falling back to JSON.
+func negotiateFormat(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if format, ok := acceptFormats[mediaType]; ok {
+			return format
+		}
+	}
+	return FormatJSON
+}
+
+func encodeStatementResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp, ok := response.(StatementResponse)
+	if !ok {
+		return kithttp.EncodeJSONResponse(ctx, w, response)
+	}
+
+	ef, ok := exportFormats[resp.Format]
+	if !ok {
+		return kithttp.EncodeJSONResponse(ctx, w, resp.Statement)
+	}
+
+	st := resp.Statement
+	filename := fmt.Sprintf("statement-%s-%s-%s.%s", st.AccountID, st.From.Format(time.DateOnly), st.To.Format(time.DateOnly), ef.extension)
+	w.Header().Set("Content-Type", ef.contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+	return ef.write(w, st, resp.Export)
+}
+
+func decodeBalanceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accountID := chi.URLParam(r, "id")
+	if !model.IsValidUUID(accountID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid account id"), "account id must be a valid UUID", "MISSING_ACCOUNT_ID", http.StatusBadRequest)
+	}
+
+	asOf := time.Now().UTC()
+	if v := r.URL.Query().Get("as_of"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, eError.NewServiceError(err, "as_of must be an RFC 3339 timestamp", "INVALID_AS_OF", http.StatusBadRequest)
+		}
+		asOf = t
+	}
+
+	return BalanceRequest{AccountID: accountID, AsOf: asOf}, nil
+}
@@ -0,0 +1,41 @@
+package statement
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+func makeStatementEndpoint(s Service, conf config.Config) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(StatementRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		st, err := s.GetStatement(ctx, req.AccountID, req.From, req.To)
+		if err != nil {
+			return nil, err
+		}
+
+		return StatementResponse{
+			Statement: st,
+			Format:    req.Format,
+			Export:    Export{GeneratedAt: time.Now().UTC(), BankID: conf.BankID},
+		}, nil
+	}
+}
+
+func makeBalanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(BalanceRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+		return s.GetBalanceAsOf(ctx, req.AccountID, req.AsOf)
+	}
+}
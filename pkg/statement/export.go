@@ -0,0 +1,409 @@
+package statement
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatCamt053 = "camt053"
+)
+
+// Export carries what a statement file needs beyond the statement itself.
+type Export struct {
+	GeneratedAt time.Time
+	BankID      string // BANKID of the OFX account
+}
+
+type exportFormat struct {
+	contentType string
+	extension   string
+	write       func(w io.Writer, st model.Statement, exp Export) error
+}
+
+var exportFormats = map[string]exportFormat{
+	FormatCSV:     {"text/csv; charset=utf-8", "csv", func(w io.Writer, st model.Statement, _ Export) error { return WriteCSV(w, st) }},
+	FormatOFX:     {"application/x-ofx", "ofx", WriteOFX},
+	FormatCamt053: {"application/xml", "xml", WriteCamt053},
+}
+
+// IsExportFormat reports whether format is a file format the statement can be rendered in.
+func IsExportFormat(format string) bool {
+	_, ok := exportFormats[format]
+	return ok
+}
+
+// WriteCSV renders one row per entry with the signed amount and running balance.
+func WriteCSV(w io.Writer, st model.Statement) error {
+	cw := csv.NewWriter(w)
+	header := []string{"date", "transaction_id", "reference_id", "type", "amount", "balance", "currency",
+		"description", "counterparty_name", "counterparty_account"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, e := range st.Entries {
+		var counterparty model.Counterparty
+		if e.Counterparty != nil {
+			counterparty = *e.Counterparty
+		}
+
+		err := cw.Write([]string{
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			e.TransactionID,
+			e.ReferenceID,
+			e.Type,
+			formatAmount(e.Movement, st.Currency),
+			formatAmount(e.RunningBalance, st.Currency),
+			st.Currency,
+			e.Description,
+			counterparty.Name,
+			counterparty.Account,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// OFX 2.x elements, in the order the specification requires.
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Response struct {
+			Status   ofxStatus `xml:"STATUS"`
+			DTServer string    `xml:"DTSERVER"`
+			Language string    `xml:"LANGUAGE"`
+		} `xml:"SONRS"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Bank struct {
+		Statement struct {
+			TrnUID   string    `xml:"TRNUID"`
+			Status   ofxStatus `xml:"STATUS"`
+			Response struct {
+				Currency string `xml:"CURDEF"`
+				Account  struct {
+					BankID   string `xml:"BANKID"`
+					AcctID   string `xml:"ACCTID"`
+					AcctType string `xml:"ACCTTYPE"`
+				} `xml:"BANKACCTFROM"`
+				Transactions struct {
+					DTStart string           `xml:"DTSTART"`
+					DTEnd   string           `xml:"DTEND"`
+					Entries []ofxTransaction `xml:"STMTTRN"`
+				} `xml:"BANKTRANLIST"`
+				LedgerBalance struct {
+					Amount string `xml:"BALAMT"`
+					AsOf   string `xml:"DTASOF"`
+				} `xml:"LEDGERBAL"`
+			} `xml:"STMTRS"`
+		} `xml:"STMTTRNRS"`
+	} `xml:"BANKMSGSRSV1"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type     string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	Amount   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	RefNum   string `xml:"REFNUM"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO"`
+}
+
+// ofxNameLength is the longest NAME the OFX specification allows.
+const ofxNameLength = 32
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+	`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+
+// WriteOFX renders an OFX 2.2 bank statement response.
+func WriteOFX(w io.Writer, st model.Statement, exp Export) error {
+	var doc ofxDocument
+	doc.SignOn.Response.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	doc.SignOn.Response.DTServer = ofxTime(exp.GeneratedAt)
+	doc.SignOn.Response.Language = "ENG"
+
+	rs := &doc.Bank.Statement
+	rs.TrnUID = model.DeriveUUID("statement", st.AccountID, st.From.Format(time.DateOnly), st.To.Format(time.DateOnly))
+	rs.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	rs.Response.Currency = st.Currency
+	rs.Response.Account.BankID = exp.BankID
+	rs.Response.Account.AcctID = st.AccountID
+	rs.Response.Account.AcctType = "CHECKING"
+	rs.Response.Transactions.DTStart = ofxTime(st.From)
+	rs.Response.Transactions.DTEnd = ofxTime(periodEnd(st.To))
+
+	for _, e := range st.Entries {
+		entry := ofxTransaction{
+			Type:     ofxTransactionType(e),
+			DTPosted: ofxTime(e.CreatedAt),
+			Amount:   formatAmount(e.Movement, st.Currency),
+			FITID:    e.TransactionID,
+			RefNum:   e.ReferenceID,
+			Memo:     e.Type,
+		}
+		if e.Counterparty != nil {
+			entry.Name = truncate(e.Counterparty.Name, ofxNameLength)
+		}
+		if e.Description != "" {
+			entry.Memo = e.Description
+		}
+		rs.Response.Transactions.Entries = append(rs.Response.Transactions.Entries, entry)
+	}
+
+	rs.Response.LedgerBalance.Amount = formatAmount(st.ClosingBalance, st.Currency)
+	rs.Response.LedgerBalance.AsOf = ofxTime(periodEnd(st.To))
+
+	return writeXML(w, ofxHeader, doc)
+}
+
+func ofxTransactionType(e model.StatementEntry) string {
+	switch {
+	case e.Type == transaction.TransactionTypeFee:
+		return "FEE"
+	case e.Type == transaction.TransactionTypeTransfer:
+		return "XFER"
+	case e.Type == transaction.TransactionTypeInterest:
+		return "INT"
+	case e.Movement.IsNegative():
+		return "DEBIT"
+	default:
+		return "CREDIT"
+	}
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// camt.053.001.02 elements, in the order the schema requires.
+type camtDocument struct {
+	XMLName   xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02 Document"`
+	Statement struct {
+		GroupHeader struct {
+			MsgID   string `xml:"MsgId"`
+			CreDtTm string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Stmt struct {
+			ID      string `xml:"Id"`
+			CreDtTm string `xml:"CreDtTm"`
+			FrToDt  struct {
+				From string `xml:"FrDtTm"`
+				To   string `xml:"ToDtTm"`
+			} `xml:"FrToDt"`
+			Account struct {
+				ID       string `xml:"Id>Othr>Id"`
+				Currency string `xml:"Ccy"`
+			} `xml:"Acct"`
+			Balances []camtBalance `xml:"Bal"`
+			Summary  struct {
+				Entries int        `xml:"TtlNtries>NbOfNtries"`
+				Credits camtTotals `xml:"TtlCdtNtries"`
+				Debits  camtTotals `xml:"TtlDbtNtries"`
+			} `xml:"TxsSummry"`
+			Entries []camtEntry `xml:"Ntry"`
+		} `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+}
+
+type camtTotals struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Ref         string          `xml:"NtryRef"`
+	Amount      camtAmount      `xml:"Amt"`
+	Indicator   string          `xml:"CdtDbtInd"`
+	Status      string          `xml:"Sts"`
+	BookingDate string          `xml:"BookgDt>DtTm"`
+	ValueDate   string          `xml:"ValDt>Dt"`
+	ServicerRef string          `xml:"AcctSvcrRef"`
+	Code        string          `xml:"BkTxCd>Prtry>Cd"`
+	EndToEndID  string          `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	Parties     *camtParties    `xml:"NtryDtls>TxDtls>RltdPties"`
+	Remittance  *camtRemittance `xml:"NtryDtls>TxDtls>RmtInf"`
+}
+
+// camtParties names the counterparty, as the debtor of a credit or the creditor of a debit.
+type camtParties struct {
+	Debtor          *camtParty   `xml:"Dbtr"`
+	DebtorAccount   *camtAccount `xml:"DbtrAcct"`
+	Creditor        *camtParty   `xml:"Cdtr"`
+	CreditorAccount *camtAccount `xml:"CdtrAcct"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm"`
+}
+
+type camtAccount struct {
+	ID string `xml:"Id>Othr>Id"`
+}
+
+type camtRemittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
+
+// WriteCamt053 renders an ISO 20022 camt.053.001.02 bank-to-customer statement. Identifiers are
+// UUIDs without hyphens to fit the schema's 35 character limit.
+func WriteCamt053(w io.Writer, st model.Statement, exp Export) error {
+	var doc camtDocument
+	created := exp.GeneratedAt.UTC().Format(time.RFC3339)
+	from, to := st.From.Format(time.DateOnly), st.To.Format(time.DateOnly)
+
+	doc.Statement.GroupHeader.MsgID = compactID(model.DeriveUUID("statement", st.AccountID, from, to, created))
+	doc.Statement.GroupHeader.CreDtTm = created
+
+	s := &doc.Statement.Stmt
+	s.ID = compactID(model.DeriveUUID("statement", st.AccountID, from, to))
+	s.CreDtTm = created
+	s.FrToDt.From = st.From.UTC().Format(time.RFC3339)
+	s.FrToDt.To = periodEnd(st.To).Format(time.RFC3339)
+	s.Account.ID = compactID(st.AccountID)
+	s.Account.Currency = st.Currency
+	s.Balances = []camtBalance{
+		camtBalanceOf("OPBD", st.OpeningBalance, st.Currency, from),
+		camtBalanceOf("CLBD", st.ClosingBalance, st.Currency, to),
+	}
+
+	s.Summary.Entries = len(st.Entries)
+	s.Summary.Credits.Sum = formatAmount(st.TotalCredits, st.Currency)
+	s.Summary.Debits.Sum = formatAmount(st.TotalDebits, st.Currency)
+
+	for _, e := range st.Entries {
+		if e.Movement.IsNegative() {
+			s.Summary.Debits.Count++
+		} else {
+			s.Summary.Credits.Count++
+		}
+
+		s.Entries = append(s.Entries, camtEntry{
+			Ref:         compactID(e.TransactionID),
+			Amount:      camtAmount{Currency: st.Currency, Value: formatAmount(e.Movement.Abs(), st.Currency)},
+			Indicator:   creditDebit(e.Movement),
+			Status:      "BOOK",
+			BookingDate: e.CreatedAt.UTC().Format(time.RFC3339),
+			ValueDate:   e.ValueDate,
+			ServicerRef: compactID(e.TransactionID),
+			Code:        e.Type,
+			EndToEndID:  compactID(e.ReferenceID),
+			Parties:     camtPartiesOf(e),
+			Remittance:  camtRemittanceOf(e),
+		})
+	}
+
+	return writeXML(w, xml.Header, doc)
+}
+
+func camtPartiesOf(e model.StatementEntry) *camtParties {
+	c := e.Counterparty
+	if c == nil {
+		return nil
+	}
+
+	var party *camtParty
+	if c.Name != "" {
+		party = &camtParty{Name: c.Name}
+	}
+	var account *camtAccount
+	if c.Account != "" {
+		account = &camtAccount{ID: c.Account}
+	}
+
+	if e.Movement.IsNegative() {
+		return &camtParties{Creditor: party, CreditorAccount: account}
+	}
+	return &camtParties{Debtor: party, DebtorAccount: account}
+}
+
+func camtRemittanceOf(e model.StatementEntry) *camtRemittance {
+	if e.Description == "" {
+		return nil
+	}
+	return &camtRemittance{Unstructured: e.Description}
+}
+
+func camtBalanceOf(code string, balance decimal.Decimal, currency, date string) camtBalance {
+	return camtBalance{
+		Type:      code,
+		Amount:    camtAmount{Currency: currency, Value: formatAmount(balance.Abs(), currency)},
+		Indicator: creditDebit(balance),
+		Date:      date,
+	}
+}
+
+func creditDebit(amount decimal.Decimal) string {
+	if amount.IsNegative() {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+func compactID(id string) string {
+	return strings.ReplaceAll(id, "-", "")
+}
+
+func writeXML(w io.Writer, header string, doc interface{}) error {
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// formatAmount writes the amount with exactly the currency's minor units.
+func formatAmount(amount decimal.Decimal, currency string) string {
+	return amount.StringFixedBank(model.CurrencyDecimals(currency))
+}
+
+// periodEnd is the last second of the statement's final day.
+func periodEnd(to time.Time) time.Time {
+	return to.UTC().AddDate(0, 0, 1).Add(-time.Second)
+}
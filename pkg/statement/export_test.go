@@ -0,0 +1,120 @@
+package statement_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/statement"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+var export = statement.Export{
+	GeneratedAt: time.Date(2026, 6, 1, 8, 30, 0, 0, time.UTC),
+	BankID:      "123456789",
+}
+
+func sampleStatement() model.Statement {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(id, txnType, amount, movement, balance, ref, parent string, at time.Duration) model.StatementEntry {
+		return model.StatementEntry{
+			TransactionID:  id,
+			Type:           txnType,
+			Amount:         decimal.RequireFromString(amount),
+			Movement:       decimal.RequireFromString(movement),
+			ReferenceID:    ref,
+			ParentID:       parent,
+			RunningBalance: decimal.RequireFromString(balance),
+			CreatedAt:      day.Add(at),
+			ValueDate:      day.Add(at).Format(time.DateOnly),
+		}
+	}
+
+	deposit := entry("0b9c7a52-3f4e-4f6a-8d2b-5e1c9a7d3b10", "deposit", "1250.5", "1250.5", "1350.5",
+		"a3e1f6c2-7b8d-4e9f-a0b1-c2d3e4f5a6b7", "", 9*time.Hour)
+	deposit.Description = "Invoice 1042 & 1043"
+	deposit.Counterparty = &model.Counterparty{Name: "ACME Manufacturing and Logistics Ltd", Account: "DE89370400440532013000"}
+
+	withdrawal := entry("1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f", "withdrawal", "300", "-300", "1050.5",
+		"b4f2a7d3-8c9e-4fa0-b1c2-d3e4f5a6b7c8", "", 14*24*time.Hour+10*time.Hour)
+	withdrawal.Description = "Rent, May"
+	withdrawal.ValueDate = "2026-05-14" // Back-valued by a day
+
+	return model.Statement{
+		AccountID:      "6f1c1a4e-4d3b-4b8e-9a57-0c1f0c0e5d21",
+		Currency:       "USD",
+		From:           day,
+		To:             time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: decimal.RequireFromString("100"),
+		TotalCredits:   decimal.RequireFromString("1250.5"),
+		TotalDebits:    decimal.RequireFromString("301.5"),
+		ClosingBalance: decimal.RequireFromString("1049"),
+		Entries: []model.StatementEntry{
+			deposit,
+			withdrawal,
+			entry("2d3e4f5a-6b7c-4d8e-9f0a-1b2c3d4e5f6a", "fee", "1.5", "-1.5", "1049",
+				"c5a3b8e4-9daf-40b1-82d3-e4f5a6b7c8d9", "1c2d3e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f", 14*24*time.Hour+10*time.Hour),
+		},
+	}
+}
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, statement.WriteCSV(&buf, sampleStatement()))
+	assertGolden(t, "statement.csv", buf.Bytes())
+}
+
+func TestWriteOFX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, statement.WriteOFX(&buf, sampleStatement(), export))
+	assertGolden(t, "statement.ofx", buf.Bytes())
+}
+
+func TestWriteCamt053(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, statement.WriteCamt053(&buf, sampleStatement(), export))
+	assertGolden(t, "statement.camt053.xml", buf.Bytes())
+}
+
+func TestWriteCSV_CurrencyDecimals(t *testing.T) {
+	st := model.Statement{
+		Currency: "JPY",
+		Entries: []model.StatementEntry{{
+			TransactionID:  "txn1",
+			Type:           "deposit",
+			Movement:       decimal.RequireFromString("1500"),
+			RunningBalance: decimal.RequireFromString("1500"),
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, statement.WriteCSV(&buf, st))
+	assert.Contains(t, buf.String(), ",1500,1500,JPY")
+
+	st.Currency = "BHD"
+	st.Entries[0].Movement = decimal.RequireFromString("1.25")
+	buf.Reset()
+	require.NoError(t, statement.WriteCSV(&buf, st))
+	assert.Contains(t, buf.String(), ",1.250,1500.000,BHD")
+}
@@ -0,0 +1,78 @@
+// Package statement produces account statements over a range of days from the Postgres ledger.
+package statement
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrAccountNotOpen = errors.New("account did not exist at as_of")
+)
+
+type Service interface {
+	GetStatement(ctx context.Context, accountID string, from, to time.Time) (model.Statement, error)
+	GetBalanceAsOf(ctx context.Context, accountID string, asOf time.Time) (model.Balance, error)
+}
+
+type service struct {
+	config config.Config
+	logger *logging.Logger
+	store  Store
+}
+
+func NewService(config config.Config, logger *logging.Logger, database *db.DB) Service {
+	return &service{
+		config: config,
+		logger: logger,
+		store:  NewStore(database),
+	}
+}
+
+// GetStatement returns the statement for the UTC days from through to, inclusive.
+func (s *service) GetStatement(ctx context.Context, accountID string, from, to time.Time) (model.Statement, error) {
+	if to.Before(from) {
+		return model.Statement{}, eError.NewServiceError(
+			errors.New("to is before from"), "to must not be before from", "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+
+	st, err := s.store.Statement(ctx, accountID, from, to)
+	if err != nil {
+		if errors.Is(err, transaction.ErrAccountNotFound) {
+			return model.Statement{}, eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+		}
+		s.logger.Error("failed to build statement", "account_id", accountID, "error", err)
+		return model.Statement{}, err
+	}
+
+	return st, nil
+}
+
+// GetBalanceAsOf returns the balance at the end of the second asOf falls in: every entry created
+// before the following second is included, however close together entries were committed.
+func (s *service) GetBalanceAsOf(ctx context.Context, accountID string, asOf time.Time) (model.Balance, error) {
+	asOf = asOf.UTC().Truncate(time.Second)
+
+	b, err := s.store.BalanceBefore(ctx, accountID, asOf.Add(time.Second))
+	switch {
+	case err == nil:
+	case errors.Is(err, transaction.ErrAccountNotFound):
+		return model.Balance{}, eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrAccountNotOpen):
+		return model.Balance{}, eError.NewServiceError(err, "account did not exist at as_of", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+	default:
+		s.logger.Error("failed to read balance", "account_id", accountID, "as_of", asOf, "error", err)
+		return model.Balance{}, err
+	}
+
+	b.AsOf = asOf
+	return b, nil
+}
@@ -0,0 +1,158 @@
+package statement
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"time"
+)
+
+type Store interface {
+	Statement(ctx context.Context, accountID string, from, to time.Time) (model.Statement, error)
+	BalanceBefore(ctx context.Context, accountID string, cutoff time.Time) (model.Balance, error)
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+// Statement reads the account's balance and transactions from one snapshot. The opening balance
+// is the current balance less every movement since the start of from, and each entry's running
+// balance is carried forward from it, so the statement reconciles by construction.
+func (s *store) Statement(ctx context.Context, accountID string, from, to time.Time) (model.Statement, error) {
+	start, end := from, to.AddDate(0, 0, 1)
+
+	tx, err := s.db.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return model.Statement{}, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	st := model.Statement{AccountID: accountID, From: from, To: to, Entries: []model.StatementEntry{}}
+	err = tx.QueryRowContext(ctx,
+		`SELECT a.currency, a.balance - COALESCE((
+			SELECT SUM(`+transaction.SignedAmountSQL+`) FROM transactions t
+			WHERE t.account_id = a.id AND t.status = 'completed' AND t.created_at >= $2
+		), 0)
+		FROM accounts a WHERE a.id = $1`,
+		accountID, start,
+	).Scan(&st.Currency, &st.OpeningBalance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Statement{}, transaction.ErrAccountNotFound
+		}
+		return model.Statement{}, errors.Wrap(err, "failed to read opening balance")
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, sequence, type, amount, `+transaction.SignedAmountSQL+`, reference_id, COALESCE(parent_id::text, ''),
+			description, counterparty_name, counterparty_account, created_at, to_char(value_date, 'YYYY-MM-DD')
+		FROM transactions
+		WHERE account_id = $1 AND status = 'completed' AND created_at >= $2 AND created_at < $3
+		ORDER BY sequence`,
+		accountID, start, end,
+	)
+	if err != nil {
+		return model.Statement{}, errors.Wrap(err, "failed to read statement entries")
+	}
+	defer rows.Close()
+
+	balance := st.OpeningBalance
+	st.TotalCredits, st.TotalDebits = decimal.Zero, decimal.Zero
+	for rows.Next() {
+		var e model.StatementEntry
+		var counterparty model.Counterparty
+		err := rows.Scan(&e.TransactionID, &e.Sequence, &e.Type, &e.Amount, &e.Movement, &e.ReferenceID, &e.ParentID,
+			&e.Description, &counterparty.Name, &counterparty.Account, &e.CreatedAt, &e.ValueDate)
+		if err != nil {
+			return model.Statement{}, errors.Wrap(err, "failed to scan statement entry")
+		}
+		if counterparty != (model.Counterparty{}) {
+			e.Counterparty = &counterparty
+		}
+
+		balance = balance.Add(e.Movement)
+		e.RunningBalance = balance
+		if e.Movement.IsNegative() {
+			st.TotalDebits = st.TotalDebits.Sub(e.Movement)
+		} else {
+			st.TotalCredits = st.TotalCredits.Add(e.Movement)
+		}
+		st.Entries = append(st.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return model.Statement{}, errors.Wrap(err, "failed to read statement entries")
+	}
+
+	st.ClosingBalance = balance
+	return st, nil
+}
+
+// BalanceBefore returns the balance left by the account's last entry, in sequence order, created
+// before cutoff. Before the first entry it is that entry's balance_after less its movement, and
+// an account without entries has had its current balance throughout.
+func (s *store) BalanceBefore(ctx context.Context, accountID string, cutoff time.Time) (model.Balance, error) {
+	tx, err := s.db.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return model.Balance{}, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	b := model.Balance{AccountID: accountID}
+	var openedAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`SELECT currency, balance, created_at FROM accounts WHERE id = $1`, accountID,
+	).Scan(&b.Currency, &b.Balance, &openedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Balance{}, transaction.ErrAccountNotFound
+		}
+		return model.Balance{}, errors.Wrap(err, "failed to get account")
+	}
+	if !openedAt.Before(cutoff) {
+		return model.Balance{}, ErrAccountNotOpen
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT sequence, balance_after FROM transactions
+		WHERE account_id = $1 AND status = 'completed' AND created_at < $2
+		ORDER BY sequence DESC
+		LIMIT 1`,
+		accountID, cutoff,
+	).Scan(&b.Sequence, &b.Balance)
+	if err == nil {
+		return b, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.Balance{}, errors.Wrap(err, "failed to read balance")
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT balance_after - (`+transaction.SignedAmountSQL+`) FROM transactions
+		WHERE account_id = $1 AND status = 'completed'
+		ORDER BY sequence
+		LIMIT 1`,
+		accountID,
+	).Scan(&b.Balance)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.Balance{}, errors.Wrap(err, "failed to read opening balance")
+	}
+	return b, nil
+}
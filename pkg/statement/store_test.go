@@ -0,0 +1,153 @@
+package statement_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/statement"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Statement(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := statement.NewStore(&db.DB{DB: sqlDB})
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+
+	// Current balance less everything since the start of the range
+	mock.ExpectQuery(`SELECT a.currency, a.balance - COALESCE`).
+		WithArgs("acc1", from).
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "opening"}).AddRow("USD", "100"))
+
+	mock.ExpectQuery(`SELECT id, sequence, type, amount, .* FROM transactions .* ORDER BY sequence`).
+		WithArgs("acc1", from, to.AddDate(0, 0, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sequence", "type", "amount", "movement", "reference_id", "parent_id", "description", "counterparty_name", "counterparty_account", "created_at", "value_date"}).
+			AddRow("txn1", 4, "deposit", "50", "50", "ref1", "", "Invoice 1042", "ACME Ltd", "", from.Add(time.Hour), "2026-05-01").
+			AddRow("txn2", 5, "withdrawal", "30", "-30", "ref2", "", "", "", "", from.Add(2*time.Hour), "2026-04-30").
+			AddRow("txn3", 6, "fee", "1.5", "-1.5", "ref3", "txn2", "", "", "", from.Add(2*time.Hour), "2026-04-30"))
+
+	mock.ExpectRollback()
+
+	st, err := store.Statement(context.Background(), "acc1", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", st.Currency)
+	assert.Equal(t, "100", st.OpeningBalance.String())
+	assert.Equal(t, "50", st.TotalCredits.String())
+	assert.Equal(t, "31.5", st.TotalDebits.String())
+	assert.Equal(t, "118.5", st.ClosingBalance.String())
+
+	assert.Len(t, st.Entries, 3)
+	assert.Equal(t, "150", st.Entries[0].RunningBalance.String())
+	assert.Equal(t, "120", st.Entries[1].RunningBalance.String())
+	assert.Equal(t, "118.5", st.Entries[2].RunningBalance.String())
+	assert.Equal(t, "txn2", st.Entries[2].ParentID)
+	assert.Equal(t, "Invoice 1042", st.Entries[0].Description)
+	assert.Equal(t, &model.Counterparty{Name: "ACME Ltd"}, st.Entries[0].Counterparty)
+	assert.Nil(t, st.Entries[1].Counterparty)
+	assert.Equal(t, "2026-04-30", st.Entries[1].ValueDate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Statement_AccountNotFound(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := statement.NewStore(&db.DB{DB: sqlDB})
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT a.currency`).
+		WithArgs("acc1", day).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = store.Statement(context.Background(), "acc1", day, day)
+	assert.ErrorIs(t, err, transaction.ErrAccountNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_BalanceBefore(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := statement.NewStore(&db.DB{DB: sqlDB})
+	cutoff := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currency, balance, created_at FROM accounts WHERE id = \$1`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance", "created_at"}).AddRow("USD", "900", cutoff.AddDate(0, -3, 0)))
+
+	// The last entry in sequence order created before the cutoff
+	mock.ExpectQuery(`SELECT sequence, balance_after FROM transactions .* ORDER BY sequence DESC LIMIT 1`).
+		WithArgs("acc1", cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence", "balance_after"}).AddRow(12, "640.25"))
+	mock.ExpectRollback()
+
+	b, err := store.BalanceBefore(context.Background(), "acc1", cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), b.Sequence)
+	assert.Equal(t, "640.25", b.Balance.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_BalanceBefore_FirstEntry(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := statement.NewStore(&db.DB{DB: sqlDB})
+	cutoff := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currency, balance, created_at FROM accounts`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance", "created_at"}).AddRow("USD", "900", cutoff.AddDate(0, -3, 0)))
+	mock.ExpectQuery(`SELECT sequence, balance_after FROM transactions`).
+		WithArgs("acc1", cutoff).
+		WillReturnError(sql.ErrNoRows)
+
+	// Opening balance from before the first entry
+	mock.ExpectQuery(`SELECT balance_after - \(.*\) FROM transactions .* ORDER BY sequence LIMIT 1`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"opening"}).AddRow("500"))
+	mock.ExpectRollback()
+
+	b, err := store.BalanceBefore(context.Background(), "acc1", cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), b.Sequence)
+	assert.Equal(t, "500", b.Balance.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_BalanceBefore_AccountNotOpen(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := statement.NewStore(&db.DB{DB: sqlDB})
+	cutoff := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currency, balance, created_at FROM accounts`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance", "created_at"}).AddRow("USD", "900", cutoff))
+	mock.ExpectRollback()
+
+	_, err = store.BalanceBefore(context.Background(), "acc1", cutoff)
+	assert.ErrorIs(t, err, statement.ErrAccountNotOpen)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
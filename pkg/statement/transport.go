@@ -0,0 +1,48 @@
+package statement
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service, conf config.Config) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	statementHandler := kithttp.NewServer(
+		makeStatementEndpoint(ms, conf),
+		decodeStatementRequest,
+		encodeStatementResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("GET", "/accounts/{id}/statement", statementHandler)
+
+	// Routed ahead of the transaction handler's /accounts/* catch-all
+	return http.Endpoint{Pattern: "/accounts/{id}/statement", Handler: r}
+}
+
+func MakeBalanceHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	balanceHandler := kithttp.NewServer(
+		makeBalanceEndpoint(ms),
+		decodeBalanceRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("GET", "/accounts/{id}/balance", balanceHandler)
+
+	return http.Endpoint{Pattern: "/accounts/{id}/balance", Handler: r}
+}
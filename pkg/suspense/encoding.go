@@ -0,0 +1,149 @@
+package suspense
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Queue page sizes.
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// MaxNoteLength is the longest note an operator can leave on a resolution.
+const MaxNoteLength = 140
+
+type RerouteRequest struct {
+	ItemID    string `json:"-"`
+	AccountID string `json:"account_id"`
+	Note      string `json:"note"`
+}
+
+type ReturnRequest struct {
+	ItemID string `json:"-"`
+	Note   string `json:"note"`
+}
+
+// decodeListItemsRequest reads status, currency, limit and after, the id of the last item of the
+// previous page.
+func decodeListItemsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	filter := ListFilter{
+		Status:   model.SuspenseStatus(strings.ToLower(query.Get("status"))),
+		Currency: strings.ToUpper(query.Get("currency")),
+		After:    query.Get("after"),
+		Limit:    DefaultListLimit,
+	}
+
+	switch filter.Status {
+	case "", model.SuspenseStatusOpen, model.SuspenseStatusRerouted, model.SuspenseStatusReturned:
+	default:
+		return nil, eError.NewServiceError(
+			errors.New("invalid status"), "status must be open, rerouted or returned", "INVALID_STATUS", http.StatusBadRequest)
+	}
+
+	if filter.Currency != "" && !model.IsKnownCurrency(filter.Currency) {
+		return nil, eError.NewServiceError(
+			errors.Errorf("unknown currency %q", filter.Currency), "currency must be a supported ISO 4217 code", "MISSING_CURRENCY", http.StatusBadRequest)
+	}
+
+	if filter.After != "" && !model.IsValidUUID(filter.After) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid suspense item id"), "after must be a valid UUID", "INVALID_SUSPENSE_ITEM_ID", http.StatusBadRequest)
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxListLimit {
+			return nil, eError.NewServiceError(
+				errors.New("invalid limit"), fmt.Sprintf("limit must be an integer between 1 and %d", MaxListLimit), "INVALID_LIMIT", http.StatusBadRequest)
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+func decodeItemIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return itemID(r)
+}
+
+func decodeRerouteRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req RerouteRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	id, err := itemID(r)
+	if err != nil {
+		return nil, err
+	}
+	req.ItemID = id
+
+	if !model.IsValidUUID(req.AccountID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid account id"), "account_id must be a valid UUID", "INVALID_ACCOUNT_ID", http.StatusBadRequest)
+	}
+	if err := validateNote(req.Note); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func decodeReturnRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req ReturnRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	id, err := itemID(r)
+	if err != nil {
+		return nil, err
+	}
+	req.ItemID = id
+
+	if err := validateNote(req.Note); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func decodeBody(r *http.Request, req interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(req); err != nil {
+		slog.Error("decode suspense request", "err", err)
+		return eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+	return nil
+}
+
+func itemID(r *http.Request) (string, error) {
+	id := chi.URLParam(r, "id")
+	if !model.IsValidUUID(id) {
+		return "", eError.NewServiceError(
+			errors.New("invalid suspense item id"), "suspense item id must be a valid UUID", "INVALID_SUSPENSE_ITEM_ID", http.StatusBadRequest)
+	}
+	return id, nil
+}
+
+func validateNote(note string) error {
+	if len([]rune(note)) > MaxNoteLength {
+		return eError.NewServiceError(
+			errors.New("note too long"), fmt.Sprintf("note must not exceed %d characters", MaxNoteLength), "INVALID_NOTE", http.StatusBadRequest)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package suspense
+
+import (
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidRequestType = errors.New("invalid request type")
+
+type ListItemsResponse struct {
+	Items []model.SuspenseItem `json:"items"`
+	Next  string               `json:"next,omitempty"` // Pass as after to get the next page
+}
+
+func makeListItemsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		filter, ok := request.(ListFilter)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		items, err := s.ListItems(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := ListItemsResponse{Items: items}
+		// A full page may be followed by more items
+		if len(items) > 0 && len(items) == filter.Limit {
+			resp.Next = items[len(items)-1].ID
+		}
+		return resp, nil
+	}
+}
+
+func makeGetItemEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		id, ok := request.(string)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.GetItem(ctx, id)
+	}
+}
+
+func makeRerouteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(RerouteRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.Reroute(ctx, req.ItemID, req.AccountID, req.Note)
+	}
+}
+
+func makeReturnEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(ReturnRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+
+		return s.Return(ctx, req.ItemID, req.Note)
+	}
+}
@@ -0,0 +1,116 @@
+// Package suspense works the queue of deposits held in suspense.
+//
+// A deposit sent to an account that doesn't exist or is closed is credited to the suspense
+// account in its currency, from the chart of accounts, and an open suspense item records the
+// account it was meant for. An operator then either reroutes the item to the right account or
+// returns it to the payer. Both post a withdrawal from the suspense account linked to the
+// suspended deposit; a reroute also posts a deposit to the account linked to that withdrawal.
+package suspense
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/logging"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type Service interface {
+	ListItems(ctx context.Context, filter ListFilter) ([]model.SuspenseItem, error)
+	GetItem(ctx context.Context, id string) (*model.SuspenseItem, error)
+	Reroute(ctx context.Context, id, accountID, note string) (*model.SuspenseItem, error)
+	Return(ctx context.Context, id, note string) (*model.SuspenseItem, error)
+}
+
+type service struct {
+	config config.Config
+	logger *logging.Logger
+	store  Store
+}
+
+func NewService(config config.Config, logger *logging.Logger, database *db.DB) Service {
+	return &service{
+		config: config,
+		logger: logger,
+		store:  NewStore(database),
+	}
+}
+
+func (s *service) ListItems(ctx context.Context, filter ListFilter) ([]model.SuspenseItem, error) {
+	items, err := s.store.List(ctx, filter)
+	if err != nil {
+		s.logger.Error("failed to list suspense items", "error", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *service) GetItem(ctx context.Context, id string) (*model.SuspenseItem, error) {
+	item, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrItemNotFound) {
+			return nil, eError.NewServiceError(err, "suspense item not found", "SUSPENSE_ITEM_NOT_FOUND", http.StatusNotFound)
+		}
+		s.logger.Error("failed to get suspense item", "suspense_item_id", id, "error", err)
+		return nil, err
+	}
+	return item, nil
+}
+
+// Reroute books an open item to the account it should have been deposited to.
+func (s *service) Reroute(ctx context.Context, id, accountID, note string) (*model.SuspenseItem, error) {
+	item, err := s.store.Reroute(ctx, id, accountID, note)
+	if err != nil {
+		if serviceErr := resolveError(err); serviceErr != nil {
+			return nil, serviceErr
+		}
+		s.logger.Error("failed to reroute suspense item", "suspense_item_id", id, "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("suspense item rerouted", "suspense_item_id", id, "account_id", accountID, "amount", item.Amount, "currency", item.Currency)
+	return item, nil
+}
+
+// Return sends an open item back to the payer.
+func (s *service) Return(ctx context.Context, id, note string) (*model.SuspenseItem, error) {
+	item, err := s.store.Return(ctx, id, note)
+	if err != nil {
+		if serviceErr := resolveError(err); serviceErr != nil {
+			return nil, serviceErr
+		}
+		s.logger.Error("failed to return suspense item", "suspense_item_id", id, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("suspense item returned", "suspense_item_id", id, "amount", item.Amount, "currency", item.Currency)
+	return item, nil
+}
+
+// resolveError maps the errors of rerouting or returning an item to API errors, nil for
+// unexpected ones.
+func resolveError(err error) error {
+	switch {
+	case errors.Is(err, ErrItemNotFound):
+		return eError.NewServiceError(err, "suspense item not found", "SUSPENSE_ITEM_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, ErrItemResolved):
+		return eError.NewServiceError(err, "suspense item is already resolved", "SUSPENSE_ITEM_RESOLVED", http.StatusConflict)
+	case errors.Is(err, ErrInvalidTarget):
+		return eError.NewServiceError(err, "item can't be rerouted to the suspense account", "INVALID_TARGET", http.StatusBadRequest)
+	case errors.Is(err, transaction.ErrAccountNotFound):
+		return eError.NewServiceError(err, "account not found", "ACCOUNT_NOT_FOUND", http.StatusNotFound)
+	case errors.Is(err, transaction.ErrAccountNotActive):
+		return eError.NewServiceError(err, "account is not active", "ACCOUNT_NOT_ACTIVE", http.StatusConflict)
+	case errors.Is(err, transaction.ErrCurrencyMismatch):
+		return eError.NewServiceError(err, "account currency does not match the item", "CURRENCY_MISMATCH", http.StatusUnprocessableEntity)
+	case errors.Is(err, transaction.ErrInsufficientFunds):
+		return eError.NewServiceError(err, "insufficient funds in the suspense account", "INSUFFICIENT_FUNDS", http.StatusConflict)
+	case errors.Is(err, transaction.ErrPeriodClosed):
+		return eError.NewServiceError(err, "the resolution falls in a closed period", "PERIOD_CLOSED", http.StatusConflict)
+	}
+	return nil
+}
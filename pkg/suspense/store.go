@@ -0,0 +1,284 @@
+package suspense
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrItemNotFound  = errors.New("suspense item not found")
+	ErrItemResolved  = errors.New("suspense item already resolved")
+	ErrInvalidTarget = errors.New("suspense item can't be rerouted to the suspense account")
+)
+
+type Store interface {
+	List(ctx context.Context, filter ListFilter) ([]model.SuspenseItem, error)
+	Get(ctx context.Context, id string) (*model.SuspenseItem, error)
+	Reroute(ctx context.Context, id, accountID, note string) (*model.SuspenseItem, error)
+	Return(ctx context.Context, id, note string) (*model.SuspenseItem, error)
+}
+
+// ListFilter narrows the suspense queue, oldest item first. After is the id of the last item of
+// the previous page.
+type ListFilter struct {
+	Status   model.SuspenseStatus
+	Currency string
+	After    string
+	Limit    int
+}
+
+type store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *store {
+	return &store{db: db}
+}
+
+const itemColumns = `id, transaction_id, suspense_account_id, intended_account_id, amount, currency, reason, status,
+	COALESCE(resolved_account_id::text, ''), COALESCE(resolution_transaction_id::text, ''), note, created_at, resolved_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanItem(row scanner, extra ...interface{}) (*model.SuspenseItem, error) {
+	var item model.SuspenseItem
+	var resolvedAt sql.NullTime
+	dest := []interface{}{&item.ID, &item.TransactionID, &item.SuspenseAccountID, &item.IntendedAccountID, &item.Amount,
+		&item.Currency, &item.Reason, &item.Status, &item.ResolvedAccountID, &item.ResolutionTransactionID, &item.Note,
+		&item.CreatedAt, &resolvedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		item.ResolvedAt = &resolvedAt.Time
+	}
+	return &item, nil
+}
+
+func (s *store) List(ctx context.Context, filter ListFilter) ([]model.SuspenseItem, error) {
+	query := `SELECT ` + itemColumns + ` FROM suspense_items WHERE TRUE`
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	if filter.Currency != "" {
+		where("currency = $%d", filter.Currency)
+	}
+	if filter.After != "" {
+		where("(created_at, id) > (SELECT created_at, id FROM suspense_items WHERE id = $%d)", filter.After)
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	rows, err := s.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list suspense items")
+	}
+	defer rows.Close()
+
+	items := []model.SuspenseItem{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan suspense item")
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (s *store) Get(ctx context.Context, id string) (*model.SuspenseItem, error) {
+	item, err := scanItem(s.db.DB.QueryRowContext(ctx, `SELECT `+itemColumns+` FROM suspense_items WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get suspense item")
+	}
+	return item, nil
+}
+
+// Reroute books an open item to the account it should have gone to: a withdrawal from the
+// suspense account linked to the suspended deposit, and a deposit to the account linked to the
+// withdrawal carrying the original description and counterparty.
+func (s *store) Reroute(ctx context.Context, id, accountID, note string) (*model.SuspenseItem, error) {
+	return s.resolve(ctx, id, accountID, note)
+}
+
+// Return sends an open item back to the payer as a withdrawal from the suspense account linked
+// to the suspended deposit.
+func (s *store) Return(ctx context.Context, id, note string) (*model.SuspenseItem, error) {
+	return s.resolve(ctx, id, "", note)
+}
+
+// resolve closes an open item within one SQL transaction, rerouting it to accountID or returning
+// it when accountID is empty. The item and the accounts are locked, in id order, so an item is
+// only ever resolved once.
+func (s *store) resolve(ctx context.Context, id, accountID, note string) (*model.SuspenseItem, error) {
+	tx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	var deposit transaction.Entry
+	var counterparty model.Counterparty
+	item, err := scanItem(tx.QueryRowContext(ctx,
+		`SELECT `+itemColumns+`, t.reference_id, t.description, t.counterparty_name, t.counterparty_account
+		FROM suspense_items JOIN transactions t ON t.id = transaction_id
+		WHERE suspense_items.id = $1 FOR UPDATE OF suspense_items`, id,
+	), &deposit.ReferenceID, &deposit.Description, &counterparty.Name, &counterparty.Account)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get suspense item")
+	}
+
+	if item.Status != model.SuspenseStatusOpen {
+		return nil, ErrItemResolved
+	}
+	if accountID == item.SuspenseAccountID {
+		return nil, ErrInvalidTarget
+	}
+
+	// The ledger period is locked before the accounts, like the processor does
+	now := time.Now().UTC()
+	valueDate, err := transaction.BookingDate(ctx, tx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := lockAccounts(ctx, tx, item.SuspenseAccountID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	suspenseAccount, ok := accounts[item.SuspenseAccountID]
+	if !ok {
+		return nil, transaction.ErrAccountNotFound
+	}
+	suspenseBalance := suspenseAccount.Balance.Sub(item.Amount)
+	if suspenseBalance.IsNegative() && !suspenseAccount.Type.AllowsNegative() {
+		return nil, transaction.ErrInsufficientFunds
+	}
+
+	withdrawal := transaction.Entry{
+		ID:           model.DeriveUUID(item.ID, "resolution"),
+		AccountID:    item.SuspenseAccountID,
+		Type:         transaction.TransactionTypeWithdrawal,
+		Amount:       item.Amount,
+		Currency:     item.Currency,
+		ReferenceID:  model.DeriveUUID(deposit.ReferenceID, "resolution"),
+		ParentID:     item.TransactionID,
+		BalanceAfter: suspenseBalance,
+		Description:  note,
+		ValueDate:    valueDate,
+		CreatedAt:    now,
+	}
+	legs := []transaction.Entry{withdrawal}
+
+	item.Status = model.SuspenseStatusReturned
+	if accountID != "" {
+		target, ok := accounts[accountID]
+		if !ok {
+			return nil, transaction.ErrAccountNotFound
+		}
+		if target.Status != model.AccountStatusActive {
+			return nil, transaction.ErrAccountNotActive
+		}
+		if target.Currency != item.Currency {
+			return nil, transaction.ErrCurrencyMismatch
+		}
+
+		legs = append(legs, transaction.Entry{
+			ID:           model.DeriveUUID(item.ID, "reroute"),
+			AccountID:    accountID,
+			Type:         transaction.TransactionTypeDeposit,
+			Amount:       item.Amount,
+			Currency:     item.Currency,
+			ReferenceID:  model.DeriveUUID(deposit.ReferenceID, "reroute"),
+			ParentID:     withdrawal.ID,
+			BalanceAfter: target.Balance.Add(item.Amount),
+			Description:  deposit.Description,
+			Counterparty: &counterparty,
+			ValueDate:    valueDate,
+			CreatedAt:    now,
+		})
+		item.Status = model.SuspenseStatusRerouted
+		item.ResolvedAccountID = accountID
+	}
+
+	for _, leg := range legs {
+		_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, leg.BalanceAfter, leg.AccountID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to update account balance")
+		}
+		if _, err := transaction.InsertEntry(ctx, tx, leg); err != nil {
+			return nil, errors.Wrap(err, "failed to create transaction record")
+		}
+	}
+
+	item.ResolutionTransactionID = withdrawal.ID
+	item.Note = note
+	item.ResolvedAt = &now
+	_, err = tx.ExecContext(ctx,
+		`UPDATE suspense_items SET status = $1, resolved_account_id = NULLIF($2, '')::uuid, resolution_transaction_id = $3, note = $4, resolved_at = $5
+		WHERE id = $6`,
+		item.Status, item.ResolvedAccountID, item.ResolutionTransactionID, item.Note, now, item.ID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve suspense item")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "transaction commit failed")
+	}
+	return item, nil
+}
+
+// lockAccounts locks the suspense account and the target account, if any, in id order to avoid
+// deadlocks with transactions on the same accounts, and returns those found by id.
+func lockAccounts(ctx context.Context, tx *sql.Tx, suspenseAccountID, accountID string) (map[string]model.Account, error) {
+	if accountID == "" {
+		accountID = suspenseAccountID
+	}
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, balance, currency, status, type FROM accounts WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`,
+		suspenseAccountID, accountID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock accounts")
+	}
+	defer rows.Close()
+
+	accounts := map[string]model.Account{}
+	for rows.Next() {
+		var a model.Account
+		if err := rows.Scan(&a.ID, &a.Balance, &a.Currency, &a.Status, &a.Type); err != nil {
+			return nil, errors.Wrap(err, "failed to scan account")
+		}
+		accounts[a.ID] = a
+	}
+	return accounts, rows.Err()
+}
@@ -0,0 +1,154 @@
+package suspense_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/suspense"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var itemColumns = []string{"id", "transaction_id", "suspense_account_id", "intended_account_id", "amount", "currency", "reason", "status",
+	"resolved_account_id", "resolution_transaction_id", "note", "created_at", "resolved_at",
+	"reference_id", "description", "counterparty_name", "counterparty_account"}
+
+func expectItem(mock sqlmock.Sqlmock, status model.SuspenseStatus) {
+	mock.ExpectQuery(`SELECT .* FROM suspense_items JOIN transactions t ON t.id = transaction_id\s+WHERE suspense_items.id = \$1 FOR UPDATE OF suspense_items`).
+		WithArgs("item1").
+		WillReturnRows(sqlmock.NewRows(itemColumns).
+			AddRow("item1", "txn1", "suspense", "missing", "25", "USD", model.SuspenseReasonAccountNotFound, status,
+				"", "", "", time.Now(), nil, "ref1", "rent", "Jane Doe", "DE89370400440532013000"))
+}
+
+// expectPeriod expects the ledger period to be locked, closed through the given date or open.
+func expectPeriod(mock sqlmock.Sqlmock, closedThrough interface{}) {
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(closedThrough))
+}
+
+func TestStore_Reroute(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := suspense.NewStore(&db.DB{DB: sqlDB})
+	withdrawalID := model.DeriveUUID("item1", "resolution")
+
+	mock.ExpectBegin()
+	expectItem(mock, model.SuspenseStatusOpen)
+	// Both legs are booked today, the first open day
+	today := time.Now().UTC()
+	expectPeriod(mock, today.AddDate(0, 0, -1))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("suspense", "acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "type"}).
+			AddRow("acc1", "10", "USD", model.AccountStatusActive, model.AccountTypeLiability).
+			AddRow("suspense", "25", "USD", model.AccountStatusActive, model.AccountTypeLiability))
+
+	// The withdrawal from suspense is linked to the suspended deposit, the deposit to the withdrawal
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.Zero, "suspense").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(withdrawalID, "suspense", decimal.NewFromInt(25), transaction.TransactionTypeWithdrawal, model.DeriveUUID("ref1", "resolution"),
+			"USD", transaction.TransactionStatusCompleted, "txn1", decimal.Zero, sqlmock.AnyArg(), nil, nil, nil, "wrong account number", "", "", "{}", today.Format(time.DateOnly)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(3))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(35), "acc1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(model.DeriveUUID("item1", "reroute"), "acc1", decimal.NewFromInt(25), transaction.TransactionTypeDeposit, model.DeriveUUID("ref1", "reroute"),
+			"USD", transaction.TransactionStatusCompleted, withdrawalID, decimal.NewFromInt(35), sqlmock.AnyArg(), nil, nil, nil, "rent", "Jane Doe", "DE89370400440532013000", "{}", today.Format(time.DateOnly)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(8))
+
+	mock.ExpectExec(`UPDATE suspense_items SET status = \$1`).
+		WithArgs(model.SuspenseStatusRerouted, "acc1", withdrawalID, "wrong account number", sqlmock.AnyArg(), "item1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	item, err := store.Reroute(context.Background(), "item1", "acc1", "wrong account number")
+	assert.NoError(t, err)
+	assert.Equal(t, model.SuspenseStatusRerouted, item.Status)
+	assert.Equal(t, "acc1", item.ResolvedAccountID)
+	assert.Equal(t, withdrawalID, item.ResolutionTransactionID)
+	assert.NotNil(t, item.ResolvedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Reroute_TargetNotActive(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := suspense.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectBegin()
+	expectItem(mock, model.SuspenseStatusOpen)
+	expectPeriod(mock, nil)
+	mock.ExpectQuery(`SELECT id, balance, currency, status, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("suspense", "acc1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "type"}).
+			AddRow("acc1", "10", "USD", model.AccountStatusClosed, model.AccountTypeLiability).
+			AddRow("suspense", "25", "USD", model.AccountStatusActive, model.AccountTypeLiability))
+	mock.ExpectRollback()
+
+	_, err = store.Reroute(context.Background(), "item1", "acc1", "")
+	assert.ErrorIs(t, err, transaction.ErrAccountNotActive)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Return(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := suspense.NewStore(&db.DB{DB: sqlDB})
+	withdrawalID := model.DeriveUUID("item1", "resolution")
+
+	mock.ExpectBegin()
+	expectItem(mock, model.SuspenseStatusOpen)
+	expectPeriod(mock, nil)
+	mock.ExpectQuery(`SELECT id, balance, currency, status, type FROM accounts WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("suspense", "suspense").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "type"}).
+			AddRow("suspense", "40", "USD", model.AccountStatusActive, model.AccountTypeLiability))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(15), "suspense").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(withdrawalID, "suspense", decimal.NewFromInt(25), transaction.TransactionTypeWithdrawal, model.DeriveUUID("ref1", "resolution"),
+			"USD", transaction.TransactionStatusCompleted, "txn1", decimal.NewFromInt(15), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(3))
+	mock.ExpectExec(`UPDATE suspense_items SET status = \$1`).
+		WithArgs(model.SuspenseStatusReturned, "", withdrawalID, "", sqlmock.AnyArg(), "item1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	item, err := store.Return(context.Background(), "item1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, model.SuspenseStatusReturned, item.Status)
+	assert.Empty(t, item.ResolvedAccountID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Return_AlreadyResolved(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := suspense.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectBegin()
+	expectItem(mock, model.SuspenseStatusRerouted)
+	mock.ExpectRollback()
+
+	_, err = store.Return(context.Background(), "item1", "")
+	assert.ErrorIs(t, err, suspense.ErrItemResolved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
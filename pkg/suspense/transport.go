@@ -0,0 +1,51 @@
+package suspense
+
+import (
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/mdshahjahanmiah/explore-go/http"
+)
+
+func MakeHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	listHandler := kithttp.NewServer(
+		makeListItemsEndpoint(ms),
+		decodeListItemsRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	getHandler := kithttp.NewServer(
+		makeGetItemEndpoint(ms),
+		decodeItemIDRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	rerouteHandler := kithttp.NewServer(
+		makeRerouteEndpoint(ms),
+		decodeRerouteRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	returnHandler := kithttp.NewServer(
+		makeReturnEndpoint(ms),
+		decodeReturnRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("GET", "/suspense", listHandler)
+	r.Method("GET", "/suspense/{id}", getHandler)
+	r.Method("POST", "/suspense/{id}/reroute", rerouteHandler)
+	r.Method("POST", "/suspense/{id}/return", returnHandler)
+
+	return http.Endpoint{Pattern: "/suspense*", Handler: r}
+}
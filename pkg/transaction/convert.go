@@ -0,0 +1,46 @@
+package transaction
+
+import (
+	"context"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// RateSource returns the mid-market rate for converting one unit of from into to.
+// fx.RateProvider satisfies it.
+type RateSource interface {
+	Rate(ctx context.Context, from, to string) (decimal.Decimal, error)
+}
+
+// convert returns txn in the account's currency. A transaction already in that currency is
+// returned unchanged. Deposits and withdrawals on an account that opted into auto-conversion are
+// converted at the mid rate and rounded half-even to the account currency's minor units; anything
+// else in another currency is a mismatch.
+func convert(ctx context.Context, rates RateSource, account model.Account, txn model.Transaction) (model.Transaction, error) {
+	if txn.Currency == account.Currency {
+		return txn, nil
+	}
+	if !account.AutoConvert || txn.Type == TransactionTypeTransfer || rates == nil {
+		return model.Transaction{}, ErrCurrencyMismatch
+	}
+
+	rate, err := rates.Rate(ctx, txn.Currency, account.Currency)
+	if err != nil {
+		return model.Transaction{}, errors.Wrapf(ErrRateUnavailable, "%s/%s: %v", txn.Currency, account.Currency, err)
+	}
+
+	amount := txn.Amount.Mul(rate).RoundBank(model.CurrencyDecimals(account.Currency))
+	if !amount.IsPositive() {
+		return model.Transaction{}, ErrInvalidAmount
+	}
+
+	txn.Conversion = &model.Conversion{
+		OriginalAmount:   txn.Amount,
+		OriginalCurrency: txn.Currency,
+		Rate:             model.Decimal{Decimal: rate},
+	}
+	txn.Amount = model.Decimal{Decimal: amount}
+	txn.Currency = account.Currency
+	return txn, nil
+}
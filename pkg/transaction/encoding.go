@@ -3,22 +3,28 @@ package transaction
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/go-chi/chi/v5"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
 	eError "github.com/mdshahjahanmiah/explore-go/error"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type TransactionRequest struct {
-	AccountID   string  `json:"account_id"`
-	Amount      float64 `json:"amount"`
-	Currency    string  `json:"currency"`
-	ReferenceID string  `json:"reference_id"`
-}
-
-type AuditRequest struct {
-	AccountID string
+	AccountID    string              `json:"account_id"`
+	Amount       float64             `json:"amount"`
+	Currency     string              `json:"currency"`
+	ReferenceID  string              `json:"reference_id"`
+	Description  string              `json:"description"`
+	Counterparty *model.Counterparty `json:"counterparty"`
+	Metadata     map[string]string   `json:"metadata"`
+	ValueDate    string              `json:"value_date"` // YYYY-MM-DD, for back-valued transactions
 }
 
 func decodeDepositRequest(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -77,6 +83,11 @@ func decodeWithdrawRequest(ctx context.Context, r *http.Request) (interface{}, e
 	return req, nil
 }
 
+// decodeAuditRequest reads the account, the search filters and the page of its transaction history:
+// q matches the description, counterparty the counterparty name or account, metadata.<key>=<value>
+// the metadata, from and to (RFC 3339 times or YYYY-MM-DD dates, to inclusive of the whole day) the
+// creation time, and min_amount and max_amount the amount. sort=asc pages oldest first, and
+// source=ledger reads the Postgres ledger instead of the audit records.
 func decodeAuditRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	accountID := chi.URLParam(r, "id")
 	if accountID == "" {
@@ -84,5 +95,167 @@ func decodeAuditRequest(_ context.Context, r *http.Request) (interface{}, error)
 			errors.New("account_id missing in path"), "missing account_id in path", "MISSING_ACCOUNT_ID", http.StatusBadRequest)
 	}
 
-	return AuditRequest{AccountID: accountID}, nil
+	query := r.URL.Query()
+	filter := HistoryFilter{
+		AccountID:    accountID,
+		Source:       strings.ToLower(query.Get("source")),
+		Query:        query.Get("q"),
+		Counterparty: query.Get("counterparty"),
+		Type:         strings.ToLower(query.Get("type")),
+		Status:       strings.ToLower(query.Get("status")),
+		Cursor:       query.Get("cursor"),
+	}
+
+	for key, values := range query {
+		if name, ok := strings.CutPrefix(key, "metadata."); ok {
+			if filter.Metadata == nil {
+				filter.Metadata = make(map[string]string)
+			}
+			filter.Metadata[name] = values[0]
+		}
+	}
+
+	if err := model.ValidateMetadata(filter.Metadata); err != nil {
+		return nil, eError.NewServiceError(err, err.Error(), "INVALID_METADATA", http.StatusBadRequest)
+	}
+
+	switch filter.Source {
+	case "":
+		filter.Source = HistorySourceAudit
+	case HistorySourceAudit, HistorySourceLedger:
+	default:
+		return nil, eError.NewServiceError(
+			errors.Errorf("unknown source %q", filter.Source), "source must be audit or ledger", "INVALID_SOURCE", http.StatusBadRequest)
+	}
+
+	switch sort := strings.ToLower(query.Get("sort")); sort {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		return nil, eError.NewServiceError(
+			errors.Errorf("unknown sort %q", sort), "sort must be asc or desc", "INVALID_SORT", http.StatusBadRequest)
+	}
+
+	var err error
+	if filter.From, err = parseHistoryTime(query.Get("from"), false); err != nil {
+		return nil, eError.NewServiceError(err, "from must be an RFC 3339 time or a date in YYYY-MM-DD format", "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+	if filter.To, err = parseHistoryTime(query.Get("to"), true); err != nil {
+		return nil, eError.NewServiceError(err, "to must be an RFC 3339 time or a date in YYYY-MM-DD format", "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, eError.NewServiceError(
+			errors.New("from is not before to"), "from must be before to", "INVALID_DATE_RANGE", http.StatusBadRequest)
+	}
+
+	if filter.MinAmount, err = parseAmountBound(query.Get("min_amount")); err != nil {
+		return nil, eError.NewServiceError(err, "min_amount must be a non-negative number", "INVALID_AMOUNT", http.StatusBadRequest)
+	}
+	if filter.MaxAmount, err = parseAmountBound(query.Get("max_amount")); err != nil {
+		return nil, eError.NewServiceError(err, "max_amount must be a non-negative number", "INVALID_AMOUNT", http.StatusBadRequest)
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && filter.MinAmount.GreaterThan(*filter.MaxAmount) {
+		return nil, eError.NewServiceError(
+			errors.New("min_amount exceeds max_amount"), "min_amount must not exceed max_amount", "INVALID_AMOUNT", http.StatusBadRequest)
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxHistoryLimit {
+			return nil, eError.NewServiceError(
+				errors.New("invalid limit"), fmt.Sprintf("limit must be an integer between 1 and %d", MaxHistoryLimit), "INVALID_LIMIT", http.StatusBadRequest)
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+// parseHistoryTime parses an RFC 3339 time or a YYYY-MM-DD date. A date as an upper bound means
+// the end of that day.
+func parseHistoryTime(value string, upper bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func parseAmountBound(value string) (*decimal.Decimal, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, err
+	}
+	if amount.IsNegative() {
+		return nil, errors.New("amount bound is negative")
+	}
+	return &amount, nil
+}
+
+type BatchItemRequest struct {
+	AccountID       string              `json:"account_id"`
+	TargetAccountID string              `json:"target_account_id"`
+	Type            string              `json:"type"`
+	Amount          decimal.Decimal     `json:"amount"`
+	Currency        string              `json:"currency"`
+	ReferenceID     string              `json:"reference_id"`
+	Description     string              `json:"description"`
+	Counterparty    *model.Counterparty `json:"counterparty"`
+	Metadata        map[string]string   `json:"metadata"`
+	ValueDate       string              `json:"value_date"`
+}
+
+type BatchRequest struct {
+	Items []BatchItemRequest `json:"items"`
+}
+
+type BatchStatusRequest struct {
+	BatchID string
+}
+
+// StatusCode lets the JSON encoder answer 202 for a queued batch and 422 for a rejected one.
+func (r BatchResult) StatusCode() int {
+	if r.Status == BatchStatusRejected {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusAccepted
+}
+
+func decodeBatchRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	var req BatchRequest
+	if err := decoder.Decode(&req); err != nil {
+		slog.Error("decode batch request", "err", err)
+		return nil, eError.NewServiceError(err, "invalid request payload", "INVALID_PAYLOAD", http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+func decodeBatchStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	batchID := chi.URLParam(r, "id")
+	if !model.IsValidUUID(batchID) {
+		return nil, eError.NewServiceError(
+			errors.New("invalid batch id"), "batch id must be a valid UUID", "INVALID_BATCH_ID", http.StatusBadRequest)
+	}
+
+	return BatchStatusRequest{BatchID: batchID}, nil
 }
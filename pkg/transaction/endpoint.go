@@ -7,6 +7,7 @@ import (
 	"github.com/mdshahjahanmiah/explore-go/logging"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
+	"strings"
 	"time"
 )
 
@@ -22,14 +23,18 @@ func makeDepositEndpoint(s Service, logger *logging.Logger) endpoint.Endpoint {
 
 		txnID := model.NewUUID()
 		txn := model.Transaction{
-			ID:          txnID,
-			AccountID:   req.AccountID,
-			Type:        TransactionTypeDeposit,
-			Amount:      model.Decimal{Decimal: decimal.NewFromFloat(req.Amount)},
-			Currency:    req.Currency,
-			ReferenceID: req.ReferenceID,
-			Status:      TransactionStatusPending,
-			CreatedAt:   time.Now().UTC(),
+			ID:           txnID,
+			AccountID:    req.AccountID,
+			Type:         TransactionTypeDeposit,
+			Amount:       model.Decimal{Decimal: decimal.NewFromFloat(req.Amount)},
+			Currency:     req.Currency,
+			ReferenceID:  req.ReferenceID,
+			Status:       TransactionStatusPending,
+			Description:  req.Description,
+			Counterparty: req.Counterparty,
+			Metadata:     req.Metadata,
+			ValueDate:    req.ValueDate,
+			CreatedAt:    time.Now().UTC(),
 		}
 
 		result, err := s.CreateTransaction(ctx, txn)
@@ -53,14 +58,18 @@ func makeWithdrawEndpoint(s Service, logger *logging.Logger) endpoint.Endpoint {
 
 		txnID := model.NewUUID()
 		txn := model.Transaction{
-			ID:          txnID,
-			AccountID:   req.AccountID,
-			Type:        TransactionTypeWithdrawal,
-			Amount:      model.Decimal{Decimal: decimal.NewFromFloat(req.Amount)},
-			Currency:    req.Currency,
-			ReferenceID: req.ReferenceID,
-			Status:      TransactionStatusPending,
-			CreatedAt:   time.Now().UTC(),
+			ID:           txnID,
+			AccountID:    req.AccountID,
+			Type:         TransactionTypeWithdrawal,
+			Amount:       model.Decimal{Decimal: decimal.NewFromFloat(req.Amount)},
+			Currency:     req.Currency,
+			ReferenceID:  req.ReferenceID,
+			Status:       TransactionStatusPending,
+			Description:  req.Description,
+			Counterparty: req.Counterparty,
+			Metadata:     req.Metadata,
+			ValueDate:    req.ValueDate,
+			CreatedAt:    time.Now().UTC(),
 		}
 
 		result, err := s.CreateTransaction(ctx, txn)
@@ -76,10 +85,48 @@ func makeWithdrawEndpoint(s Service, logger *logging.Logger) endpoint.Endpoint {
 
 func makeAuditEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req, ok := request.(AuditRequest)
+		filter, ok := request.(HistoryFilter)
 		if !ok {
 			return nil, ErrInvalidRequestType
 		}
-		return s.GetTransactions(req.AccountID)
+		return s.GetTransactions(ctx, filter)
+	}
+}
+
+func makeBatchEndpoint(s Service, logger *logging.Logger) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(BatchRequest)
+		if !ok {
+			logger.Error("invalid batch request type")
+			return nil, ErrInvalidRequestType
+		}
+
+		inputs := make([]model.Transaction, len(req.Items))
+		for i, item := range req.Items {
+			inputs[i] = model.Transaction{
+				AccountID:       item.AccountID,
+				TargetAccountID: item.TargetAccountID,
+				Type:            strings.ToLower(item.Type),
+				Amount:          model.Decimal{Decimal: item.Amount},
+				Currency:        item.Currency,
+				ReferenceID:     item.ReferenceID,
+				Description:     item.Description,
+				Counterparty:    item.Counterparty,
+				Metadata:        item.Metadata,
+				ValueDate:       item.ValueDate,
+			}
+		}
+
+		return s.CreateBatch(ctx, inputs)
+	}
+}
+
+func makeBatchStatusEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(BatchStatusRequest)
+		if !ok {
+			return nil, ErrInvalidRequestType
+		}
+		return s.GetBatch(ctx, req.BatchID)
 	}
 }
@@ -0,0 +1,363 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	eError "github.com/mdshahjahanmiah/explore-go/error"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sources of the transaction history.
+const (
+	HistorySourceAudit  = "audit"  // Audit documents in MongoDB: every processed transaction, completed or failed
+	HistorySourceLedger = "ledger" // Entries in Postgres: every completed entry, including fees and transfer credits
+)
+
+// History page sizes.
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 200
+)
+
+// HistoryFilter narrows and pages an account's transaction history. Empty fields match every
+// transaction. Pages are newest first unless Ascending is set, and Cursor is the NextCursor of the
+// previous page.
+type HistoryFilter struct {
+	AccountID    string
+	Source       string
+	Query        string            // Case-insensitive substring of the description
+	Counterparty string            // Case-insensitive substring of the counterparty name, or its exact account identifier
+	Metadata     map[string]string // Every pair must be present
+	From         *time.Time        // Inclusive
+	To           *time.Time        // Exclusive
+	Type         string
+	Status       string
+	MinAmount    *decimal.Decimal
+	MaxAmount    *decimal.Decimal
+	Ascending    bool
+	Cursor       string
+	Limit        int
+}
+
+type HistoryPage struct {
+	Transactions []model.Transaction `json:"transactions"`
+	NextCursor   string              `json:"next_cursor,omitempty"` // Set when more transactions may follow
+}
+
+// historyCursor is the position after the last transaction of a page: the audit document's
+// creation time and object id, or the ledger entry's sequence.
+type historyCursor struct {
+	CreatedAt time.Time `json:"t,omitempty"`
+	ObjectID  string    `json:"o,omitempty"`
+	Sequence  int64     `json:"s,omitempty"`
+}
+
+func (c historyCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (historyCursor, error) {
+	var c historyCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// historyLimit returns the page size for a requested limit, 0 for the default.
+func historyLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultHistoryLimit
+	}
+	return min(limit, MaxHistoryLimit)
+}
+
+func (s *service) GetTransactions(ctx context.Context, filter HistoryFilter) (HistoryPage, error) {
+	filter.Limit = historyLimit(filter.Limit)
+
+	cursor, err := filter.cursor()
+	if err != nil {
+		return HistoryPage{}, eError.NewServiceError(err, "cursor is not valid for this query", "INVALID_CURSOR", http.StatusBadRequest)
+	}
+
+	var page HistoryPage
+	if filter.Source == HistorySourceLedger {
+		page, err = s.store.History(ctx, filter, cursor.Sequence)
+	} else {
+		page, err = auditHistory(ctx, s.repo.Collection, filter, cursor)
+	}
+	if err != nil {
+		s.logger.Error("failed to read transaction history", "account_id", filter.AccountID, "source", filter.Source, "error", err)
+		return HistoryPage{}, err
+	}
+	return page, nil
+}
+
+// cursor decodes the filter's cursor, which must come from a page of the same source.
+func (f HistoryFilter) cursor() (historyCursor, error) {
+	if f.Cursor == "" {
+		return historyCursor{}, nil
+	}
+
+	cursor, err := decodeCursor(f.Cursor)
+	if err != nil {
+		return historyCursor{}, err
+	}
+	if f.Source == HistorySourceLedger && cursor.Sequence == 0 || f.Source != HistorySourceLedger && cursor.ObjectID == "" {
+		return historyCursor{}, ErrInvalidCursor
+	}
+	if cursor.ObjectID != "" && !primitive.IsValidObjectID(cursor.ObjectID) {
+		return historyCursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// auditHistory reads a page of the account's audit documents, ordered by creation time and object id.
+func auditHistory(ctx context.Context, collection *mongo.Collection, filter HistoryFilter, cursor historyCursor) (HistoryPage, error) {
+	conditions := bson.A{bson.M{"accountid": filter.AccountID}}
+	if filter.Query != "" {
+		conditions = append(conditions, bson.M{"description": bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}})
+	}
+	if filter.Counterparty != "" {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"counterparty.name": bson.M{"$regex": regexp.QuoteMeta(filter.Counterparty), "$options": "i"}},
+			bson.M{"counterparty.account": filter.Counterparty},
+		}})
+	}
+	for key, value := range filter.Metadata {
+		conditions = append(conditions, bson.M{"metadata." + key: value})
+	}
+	if filter.From != nil {
+		conditions = append(conditions, bson.M{"createdat": bson.M{"$gte": *filter.From}})
+	}
+	if filter.To != nil {
+		conditions = append(conditions, bson.M{"createdat": bson.M{"$lt": *filter.To}})
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, bson.M{"type": filter.Type})
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, bson.M{"status": filter.Status})
+	}
+
+	// Amounts are stored as strings, so they are compared as decimals
+	for op, bound := range map[string]*decimal.Decimal{"$gte": filter.MinAmount, "$lte": filter.MaxAmount} {
+		if bound == nil {
+			continue
+		}
+		value, err := primitive.ParseDecimal128(bound.String())
+		if err != nil {
+			return HistoryPage{}, errors.Wrap(err, "invalid amount bound")
+		}
+		conditions = append(conditions, bson.M{"$expr": bson.M{op: bson.A{bson.M{"$toDecimal": "$amount"}, value}}})
+	}
+
+	direction, after := -1, "$lt"
+	if filter.Ascending {
+		direction, after = 1, "$gt"
+	}
+
+	if cursor.ObjectID != "" {
+		objectID, _ := primitive.ObjectIDFromHex(cursor.ObjectID)
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"createdat": bson.M{after: cursor.CreatedAt}},
+			bson.M{"createdat": cursor.CreatedAt, "_id": bson.M{after: objectID}},
+		}})
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdat", Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(filter.Limit + 1))
+
+	rows, err := collection.Find(ctx, bson.M{"$and": conditions}, opts)
+	if err != nil {
+		return HistoryPage{}, errors.Wrap(err, "failed to find transactions")
+	}
+	defer rows.Close(ctx)
+
+	var docs []struct {
+		ObjectID          primitive.ObjectID `bson:"_id"`
+		model.Transaction `bson:",inline"`
+	}
+	if err := rows.All(ctx, &docs); err != nil {
+		return HistoryPage{}, errors.Wrap(err, "failed to decode transactions")
+	}
+
+	page := HistoryPage{Transactions: make([]model.Transaction, 0, min(len(docs), filter.Limit))}
+	for i, doc := range docs {
+		if i == filter.Limit {
+			last := docs[i-1]
+			page.NextCursor = historyCursor{CreatedAt: last.CreatedAt, ObjectID: last.ObjectID.Hex()}.encode()
+			break
+		}
+		page.Transactions = append(page.Transactions, doc.Transaction)
+	}
+	return page, nil
+}
+
+// EnsureHistoryIndexes creates the audit collection indexes the history queries page through.
+func EnsureHistoryIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "accountid", Value: 1}, {Key: "createdat", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("account_history"),
+		},
+		{
+			Keys:    bson.D{{Key: "accountid", Value: 1}, {Key: "type", Value: 1}, {Key: "createdat", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("account_history_by_type"),
+		},
+		{
+			Keys:    bson.D{{Key: "accountid", Value: 1}, {Key: "status", Value: 1}, {Key: "createdat", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("account_history_by_status"),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create history indexes")
+	}
+	return nil
+}
+
+// EntryColumns selects a transactions row for ScanEntry.
+const EntryColumns = `id, account_id, type, amount, currency, reference_id, status, COALESCE(parent_id::text, ''),
+	sequence, balance_after, original_amount, original_currency, fx_rate,
+	description, counterparty_name, counterparty_account, metadata, created_at, to_char(value_date, 'YYYY-MM-DD')`
+
+// History reads a page of the account's ledger entries, ordered by sequence and starting after the
+// given sequence, 0 for the first page.
+func (s *store) History(ctx context.Context, filter HistoryFilter, after int64) (HistoryPage, error) {
+	query := `SELECT ` + EntryColumns + ` FROM transactions WHERE account_id = $1`
+	args := []interface{}{filter.AccountID}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	if filter.Query != "" {
+		where(`description ILIKE '%%' || $%d || '%%'`, escapeLike(filter.Query))
+	}
+	if filter.Counterparty != "" {
+		args = append(args, escapeLike(filter.Counterparty), filter.Counterparty)
+		query += fmt.Sprintf(` AND (counterparty_name ILIKE '%%' || $%d || '%%' OR counterparty_account = $%d)`, len(args)-1, len(args))
+	}
+	if len(filter.Metadata) > 0 {
+		metadata, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return HistoryPage{}, errors.Wrap(err, "failed to encode metadata filter")
+		}
+		where("metadata @> $%d::jsonb", string(metadata))
+	}
+	if filter.From != nil {
+		where("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("created_at < $%d", *filter.To)
+	}
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	if filter.MinAmount != nil {
+		where("amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		where("amount <= $%d", *filter.MaxAmount)
+	}
+
+	order := "DESC"
+	if filter.Ascending {
+		order = "ASC"
+		if after != 0 {
+			where("sequence > $%d", after)
+		}
+	} else if after != 0 {
+		where("sequence < $%d", after)
+	}
+
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf(" ORDER BY sequence %s LIMIT $%d", order, len(args))
+
+	rows, err := s.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return HistoryPage{}, errors.Wrap(err, "failed to read transactions")
+	}
+	defer rows.Close()
+
+	page := HistoryPage{Transactions: []model.Transaction{}}
+	for rows.Next() {
+		if len(page.Transactions) == filter.Limit {
+			last := page.Transactions[len(page.Transactions)-1]
+			page.NextCursor = historyCursor{Sequence: last.Sequence}.encode()
+			break
+		}
+
+		txn, err := ScanEntry(rows)
+		if err != nil {
+			return HistoryPage{}, errors.Wrap(err, "failed to scan transaction")
+		}
+		page.Transactions = append(page.Transactions, txn)
+	}
+	return page, rows.Err()
+}
+
+// ScanEntry reads a row selected with EntryColumns as a completed transaction.
+func ScanEntry(rows *sql.Rows) (model.Transaction, error) {
+	var txn model.Transaction
+	var balanceAfter decimal.Decimal
+	var originalAmount, rate decimal.NullDecimal
+	var originalCurrency sql.NullString
+	var counterparty model.Counterparty
+	var metadata []byte
+
+	err := rows.Scan(&txn.ID, &txn.AccountID, &txn.Type, &txn.Amount.Decimal, &txn.Currency, &txn.ReferenceID, &txn.Status,
+		&txn.ParentID, &txn.Sequence, &balanceAfter, &originalAmount, &originalCurrency, &rate,
+		&txn.Description, &counterparty.Name, &counterparty.Account, &metadata, &txn.CreatedAt, &txn.ValueDate)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	txn.BalanceAfter = &model.Decimal{Decimal: balanceAfter}
+	if originalAmount.Valid {
+		txn.Conversion = &model.Conversion{
+			OriginalAmount:   model.Decimal{Decimal: originalAmount.Decimal},
+			OriginalCurrency: originalCurrency.String,
+			Rate:             model.Decimal{Decimal: rate.Decimal},
+		}
+	}
+	if counterparty != (model.Counterparty{}) {
+		txn.Counterparty = &counterparty
+	}
+	if err := json.Unmarshal(metadata, &txn.Metadata); err != nil {
+		return model.Transaction{}, errors.Wrap(err, "failed to decode transaction metadata")
+	}
+	if len(txn.Metadata) == 0 {
+		txn.Metadata = nil
+	}
+	return txn, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -2,15 +2,19 @@ package transaction
 
 import (
 	"context"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/mdshahjahanmiah/banking-ledger/model"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/broker"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/chart"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/config"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/fee"
 	eError "github.com/mdshahjahanmiah/explore-go/error"
 	"github.com/mdshahjahanmiah/explore-go/logging"
 	"github.com/mdshahjahanmiah/explore-go/repository"
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
 	"net/http"
 	"time"
 )
@@ -18,40 +22,92 @@ import (
 var (
 	ErrDuplicateTransaction   = errors.New("duplicate transaction")
 	ErrAccountNotFound        = errors.New("account not found")
+	ErrTargetAccountNotFound  = errors.New("target account not found")
+	ErrCurrencyMismatch       = errors.New("currency mismatch")
+	ErrRateUnavailable        = errors.New("exchange rate unavailable")
 	ErrAccountNotActive       = errors.New("account not active")
 	ErrInvalidAmount          = errors.New("invalid amount")
 	ErrInsufficientFunds      = errors.New("insufficient funds")
 	ErrInvalidTransactionType = errors.New("invalid transaction type")
+	ErrBatchNotFound          = errors.New("batch not found")
+	ErrPeriodClosed           = errors.New("accounting period closed")
+	ErrValueDateRequired      = errors.New("ledger entry has no value date")
 )
 
 const (
 	TransactionTypeDeposit    = "deposit"
 	TransactionTypeWithdrawal = "withdrawal"
+	TransactionTypeFee        = "fee"
+	TransactionTypeTransfer   = "transfer"
+
+	TransactionTypeOpeningBalance = "opening_balance" // Initial balance of a new account
+	TransactionTypeInterest       = "interest"        // Monthly interest credit, never charged fees
 
 	TransactionStatusPending   = "pending"
 	TransactionStatusCompleted = "completed"
 	TransactionStatusFailed    = "failed"
+
+	BatchStatusQueued   = "queued"
+	BatchStatusRejected = "rejected"
+
+	BatchItemStatusQueued  = "queued"
+	BatchItemStatusInvalid = "invalid"
+	BatchItemStatusSkipped = "skipped" // Valid, but not queued because another item in the batch is invalid
 )
 
+// SignedAmountSQL is a SQL expression for a transactions row's effect on its account balance:
+// credits are positive and debits (withdrawals, fees) negative.
+const SignedAmountSQL = `CASE WHEN type IN ('deposit', 'opening_balance', 'interest') THEN amount ELSE -amount END`
+
+// IsCredit reports whether a transaction type increases the account balance.
+func IsCredit(txnType string) bool {
+	return txnType == TransactionTypeDeposit || txnType == TransactionTypeOpeningBalance || txnType == TransactionTypeInterest
+}
+
 type Service interface {
 	CreateTransaction(ctx context.Context, input model.Transaction) (model.Transaction, error)
-	ProcessTransaction(ctx context.Context, txn model.Transaction) error
-	GetTransactions(accountID string) ([]model.Transaction, error)
+	ProcessTransaction(ctx context.Context, txn model.Transaction) (model.Transaction, error)
+	GetTransactions(ctx context.Context, filter HistoryFilter) (HistoryPage, error)
+	CreateBatch(ctx context.Context, inputs []model.Transaction) (BatchResult, error)
+	GetBatch(ctx context.Context, batchID string) (model.Batch, error)
+}
+
+// BatchResult is the outcome of a batch submission. A batch is queued only when every item is
+// valid; otherwise nothing is queued and the invalid items carry their validation errors.
+type BatchResult struct {
+	BatchID string                  `json:"batch_id,omitempty"`
+	Status  string                  `json:"status"`
+	Items   []model.BatchItemResult `json:"items"`
 }
 
 type service struct {
 	config   config.Config
 	logger   *logging.Logger
 	store    *store
+	fees     *fee.Schedule
+	rates    RateSource
 	repo     *repository.Repository[model.Transaction]
 	producer broker.Producer
 }
 
-func NewService(config config.Config, logger *logging.Logger, database *db.DB, repo *repository.Repository[model.Transaction], producer broker.Producer) (Service, error) {
+func NewService(config config.Config, logger *logging.Logger, database *db.DB, repo *repository.Repository[model.Transaction], producer broker.Producer, rates RateSource) (Service, error) {
+	fees, err := fee.LoadSchedule(config.FeeSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := chart.Load(config.ChartOfAccounts)
+	if err != nil {
+		return nil, err
+	}
+	fees.WithDefaultRevenueAccounts(accounts.ByCurrency(model.SystemRoleFeeRevenue))
+
 	return &service{
 		config:   config,
 		logger:   logger,
-		store:    NewStore(database),
+		store:    NewStore(database).WithFeeSchedule(fees).WithRates(rates).WithSuspense(accounts.ByCurrency(model.SystemRoleSuspense)),
+		fees:     fees,
+		rates:    rates,
 		repo:     repo,
 		producer: producer,
 	}, nil
@@ -66,20 +122,39 @@ func (s *service) CreateTransaction(ctx context.Context, input model.Transaction
 	}
 
 	txn := model.Transaction{
-		ID:          model.NewUUID(),
-		AccountID:   input.AccountID,
-		Type:        input.Type,
-		Amount:      input.Amount,
-		Currency:    input.Currency,
-		ReferenceID: input.ReferenceID,
-		Status:      TransactionStatusPending,
-		CreatedAt:   time.Now().UTC(),
+		ID:              model.NewUUID(),
+		AccountID:       input.AccountID,
+		TargetAccountID: input.TargetAccountID,
+		Type:            input.Type,
+		Amount:          input.Amount,
+		Currency:        input.Currency,
+		ReferenceID:     input.ReferenceID,
+		Status:          TransactionStatusPending,
+		Description:     input.Description,
+		Counterparty:    input.Counterparty,
+		Metadata:        input.Metadata,
+		ValueDate:       input.ValueDate,
+		CreatedAt:       time.Now().UTC(),
 	}
 
 	// Validate transaction
 	if err := txn.Validate(); err != nil {
+		return model.Transaction{}, detailsError(err)
+	}
+
+	// Reject back-valued transactions the processor would refuse
+	if err := s.checkValueDate(ctx, txn); err != nil {
+		s.logger.Error("failed to check value date", "reference_id", txn.ReferenceID, "error", err)
+		return model.Transaction{}, err
+	}
+
+	// Check the currencies and quote the fees the processor will charge
+	fees, err := s.checkAccounts(ctx, txn)
+	if err != nil {
+		s.logger.Error("failed to check accounts", "reference_id", txn.ReferenceID, "error", err)
 		return model.Transaction{}, err
 	}
+	txn.Fees = fees
 
 	// Publish to Kafka (or other broker)
 	if err := s.producer.PublishTransaction(txn); err != nil {
@@ -91,23 +166,254 @@ func (s *service) CreateTransaction(ctx context.Context, input model.Transaction
 	return txn, nil
 }
 
-func (s *service) ProcessTransaction(ctx context.Context, txn model.Transaction) error {
+func (s *service) ProcessTransaction(ctx context.Context, txn model.Transaction) (model.Transaction, error) {
 	// Validate transaction
 	if err := txn.Validate(); err != nil {
-		return err
+		return model.Transaction{}, err
 	}
 
 	// Process transaction in the store
-	if err := s.store.ProcessTransaction(ctx, txn); err != nil {
-		return err
+	processed, err := s.store.ProcessTransaction(ctx, txn)
+	if err != nil {
+		return model.Transaction{}, err
 	}
 
-	s.logger.Info("transaction processed successfully", "reference_id", txn.ReferenceID, "amount", txn.Amount, "currency", txn.Currency)
+	s.logger.Info("transaction processed successfully", "reference_id", txn.ReferenceID, "amount", txn.Amount, "currency", txn.Currency, "sequence", processed.Sequence)
+	return processed, nil
+
+}
+
+// checkAccounts rejects a transaction about to be queued whose currency its accounts can't take,
+// and previews the fee breakdown, in the account currency. Unknown accounts are not checked and
+// get no quote; the processor reports them when the transaction is applied.
+func (s *service) checkAccounts(ctx context.Context, txn model.Transaction) ([]model.Fee, error) {
+	account, err := s.store.GetAccount(ctx, txn.AccountID)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	converted, err := convert(ctx, s.rates, account, txn)
+	if err != nil {
+		return nil, currencyError(err)
+	}
+
+	if txn.Type == TransactionTypeTransfer {
+		target, err := s.store.GetAccount(ctx, txn.TargetAccountID)
+		if err != nil && !errors.Is(err, ErrAccountNotFound) {
+			return nil, err
+		}
+		if err == nil && target.Currency != txn.Currency {
+			return nil, currencyError(ErrCurrencyMismatch)
+		}
+	}
+
+	if len(s.fees.Rules) == 0 {
+		return nil, nil
+	}
+	return feesFor(s.fees, account, converted), nil
+}
+
+// checkValueDate rejects a transaction about to be queued whose explicit value date is in a closed
+// period. The processor checks again under the period lock, as a close may land in between.
+func (s *service) checkValueDate(ctx context.Context, txn model.Transaction) error {
+	if txn.ValueDate == "" {
+		return nil
+	}
+
+	closedThrough, err := s.store.ClosedThrough(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := valueDate(txn, closedThrough); err != nil {
+		return eError.NewServiceError(err, fmt.Sprintf("value date %s is in a closed period", txn.ValueDate), "PERIOD_CLOSED", http.StatusConflict)
+	}
 	return nil
+}
+
+// currencyError maps conversion failures to the errors reported by the API.
+func currencyError(err error) error {
+	switch {
+	case errors.Is(err, ErrCurrencyMismatch):
+		return eError.NewServiceError(err, "transaction currency does not match the account currency", "CURRENCY_MISMATCH", http.StatusUnprocessableEntity)
+	case errors.Is(err, ErrRateUnavailable):
+		return eError.NewServiceError(err, "no exchange rate for the currency pair", "RATE_UNAVAILABLE", http.StatusServiceUnavailable)
+	case errors.Is(err, ErrInvalidAmount):
+		return eError.NewServiceError(err, "amount converts to zero in the account currency", "INVALID_AMOUNT", http.StatusBadRequest)
+	}
+	return err
+}
 
+// detailsError maps a rejected description, counterparty, metadata or value date to the error reported by the API.
+func detailsError(err error) error {
+	if errors.Is(err, model.ErrInvalidValueDate) {
+		return eError.NewServiceError(err, err.Error(), "INVALID_VALUE_DATE", http.StatusBadRequest)
+	}
+	for _, detailsErr := range []error{model.ErrInvalidDescription, model.ErrInvalidCounterparty, model.ErrInvalidMetadata} {
+		if errors.Is(err, detailsErr) {
+			return eError.NewServiceError(err, err.Error(), "INVALID_DETAILS", http.StatusBadRequest)
+		}
+	}
+	return err
+}
+
+// CreateBatch validates every input up front and, if all are valid, queues them with a single
+// publish. The batch header is stored before publishing so its status can be polled as soon as
+// the processor picks up the first item. If publishing fails part way, resubmitting the same
+// reference IDs is safe: items that were already queued are rejected as duplicates.
+func (s *service) CreateBatch(ctx context.Context, inputs []model.Transaction) (BatchResult, error) {
+	if len(inputs) == 0 {
+		return BatchResult{}, eError.NewServiceError(
+			errors.New("batch is empty"), "batch must contain at least one item", "EMPTY_BATCH", http.StatusBadRequest)
+	}
+	if len(inputs) > s.config.BatchMaxItems {
+		return BatchResult{}, eError.NewServiceError(
+			errors.Errorf("batch has %d items", len(inputs)), fmt.Sprintf("batch must not exceed %d items", s.config.BatchMaxItems), "BATCH_TOO_LARGE", http.StatusBadRequest)
+	}
+
+	batchID := model.NewUUID()
+	results := make([]model.BatchItemResult, len(inputs))
+	txns := make([]model.Transaction, 0, len(inputs))
+	seen := make(map[string]int, len(inputs))
+	rejected := false
+
+	for i, input := range inputs {
+		txn, err := s.newBatchTransaction(batchID, input)
+		if err == nil {
+			if first, ok := seen[txn.ReferenceID]; ok {
+				err = errors.Errorf("reference_id duplicates item %d", first)
+			}
+		}
+
+		// Check the value date and currencies and quote the fees the processor will charge
+		if err == nil {
+			if err = s.checkValueDate(ctx, txn); err == nil {
+				txn.Fees, err = s.checkAccounts(ctx, txn)
+			}
+			var serviceErr eError.ServiceError
+			if err != nil && !errors.As(err, &serviceErr) {
+				s.logger.Error("failed to check batch item", "batch_id", batchID, "reference_id", txn.ReferenceID, "error", err)
+				return BatchResult{}, err
+			}
+		}
+
+		if err != nil {
+			rejected = true
+			results[i] = model.BatchItemResult{Index: i, ReferenceID: input.ReferenceID, Status: BatchItemStatusInvalid, Error: err.Error()}
+			continue
+		}
+
+		seen[txn.ReferenceID] = i
+		results[i] = model.BatchItemResult{Index: i, TransactionID: txn.ID, ReferenceID: txn.ReferenceID, Status: BatchItemStatusQueued}
+		txns = append(txns, txn)
+	}
+
+	if rejected {
+		for i := range results {
+			if results[i].Status == BatchItemStatusQueued {
+				results[i].Status = BatchItemStatusSkipped
+				results[i].TransactionID = ""
+			}
+		}
+		s.logger.Warn("batch rejected", "items", len(inputs), "invalid", len(inputs)-len(txns))
+		return BatchResult{Status: BatchStatusRejected, Items: results}, nil
+	}
+
+	batch := &model.Batch{ID: batchID, ItemCount: len(txns)}
+	if err := s.store.InsertBatch(ctx, batch); err != nil {
+		s.logger.Error("failed to create batch", "batch_id", batchID, "error", err)
+		return BatchResult{}, err
+	}
+
+	if err := s.producer.PublishTransactions(txns); err != nil {
+		s.logger.Error("failed to publish batch", "batch_id", batchID, "items", len(txns), "error", err)
+		return BatchResult{}, err
+	}
+
+	s.logger.Info("batch queued successfully", "batch_id", batchID, "items", len(txns))
+	return BatchResult{BatchID: batchID, Status: BatchStatusQueued, Items: results}, nil
+}
+
+// newBatchTransaction builds and validates the transaction for one batch item.
+func (s *service) newBatchTransaction(batchID string, input model.Transaction) (model.Transaction, error) {
+	if input.ReferenceID == "" {
+		input.ReferenceID = uuid.NewString()
+	} else if !model.IsValidUUID(input.ReferenceID) {
+		return model.Transaction{}, model.ErrInvalidReferenceID
+	}
+
+	txn := model.Transaction{
+		ID:              model.NewUUID(),
+		AccountID:       input.AccountID,
+		TargetAccountID: input.TargetAccountID,
+		Type:            input.Type,
+		Amount:          input.Amount,
+		Currency:        input.Currency,
+		ReferenceID:     input.ReferenceID,
+		Status:          TransactionStatusPending,
+		BatchID:         batchID,
+		Description:     input.Description,
+		Counterparty:    input.Counterparty,
+		Metadata:        input.Metadata,
+		ValueDate:       input.ValueDate,
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	if err := txn.Validate(); err != nil {
+		return model.Transaction{}, err
+	}
+	return txn, nil
+}
+
+func (s *service) GetBatch(ctx context.Context, batchID string) (model.Batch, error) {
+	batch, err := s.store.GetBatch(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, ErrBatchNotFound) {
+			return model.Batch{}, eError.NewServiceError(err, "batch not found", "BATCH_NOT_FOUND", http.StatusNotFound)
+		}
+		s.logger.Error("failed to get batch", "batch_id", batchID, "error", err)
+		return model.Batch{}, err
+	}
+
+	completed, failed, err := s.batchCounts(ctx, batchID)
+	if err != nil {
+		s.logger.Error("failed to count batch results", "batch_id", batchID, "error", err)
+		return model.Batch{}, err
+	}
+
+	batch.Completed = completed
+	batch.Failed = failed
+	batch.Pending = max(batch.ItemCount-completed-failed, 0)
+	return batch, nil
 }
 
-func (s *service) GetTransactions(accountID string) ([]model.Transaction, error) {
-	// Get transactions from mongodb
-	return s.repo.FindByField("accountid", accountID)
+// batchCounts counts the batch's processed transactions from the audit records, one per
+// transaction.
+func (s *service) batchCounts(ctx context.Context, batchID string) (completed, failed int, err error) {
+	cursor, err := s.repo.Collection.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"batchid": batchID}},
+		bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			Status string `bson:"_id"`
+			Count  int    `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return 0, 0, err
+		}
+		if row.Status == TransactionStatusCompleted {
+			completed = row.Count
+		} else {
+			failed += row.Count
+		}
+	}
+	return completed, failed, cursor.Err()
 }
@@ -3,8 +3,10 @@ package transaction
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"github.com/mdshahjahanmiah/banking-ledger/model"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/fee"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	"log/slog"
@@ -12,21 +14,104 @@ import (
 )
 
 type Store interface {
-	ProcessTransaction(ctx context.Context, txn model.Transaction) error
+	ProcessTransaction(ctx context.Context, txn model.Transaction) (model.Transaction, error)
+	GetAccount(ctx context.Context, accountID string) (model.Account, error)
+	InsertBatch(ctx context.Context, batch *model.Batch) error
+	GetBatch(ctx context.Context, batchID string) (model.Batch, error)
+	History(ctx context.Context, filter HistoryFilter, after int64) (HistoryPage, error)
+	ClosedThrough(ctx context.Context) (time.Time, error)
 }
 
 type store struct {
-	db *db.DB
+	db       *db.DB
+	fees     *fee.Schedule
+	rates    RateSource
+	suspense map[string]string // Suspense account per currency
 }
 
 func NewStore(db *db.DB) *store {
 	return &store{db: db}
 }
 
-func (s *store) ProcessTransaction(ctx context.Context, txn model.Transaction) error {
+// WithFeeSchedule sets the fee schedule charged when transactions are processed.
+func (s *store) WithFeeSchedule(schedule *fee.Schedule) *store {
+	s.fees = schedule
+	return s
+}
+
+// WithSuspense sets the suspense accounts, by currency, credited with deposits for accounts that
+// don't exist or are closed. Without one in the deposit's currency such a deposit fails.
+func (s *store) WithSuspense(accounts map[string]string) *store {
+	s.suspense = accounts
+	return s
+}
+
+// WithRates sets the rate source used to convert transactions on auto-converting accounts.
+func (s *store) WithRates(rates RateSource) *store {
+	s.rates = rates
+	return s
+}
+
+func (s *store) GetAccount(ctx context.Context, accountID string) (model.Account, error) {
+	var account model.Account
+	err := s.db.DB.QueryRowContext(ctx,
+		`SELECT id, user_id, balance, currency, status, tier, auto_convert FROM accounts WHERE id = $1`,
+		accountID,
+	).Scan(&account.ID, &account.UserID, &account.Balance, &account.Currency, &account.Status, &account.Tier, &account.AutoConvert)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Account{}, ErrAccountNotFound
+		}
+		return model.Account{}, errors.Wrap(err, "failed to get account")
+	}
+	return account, nil
+}
+
+func (s *store) InsertBatch(ctx context.Context, batch *model.Batch) error {
+	err := s.db.DB.QueryRowContext(ctx,
+		`INSERT INTO transaction_batches (id, item_count) VALUES ($1, $2) RETURNING created_at`,
+		batch.ID, batch.ItemCount,
+	).Scan(&batch.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err, "failed to create batch")
+	}
+	return nil
+}
+
+func (s *store) GetBatch(ctx context.Context, batchID string) (model.Batch, error) {
+	var batch model.Batch
+	err := s.db.DB.QueryRowContext(ctx,
+		`SELECT id, item_count, created_at FROM transaction_batches WHERE id = $1`, batchID,
+	).Scan(&batch.ID, &batch.ItemCount, &batch.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Batch{}, ErrBatchNotFound
+		}
+		return model.Batch{}, errors.Wrap(err, "failed to get batch")
+	}
+	return batch, nil
+}
+
+// ClosedThrough returns the last closed business date, the zero time if no date was ever closed.
+func (s *store) ClosedThrough(ctx context.Context) (time.Time, error) {
+	var closedThrough sql.NullTime
+	err := s.db.DB.QueryRowContext(ctx, `SELECT closed_through FROM ledger_period`).Scan(&closedThrough)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, errors.Wrap(err, "failed to get closed period")
+	}
+	return closedThrough.Time, nil
+}
+
+// ProcessTransaction applies the transaction and returns it with the sequence number and
+// resulting balance recorded on its row. A converted transaction is returned in the account
+// currency with the submitted amount in its Conversion, and every transaction with the value
+// date it was booked on.
+func (s *store) ProcessTransaction(ctx context.Context, txn model.Transaction) (model.Transaction, error) {
 	tx, err := s.db.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction")
+		return model.Transaction{}, errors.Wrap(err, "failed to begin transaction")
 	}
 	defer func() {
 		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
@@ -35,75 +120,391 @@ func (s *store) ProcessTransaction(ctx context.Context, txn model.Transaction) e
 		}
 	}()
 
-	// Idempotency Checking for existing transaction
+	// Idempotency Checking for existing transaction, converted ones are recorded in the account currency
 	var existingID string
 	err = tx.QueryRowContext(ctx,
-		`SELECT id FROM transactions WHERE reference_id = $1 AND currency = $2`, txn.ReferenceID, txn.Currency,
+		`SELECT id FROM transactions WHERE reference_id = $1 AND (currency = $2 OR original_currency = $2)`, txn.ReferenceID, txn.Currency,
 	).Scan(&existingID)
 
 	if err == nil {
-		return ErrDuplicateTransaction
+		return model.Transaction{}, ErrDuplicateTransaction
 	} else if !errors.Is(err, sql.ErrNoRows) {
-		return errors.Wrap(err, "failed to check existing transactions")
+		return model.Transaction{}, errors.Wrap(err, "failed to check existing transactions")
 	}
 
-	// Get account details with locking
-	var account model.Account
-	err = tx.QueryRowContext(ctx,
-		`SELECT id, balance, currency, status FROM accounts WHERE id = $1 FOR UPDATE`,
-		txn.AccountID,
-	).Scan(&account.ID, &account.Balance, &account.Currency, &account.Status)
-
+	// Locking the ledger period so the value date can't be closed before this commits
+	closedThrough, err := LockPeriod(ctx, tx)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrAccountNotFound
+		return model.Transaction{}, err
+	}
+
+	if txn.ValueDate, err = valueDate(txn, closedThrough); err != nil {
+		return model.Transaction{}, err
+	}
+
+	// Get account details with locking, transfers lock both accounts in id order to avoid deadlocks
+	var target model.Account
+	if txn.Type == TransactionTypeTransfer && txn.TargetAccountID < txn.AccountID {
+		if target, err = lockTarget(ctx, tx, txn); err != nil {
+			return model.Transaction{}, err
 		}
-		return errors.Wrap(err, "failed to get account details")
+	}
+
+	account, err := lockAccount(ctx, tx, txn.AccountID)
+	intended, reason := txn.AccountID, suspenseReason(txn, account, err)
+	if suspenseID := s.suspense[txn.Currency]; reason != "" && suspenseID != "" {
+		// Holding the deposit in suspense instead, it is booked like any deposit to that account
+		txn.AccountID = suspenseID
+		txn.SuspenseItemID = model.DeriveUUID(txn.ID, "suspense")
+		account, err = lockAccount(ctx, tx, suspenseID)
+	}
+	if err != nil {
+		return model.Transaction{}, err
 	}
 
 	if account.Status != model.AccountStatusActive {
-		return ErrAccountNotActive
+		return model.Transaction{}, ErrAccountNotActive
+	}
+
+	if txn, err = convert(ctx, s.rates, account, txn); err != nil {
+		return model.Transaction{}, err
+	}
+
+	if txn.Type == TransactionTypeTransfer && target.ID == "" {
+		if target, err = lockTarget(ctx, tx, txn); err != nil {
+			return model.Transaction{}, err
+		}
 	}
 
 	// Validating transaction amount
 	if txn.Amount.LessThanOrEqual(decimal.Zero) {
-		return ErrInvalidAmount
+		return model.Transaction{}, ErrInvalidAmount
 	}
 
-	// Calculating new balance
-	newBalance := account.Balance
+	// Evaluating the fee schedule
+	fees := feesFor(s.fees, account, txn)
+
+	// Calculating the balance after the transaction and after its fees, which are always charged to the account
+	balanceAfter := account.Balance
 	switch txn.Type {
 	case TransactionTypeDeposit:
-		newBalance = newBalance.Add(txn.Amount.Unwrap())
-	case TransactionTypeWithdrawal:
-		if account.Balance.LessThan(txn.Amount.Unwrap()) {
-			return ErrInsufficientFunds
-		}
-		newBalance = newBalance.Sub(txn.Amount.Unwrap())
+		balanceAfter = balanceAfter.Add(txn.Amount.Unwrap())
+	case TransactionTypeWithdrawal, TransactionTypeTransfer:
+		balanceAfter = balanceAfter.Sub(txn.Amount.Unwrap())
 	default:
-		return ErrInvalidTransactionType
+		return model.Transaction{}, ErrInvalidTransactionType
+	}
+	newBalance := balanceAfter.Sub(fee.Total(fees))
+
+	// Debit-normal system accounts such as settlement go negative as they are debited
+	if newBalance.IsNegative() && !account.Type.AllowsNegative() {
+		return model.Transaction{}, ErrInsufficientFunds
 	}
 
 	// Updating account balance
 	_, err = tx.ExecContext(ctx, `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, newBalance, txn.AccountID)
 	if err != nil {
-		return errors.Wrap(err, "failed to update account balance")
+		return model.Transaction{}, errors.Wrap(err, "failed to update account balance")
 	}
 
 	// Creating transaction record
-	_, err = tx.ExecContext(ctx,
-		`INSERT INTO transactions 
-		(id, account_id, amount, type, reference_id, currency, status, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		txn.ID, txn.AccountID, txn.Amount, txn.Type,
-		txn.ReferenceID, txn.Currency, TransactionStatusCompleted, time.Now().UTC(),
-	)
+	sequence, err := InsertEntry(ctx, tx, Entry{
+		ID:           txn.ID,
+		AccountID:    txn.AccountID,
+		Type:         txn.Type,
+		Amount:       txn.Amount.Unwrap(),
+		Currency:     txn.Currency,
+		ReferenceID:  txn.ReferenceID,
+		BalanceAfter: balanceAfter,
+		Conversion:   txn.Conversion,
+		Description:  txn.Description,
+		Counterparty: txn.Counterparty,
+		Metadata:     txn.Metadata,
+		ValueDate:    txn.ValueDate,
+		CreatedAt:    time.Now().UTC(),
+	})
 	if err != nil {
-		return errors.Wrap(err, "failed to create transaction record")
+		return model.Transaction{}, errors.Wrap(err, "failed to create transaction record")
+	}
+
+	if txn.Type == TransactionTypeTransfer {
+		if err := creditTarget(ctx, tx, txn, target); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	if txn.SuspenseItemID != "" {
+		if err := insertSuspenseItem(ctx, tx, txn, intended, reason); err != nil {
+			return model.Transaction{}, err
+		}
+	}
+
+	if err := s.postFees(ctx, tx, txn, fees, balanceAfter); err != nil {
+		return model.Transaction{}, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return errors.Wrap(err, "transaction commit failed")
+		return model.Transaction{}, errors.Wrap(err, "transaction commit failed")
+	}
+
+	txn.Sequence = sequence
+	txn.BalanceAfter = &model.Decimal{Decimal: balanceAfter}
+	return txn, nil
+}
+
+// postFees records each fee as an entry linked to the charged transaction and credits it to the
+// currency's revenue account, within the caller's transaction. balance is the charged account's
+// balance before the fees.
+func (s *store) postFees(ctx context.Context, tx *sql.Tx, txn model.Transaction, fees []model.Fee, balance decimal.Decimal) error {
+	if len(fees) == 0 {
+		return nil
+	}
+
+	revenueAccountID, err := s.fees.RevenueAccount(txn.Currency)
+	if err != nil {
+		return err
+	}
+
+	var revenueCurrency string
+	var revenueBalance decimal.Decimal
+	err = tx.QueryRowContext(ctx,
+		`SELECT currency, balance FROM accounts WHERE id = $1 FOR UPDATE`, revenueAccountID,
+	).Scan(&revenueCurrency, &revenueBalance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.Wrap(ErrAccountNotFound, "fee revenue account")
+		}
+		return errors.Wrap(err, "failed to get fee revenue account")
+	}
+	if revenueCurrency != txn.Currency {
+		return errors.Wrapf(fee.ErrInvalidSchedule, "revenue account %s is not in %s", revenueAccountID, txn.Currency)
+	}
+
+	for _, f := range fees {
+		balance = balance.Sub(f.Amount.Unwrap())
+		revenueBalance = revenueBalance.Add(f.Amount.Unwrap())
+
+		legs := []struct {
+			accountID    string
+			txnType      string
+			balanceAfter decimal.Decimal
+			side         string
+		}{
+			{txn.AccountID, TransactionTypeFee, balance, "debit"},
+			{revenueAccountID, TransactionTypeDeposit, revenueBalance, "credit"},
+		}
+
+		for _, leg := range legs {
+			err := insertLinkedEntry(ctx, tx, txn, leg.accountID, leg.txnType, f.Amount.Unwrap(), leg.balanceAfter, "fee", f.Rule, leg.side)
+			if err != nil {
+				return errors.Wrap(err, "failed to create fee record")
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, revenueBalance, revenueAccountID)
+	if err != nil {
+		return errors.Wrap(err, "failed to credit fee revenue account")
+	}
+
+	return nil
+}
+
+// creditTarget completes a transfer by crediting the locked target account with a deposit linked to the transfer.
+func creditTarget(ctx context.Context, tx *sql.Tx, txn model.Transaction, target model.Account) error {
+	balance := target.Balance.Add(txn.Amount.Unwrap())
+	_, err := tx.ExecContext(ctx,
+		`UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`, balance, txn.TargetAccountID)
+	if err != nil {
+		return errors.Wrap(err, "failed to credit target account")
+	}
+
+	err = insertLinkedEntry(ctx, tx, txn, txn.TargetAccountID, TransactionTypeDeposit, txn.Amount.Unwrap(), balance, "transfer", "credit")
+	if err != nil {
+		return errors.Wrap(err, "failed to create transfer record")
 	}
 	return nil
 }
+
+// insertLinkedEntry records an entry posted on behalf of txn. Its id and reference are derived
+// from the parent's and the given name parts, so a redelivered parent yields the same entries.
+func insertLinkedEntry(ctx context.Context, tx *sql.Tx, txn model.Transaction, accountID, txnType string, amount, balanceAfter decimal.Decimal, name ...string) error {
+	_, err := InsertEntry(ctx, tx, Entry{
+		ID:           model.DeriveUUID(append([]string{txn.ID}, name...)...),
+		AccountID:    accountID,
+		Type:         txnType,
+		Amount:       amount,
+		Currency:     txn.Currency,
+		ReferenceID:  model.DeriveUUID(append([]string{txn.ReferenceID}, name...)...),
+		ParentID:     txn.ID,
+		BalanceAfter: balanceAfter,
+		ValueDate:    txn.ValueDate,
+		CreatedAt:    time.Now().UTC(),
+	})
+	return err
+}
+
+// LockPeriod share-locks the ledger period within tx, so no date can be closed before tx commits,
+// and returns the last closed business date, zero when none is closed. Posting paths take it
+// before locking any account, so the lock InsertEntry takes again is already held.
+func LockPeriod(ctx context.Context, tx *sql.Tx) (time.Time, error) {
+	var closedThrough sql.NullTime
+	err := tx.QueryRowContext(ctx, `SELECT closed_through FROM ledger_period FOR SHARE`).Scan(&closedThrough)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, errors.Wrap(err, "failed to lock ledger period")
+	}
+	return closedThrough.Time, nil
+}
+
+// BookingDate locks the ledger period within tx, like the processor does, and returns the value
+// date of an entry posted at the given time outside the processor: its day, or the first open day
+// if that day is closed.
+func BookingDate(ctx context.Context, tx *sql.Tx, at time.Time) (string, error) {
+	closedThrough, err := LockPeriod(ctx, tx)
+	if err != nil {
+		return "", err
+	}
+	return valueDate(model.Transaction{CreatedAt: at}, closedThrough)
+}
+
+// valueDate returns the business date txn is booked on given the last closed date, zero when
+// none is closed. An explicit value date in a closed period is rejected; a transaction without
+// one is booked on the day it was created, or the first open day if that day has been closed
+// since.
+func valueDate(txn model.Transaction, closedThrough time.Time) (string, error) {
+	if txn.ValueDate != "" {
+		date, err := time.Parse(time.DateOnly, txn.ValueDate)
+		if err != nil {
+			return "", model.ErrInvalidValueDate
+		}
+		if !closedThrough.IsZero() && !date.After(closedThrough) {
+			return "", ErrPeriodClosed
+		}
+		return txn.ValueDate, nil
+	}
+
+	created := txn.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	date := created.UTC().Truncate(24 * time.Hour)
+	if !closedThrough.IsZero() && !date.After(closedThrough) {
+		date = closedThrough.AddDate(0, 0, 1)
+	}
+	return date.Format(time.DateOnly), nil
+}
+
+// Entry is a completed row of the transactions table.
+type Entry struct {
+	ID           string
+	AccountID    string
+	Type         string
+	Amount       decimal.Decimal
+	Currency     string
+	ReferenceID  string
+	ParentID     string
+	BalanceAfter decimal.Decimal
+	Conversion   *model.Conversion // Set when the amount was converted into the account currency
+	Description  string
+	Counterparty *model.Counterparty
+	Metadata     map[string]string
+	ValueDate    string // Business date (YYYY-MM-DD) the entry is booked on
+	CreatedAt    time.Time
+}
+
+// InsertEntry records a completed entry within tx and returns its sequence number, the account's
+// next. The caller must hold the account's row lock, which serialises sequence assignment, and
+// BalanceAfter must be the account balance the entry leaves. The entry must have a value date, as
+// set by the processor or BookingDate, and it is only inserted if that date is still open, checked
+// under the period's share lock, so no posting path can write into a closed period.
+func InsertEntry(ctx context.Context, tx *sql.Tx, e Entry) (int64, error) {
+	if e.ValueDate == "" {
+		return 0, ErrValueDateRequired
+	}
+
+	var originalAmount, originalCurrency, rate interface{}
+	if c := e.Conversion; c != nil {
+		originalAmount, originalCurrency, rate = c.OriginalAmount.Unwrap(), c.OriginalCurrency, c.Rate.Unwrap()
+	}
+
+	var counterpartyName, counterpartyAccount string
+	if c := e.Counterparty; c != nil {
+		counterpartyName, counterpartyAccount = c.Name, c.Account
+	}
+
+	metadata := "{}"
+	if len(e.Metadata) > 0 {
+		data, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to encode transaction metadata")
+		}
+		metadata = string(data)
+	}
+
+	var sequence int64
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO transactions
+		(id, account_id, amount, type, reference_id, currency, status, parent_id, balance_after, sequence, created_at,
+		original_amount, original_currency, fx_rate, description, counterparty_name, counterparty_account, metadata, value_date)
+		SELECT $1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::uuid, $9,
+			(SELECT COALESCE(MAX(sequence), 0) + 1 FROM transactions WHERE account_id = $2), $10, $11, $12, $13,
+			$14, $15, $16, $17, $18
+		WHERE $18::date > COALESCE((SELECT closed_through FROM ledger_period FOR SHARE), '-infinity'::date)
+		RETURNING sequence`,
+		e.ID, e.AccountID, e.Amount, e.Type, e.ReferenceID, e.Currency, TransactionStatusCompleted,
+		e.ParentID, e.BalanceAfter, e.CreatedAt, originalAmount, originalCurrency, rate,
+		e.Description, counterpartyName, counterpartyAccount, metadata, e.ValueDate,
+	).Scan(&sequence)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.Wrapf(ErrPeriodClosed, "value date %s", e.ValueDate)
+	}
+	return sequence, err
+}
+
+func lockAccount(ctx context.Context, tx *sql.Tx, accountID string) (model.Account, error) {
+	var account model.Account
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = $1 FOR UPDATE`,
+		accountID,
+	).Scan(&account.ID, &account.Balance, &account.Currency, &account.Status, &account.Tier, &account.AutoConvert, &account.Kind, &account.Type)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Account{}, ErrAccountNotFound
+		}
+		return model.Account{}, errors.Wrap(err, "failed to get account details")
+	}
+	return account, nil
+}
+
+// lockTarget locks a transfer's target account and checks it can receive the funds.
+func lockTarget(ctx context.Context, tx *sql.Tx, txn model.Transaction) (model.Account, error) {
+	target, err := lockAccount(ctx, tx, txn.TargetAccountID)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			return model.Account{}, ErrTargetAccountNotFound
+		}
+		return model.Account{}, err
+	}
+
+	if target.Status != model.AccountStatusActive {
+		return model.Account{}, ErrAccountNotActive
+	}
+	if target.Currency != txn.Currency {
+		return model.Account{}, ErrCurrencyMismatch
+	}
+	return target, nil
+}
+
+// feesFor evaluates the schedule for a transaction on the account. System accounts and the
+// revenue account itself are never charged, so fee credits don't recurse.
+func feesFor(schedule *fee.Schedule, account model.Account, txn model.Transaction) []model.Fee {
+	if account.Kind == model.AccountKindSystem {
+		return nil
+	}
+	if revenueAccountID, err := schedule.RevenueAccount(txn.Currency); err == nil && revenueAccountID == account.ID {
+		return nil
+	}
+	return schedule.Calculate(txn.Type, txn.Currency, account.Tier, txn.Amount.Unwrap())
+}
@@ -6,10 +6,12 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/mdshahjahanmiah/banking-ledger/model"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/fee"
 	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestProcessTransaction_Success(t *testing.T) {
@@ -38,14 +40,16 @@ func TestProcessTransaction_Success(t *testing.T) {
 	mock.ExpectBegin()
 
 	// Expect select to check existing transaction (no rows)
-	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1 AND currency = \$2`).
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1 AND \(currency = \$2 OR original_currency = \$2\)`).
 		WithArgs(txn.ReferenceID, txn.Currency).
 		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
 
 	// Expect select for account details with FOR UPDATE
-	rows := sqlmock.NewRows([]string{"id", "balance", "currency", "status"}).
-		AddRow("acc1", decimal.NewFromFloat(200).String(), "USD", model.AccountStatusActive)
-	mock.ExpectQuery(`SELECT id, balance, currency, status FROM accounts WHERE id = \$1 FOR UPDATE`).
+	rows := sqlmock.NewRows([]string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}).
+		AddRow("acc1", decimal.NewFromFloat(200).String(), "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindCustomer, model.AccountTypeLiability)
+	mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
 		WithArgs(txn.AccountID).
 		WillReturnRows(rows)
 
@@ -54,17 +58,627 @@ func TestProcessTransaction_Success(t *testing.T) {
 		WithArgs(sqlmock.AnyArg(), txn.AccountID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	// Expect insert transaction
-	mock.ExpectExec(`INSERT INTO transactions \(id, account_id, amount, type, reference_id, currency, status, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\)`).
-		WithArgs(txn.ID, txn.AccountID, txn.Amount, txn.Type, txn.ReferenceID, txn.Currency, transaction.TransactionStatusCompleted, sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	// Expect insert transaction with the resulting balance and the account's next sequence number
+	mock.ExpectQuery(`INSERT INTO transactions \(id, account_id, amount, type, reference_id, currency, status, parent_id, balance_after, sequence, created_at, original_amount, original_currency, fx_rate, description, counterparty_name, counterparty_account, metadata, value_date\) .* RETURNING sequence`).
+		WithArgs(txn.ID, txn.AccountID, txn.Amount.Unwrap(), txn.Type, txn.ReferenceID, txn.Currency, transaction.TransactionStatusCompleted, "", decimal.NewFromInt(210), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(7))
 
 	// Expect commit
 	mock.ExpectCommit()
 
-	err = store.ProcessTransaction(ctx, txn)
+	processed, err := store.ProcessTransaction(ctx, txn)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(7), processed.Sequence)
+	assert.Equal(t, "210", processed.BalanceAfter.String())
 
 	// ensure all expectations met
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestProcessTransaction_WithFees(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	schedule := &fee.Schedule{
+		RevenueAccounts: map[string]string{"USD": "revenue"},
+		Rules: []fee.Rule{
+			{Name: "atm", Type: transaction.TransactionTypeWithdrawal, Flat: decimal.NewFromInt(2)},
+		},
+	}
+	store := transaction.NewStore(&db.DB{DB: sqlDB}).WithFeeSchedule(schedule)
+
+	txn := model.Transaction{
+		ID:          "txn1",
+		AccountID:   "acc1",
+		ReferenceID: "ref1",
+		Currency:    "USD",
+		Amount:      model.Decimal{Decimal: decimal.NewFromFloat(10)},
+		Type:        transaction.TransactionTypeWithdrawal,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1 AND \(currency = \$2 OR original_currency = \$2\)`).
+		WithArgs(txn.ReferenceID, txn.Currency).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(txn.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}).
+			AddRow("acc1", "200", "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindCustomer, model.AccountTypeLiability))
+
+	// Balance is reduced by the amount plus the fee
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(188), txn.AccountID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(txn.ID, txn.AccountID, txn.Amount.Unwrap(), txn.Type, txn.ReferenceID, "USD", transaction.TransactionStatusCompleted, "", decimal.NewFromInt(190), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(3))
+
+	// Fee legs against the customer and revenue accounts
+	mock.ExpectQuery(`SELECT currency, balance FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("revenue").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "balance"}).AddRow("USD", "40"))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(sqlmock.AnyArg(), txn.AccountID, decimal.NewFromInt(2), transaction.TransactionTypeFee, sqlmock.AnyArg(), "USD", transaction.TransactionStatusCompleted, txn.ID, decimal.NewFromInt(188), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(4))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(sqlmock.AnyArg(), "revenue", decimal.NewFromInt(2), transaction.TransactionTypeDeposit, sqlmock.AnyArg(), "USD", transaction.TransactionStatusCompleted, txn.ID, decimal.NewFromInt(42), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(11))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(42), "revenue").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	processed, err := store.ProcessTransaction(context.Background(), txn)
+	assert.NoError(t, err)
+	assert.Equal(t, "190", processed.BalanceAfter.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessTransaction_SystemAccount(t *testing.T) {
+	tests := []struct {
+		name        string
+		accountType model.AccountType
+		wantErr     error
+	}{
+		// Debit-normal accounts go negative, others can't
+		{"asset goes negative", model.AccountTypeAsset, nil},
+		{"liability can't", model.AccountTypeLiability, transaction.ErrInsufficientFunds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer sqlDB.Close()
+
+			// System accounts are never charged fees
+			schedule := &fee.Schedule{
+				RevenueAccounts: map[string]string{"USD": "revenue"},
+				Rules:           []fee.Rule{{Name: "atm", Type: transaction.TransactionTypeWithdrawal, Flat: decimal.NewFromInt(2)}},
+			}
+			store := transaction.NewStore(&db.DB{DB: sqlDB}).WithFeeSchedule(schedule)
+
+			txn := model.Transaction{
+				ID:          "txn1",
+				AccountID:   "settlement",
+				ReferenceID: "ref1",
+				Currency:    "USD",
+				Amount:      model.Decimal{Decimal: decimal.NewFromInt(80)},
+				Type:        transaction.TransactionTypeWithdrawal,
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+				WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+			mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+				WithArgs("settlement").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}).
+					AddRow("settlement", "30", "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindSystem, tt.accountType))
+
+			if tt.wantErr != nil {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+					WithArgs(decimal.NewFromInt(-50), "settlement").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(`INSERT INTO transactions`).
+					WithArgs(txn.ID, "settlement", txn.Amount.Unwrap(), txn.Type, txn.ReferenceID, "USD", transaction.TransactionStatusCompleted, "", decimal.NewFromInt(-50), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(2))
+				mock.ExpectCommit()
+			}
+
+			processed, err := store.ProcessTransaction(context.Background(), txn)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "-50", processed.BalanceAfter.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProcessTransaction_Suspense(t *testing.T) {
+	accountColumns := []string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}
+	tests := []struct {
+		name     string
+		account  *sqlmock.Rows // Intended account, none when not found
+		suspense map[string]string
+		reason   model.SuspenseReason
+		wantErr  error
+	}{
+		{"account not found", sqlmock.NewRows(accountColumns), map[string]string{"USD": "suspense"}, model.SuspenseReasonAccountNotFound, nil},
+		{"account closed", sqlmock.NewRows(accountColumns).
+			AddRow("acc1", "0", "USD", model.AccountStatusClosed, model.AccountTierStandard, false, model.AccountKindCustomer, model.AccountTypeLiability),
+			map[string]string{"USD": "suspense"}, model.SuspenseReasonAccountClosed, nil},
+		// Without a suspense account in the currency the deposit still fails
+		{"no suspense account", sqlmock.NewRows(accountColumns), map[string]string{"EUR": "suspense"}, "", transaction.ErrAccountNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer sqlDB.Close()
+
+			store := transaction.NewStore(&db.DB{DB: sqlDB}).WithSuspense(tt.suspense)
+
+			txn := model.Transaction{
+				ID:          "txn1",
+				AccountID:   "acc1",
+				ReferenceID: "ref1",
+				Currency:    "USD",
+				Amount:      model.Decimal{Decimal: decimal.NewFromInt(25)},
+				Type:        transaction.TransactionTypeDeposit,
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+				WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+			mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+				WithArgs("acc1").
+				WillReturnRows(tt.account)
+
+			if tt.wantErr != nil {
+				mock.ExpectRollback()
+			} else {
+				itemID := model.DeriveUUID("txn1", "suspense")
+				mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+					WithArgs("suspense").
+					WillReturnRows(sqlmock.NewRows(accountColumns).
+						AddRow("suspense", "100", "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindSystem, model.AccountTypeLiability))
+				mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+					WithArgs(decimal.NewFromInt(125), "suspense").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(`INSERT INTO transactions`).
+					WithArgs(txn.ID, "suspense", txn.Amount.Unwrap(), txn.Type, txn.ReferenceID, "USD", transaction.TransactionStatusCompleted, "", decimal.NewFromInt(125), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(4))
+				mock.ExpectExec(`INSERT INTO suspense_items`).
+					WithArgs(itemID, "txn1", "suspense", "acc1", txn.Amount.Unwrap(), "USD", tt.reason, model.SuspenseStatusOpen).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			}
+
+			processed, err := store.ProcessTransaction(context.Background(), txn)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "suspense", processed.AccountID)
+				assert.Equal(t, model.DeriveUUID("txn1", "suspense"), processed.SuspenseItemID)
+				assert.Equal(t, "125", processed.BalanceAfter.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProcessTransaction_Transfer(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB})
+
+	txn := model.Transaction{
+		ID:              "txn1",
+		AccountID:       "acc2",
+		TargetAccountID: "acc1",
+		ReferenceID:     "ref1",
+		Currency:        "USD",
+		Amount:          model.Decimal{Decimal: decimal.NewFromFloat(50)},
+		Type:            transaction.TransactionTypeTransfer,
+	}
+	accountRows := []string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1 AND \(currency = \$2 OR original_currency = \$2\)`).
+		WithArgs(txn.ReferenceID, txn.Currency).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+
+	// The target has the lower id, so it is locked first
+	mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc1").
+		WillReturnRows(sqlmock.NewRows(accountRows).AddRow("acc1", "0", "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindCustomer, model.AccountTypeLiability))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("acc2").
+		WillReturnRows(sqlmock.NewRows(accountRows).AddRow("acc2", "80", "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindCustomer, model.AccountTypeLiability))
+
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(30), "acc2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(txn.ID, "acc2", txn.Amount.Unwrap(), transaction.TransactionTypeTransfer, txn.ReferenceID, "USD", transaction.TransactionStatusCompleted, "", decimal.NewFromInt(30), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(2))
+
+	// Target is credited with a deposit linked to the transfer
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(50), "acc1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(sqlmock.AnyArg(), "acc1", decimal.NewFromInt(50), transaction.TransactionTypeDeposit, sqlmock.AnyArg(), "USD", transaction.TransactionStatusCompleted, txn.ID, decimal.NewFromInt(50), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectCommit()
+
+	_, err = store.ProcessTransaction(context.Background(), txn)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessTransaction_CurrencyMismatch(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB})
+
+	txn := model.Transaction{
+		ID:          "txn1",
+		AccountID:   "acc1",
+		ReferenceID: "ref1",
+		Currency:    "JPY",
+		Amount:      model.Decimal{Decimal: decimal.NewFromInt(100)},
+		Type:        transaction.TransactionTypeDeposit,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+		WithArgs(txn.ReferenceID, "JPY").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(txn.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}).
+			AddRow("acc1", "200", "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindCustomer, model.AccountTypeLiability))
+	mock.ExpectRollback()
+
+	_, err = store.ProcessTransaction(context.Background(), txn)
+	assert.ErrorIs(t, err, transaction.ErrCurrencyMismatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type fixedRates map[string]decimal.Decimal
+
+func (r fixedRates) Rate(_ context.Context, from, to string) (decimal.Decimal, error) {
+	return r[from+to], nil
+}
+
+func TestProcessTransaction_AutoConvert(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB}).WithRates(fixedRates{"JPYUSD": decimal.RequireFromString("0.0066")})
+
+	txn := model.Transaction{
+		ID:          "txn1",
+		AccountID:   "acc1",
+		ReferenceID: "ref1",
+		Currency:    "JPY",
+		Amount:      model.Decimal{Decimal: decimal.NewFromInt(1000)},
+		Type:        transaction.TransactionTypeDeposit,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+		WithArgs(txn.ReferenceID, "JPY").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(txn.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}).
+			AddRow("acc1", "200", "USD", model.AccountStatusActive, model.AccountTierStandard, true, model.AccountKindCustomer, model.AccountTypeLiability))
+
+	// 1000 JPY at 0.0066 is recorded as 6.60 USD, keeping the submitted amount and the rate
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.RequireFromString("206.6"), txn.AccountID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(txn.ID, txn.AccountID, decimal.RequireFromString("6.6"), txn.Type, txn.ReferenceID, "USD", transaction.TransactionStatusCompleted, "",
+			decimal.RequireFromString("206.6"), sqlmock.AnyArg(), decimal.NewFromInt(1000), "JPY", decimal.RequireFromString("0.0066"), "", "", "", "{}", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectCommit()
+
+	processed, err := store.ProcessTransaction(context.Background(), txn)
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", processed.Currency)
+	assert.Equal(t, "6.6", processed.Amount.String())
+	assert.Equal(t, "JPY", processed.Conversion.OriginalCurrency)
+	assert.Equal(t, "1000", processed.Conversion.OriginalAmount.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessTransaction_WithDetails(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB})
+
+	txn := model.Transaction{
+		ID:           "txn1",
+		AccountID:    "acc1",
+		ReferenceID:  "ref1",
+		Currency:     "USD",
+		Amount:       model.Decimal{Decimal: decimal.NewFromInt(10)},
+		Type:         transaction.TransactionTypeDeposit,
+		Description:  "Invoice 1042",
+		Counterparty: &model.Counterparty{Name: "ACME Ltd", Account: "DE89370400440532013000"},
+		Metadata:     map[string]string{"invoice": "1042"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+		WithArgs(txn.ReferenceID, "USD").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(txn.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}).
+			AddRow("acc1", "0", "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindCustomer, model.AccountTypeLiability))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(decimal.NewFromInt(10), txn.AccountID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(txn.ID, txn.AccountID, decimal.NewFromInt(10), txn.Type, txn.ReferenceID, "USD", transaction.TransactionStatusCompleted, "",
+			decimal.NewFromInt(10), sqlmock.AnyArg(), nil, nil, nil, "Invoice 1042", "ACME Ltd", "DE89370400440532013000", `{"invoice":"1042"}`, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectCommit()
+
+	_, err = store.ProcessTransaction(context.Background(), txn)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessTransaction_ValueDate(t *testing.T) {
+	closedThrough := time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)
+	deposit := model.Transaction{
+		ID:          "txn1",
+		AccountID:   "acc1",
+		ReferenceID: "ref1",
+		Currency:    "USD",
+		Amount:      model.Decimal{Decimal: decimal.NewFromInt(10)},
+		Type:        transaction.TransactionTypeDeposit,
+		CreatedAt:   time.Date(2026, 6, 3, 9, 0, 0, 0, time.UTC),
+	}
+
+	cases := map[string]struct {
+		valueDate string
+		createdAt time.Time
+		booked    string
+	}{
+		"back-valued into the open period": {valueDate: "2026-06-01", createdAt: deposit.CreatedAt, booked: "2026-06-01"},
+		"created on the day":               {createdAt: deposit.CreatedAt, booked: "2026-06-03"},
+		"created on a day closed since":    {createdAt: time.Date(2026, 5, 31, 23, 59, 0, 0, time.UTC), booked: "2026-06-01"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer sqlDB.Close()
+
+			store := transaction.NewStore(&db.DB{DB: sqlDB})
+			txn := deposit
+			txn.ValueDate, txn.CreatedAt = tc.valueDate, tc.createdAt
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+				WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(closedThrough))
+			mock.ExpectQuery(`SELECT id, balance, currency, status, tier, auto_convert, kind, type FROM accounts WHERE id = \$1 FOR UPDATE`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "currency", "status", "tier", "auto_convert", "kind", "type"}).
+					AddRow("acc1", "0", "USD", model.AccountStatusActive, model.AccountTierStandard, false, model.AccountKindCustomer, model.AccountTypeLiability))
+			mock.ExpectExec(`UPDATE accounts SET balance = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(`INSERT INTO transactions`).
+				WithArgs(txn.ID, txn.AccountID, decimal.NewFromInt(10), txn.Type, txn.ReferenceID, "USD", transaction.TransactionStatusCompleted, "",
+					decimal.NewFromInt(10), sqlmock.AnyArg(), nil, nil, nil, "", "", "", "{}", tc.booked).
+				WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+			mock.ExpectCommit()
+
+			processed, err := store.ProcessTransaction(context.Background(), txn)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.booked, processed.ValueDate)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProcessTransaction_PeriodClosed(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB})
+	txn := model.Transaction{
+		ID:          "txn1",
+		AccountID:   "acc1",
+		ReferenceID: "ref1",
+		Currency:    "USD",
+		Amount:      model.Decimal{Decimal: decimal.NewFromInt(10)},
+		Type:        transaction.TransactionTypeDeposit,
+		ValueDate:   "2026-05-31",
+		CreatedAt:   time.Date(2026, 6, 3, 9, 0, 0, 0, time.UTC),
+	}
+
+	// An explicit value date on the last closed day is refused before any account is touched
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM transactions WHERE reference_id = \$1`).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT closed_through FROM ledger_period FOR SHARE`).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_through"}).AddRow(time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectRollback()
+
+	_, err = store.ProcessTransaction(context.Background(), txn)
+	assert.ErrorIs(t, err, transaction.ErrPeriodClosed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertEntry_PeriodClosed(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	entry := transaction.Entry{ID: "fx1", AccountID: "acc1", Type: transaction.TransactionTypeDeposit, Amount: decimal.NewFromInt(10),
+		Currency: "USD", ReferenceID: "ref1", BalanceAfter: decimal.NewFromInt(10), CreatedAt: time.Date(2026, 6, 3, 9, 0, 0, 0, time.UTC)}
+
+	mock.ExpectBegin()
+	// The insert checks the value date against the share-locked period itself and writes nothing when it is closed
+	mock.ExpectQuery(`INSERT INTO transactions .* SELECT .* WHERE \$18::date > COALESCE\(\(SELECT closed_through FROM ledger_period FOR SHARE\), '-infinity'::date\) RETURNING sequence`).
+		WithArgs("fx1", "acc1", decimal.NewFromInt(10), transaction.TransactionTypeDeposit, "ref1", "USD", transaction.TransactionStatusCompleted,
+			"", decimal.NewFromInt(10), entry.CreatedAt, nil, nil, nil, "", "", "", "{}", "2026-05-31").
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}))
+	mock.ExpectRollback()
+
+	tx, err := sqlDB.Begin()
+	assert.NoError(t, err)
+	defer tx.Rollback()
+
+	// An entry without a value date is refused before anything is written
+	_, err = transaction.InsertEntry(context.Background(), tx, entry)
+	assert.ErrorIs(t, err, transaction.ErrValueDateRequired)
+
+	entry.ValueDate = "2026-05-31"
+	_, err = transaction.InsertEntry(context.Background(), tx, entry)
+	assert.ErrorIs(t, err, transaction.ErrPeriodClosed)
+
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBatch(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB})
+	createdAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`INSERT INTO transaction_batches \(id, item_count\) VALUES \(\$1, \$2\) RETURNING created_at`).
+		WithArgs("batch1", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+
+	batch := &model.Batch{ID: "batch1", ItemCount: 3}
+	err = store.InsertBatch(context.Background(), batch)
+	assert.NoError(t, err)
+	assert.Equal(t, createdAt, batch.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBatch_NotFound(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectQuery(`SELECT id, item_count, created_at FROM transaction_batches WHERE id = \$1`).
+		WithArgs("batch1").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = store.GetBatch(context.Background(), "batch1")
+	assert.ErrorIs(t, err, transaction.ErrBatchNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+var historyColumns = []string{"id", "account_id", "type", "amount", "currency", "reference_id", "status", "parent_id",
+	"sequence", "balance_after", "original_amount", "original_currency", "fx_rate",
+	"description", "counterparty_name", "counterparty_account", "metadata", "created_at", "value_date"}
+
+func TestHistory_FirstPage(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB})
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	minAmount := decimal.NewFromInt(5)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT .* FROM transactions WHERE account_id = \$1 AND created_at >= \$2 AND type = \$3 AND amount >= \$4 ORDER BY sequence DESC LIMIT \$5`).
+		WithArgs("acc1", from, transaction.TransactionTypeDeposit, "5", 3).
+		WillReturnRows(sqlmock.NewRows(historyColumns).
+			AddRow("txn3", "acc1", "deposit", "30", "USD", "ref3", "completed", "", 3, "60", nil, nil, nil, "Rent", "ACME", "", []byte(`{}`), now, now.Format(time.DateOnly)).
+			AddRow("txn2", "acc1", "deposit", "20", "USD", "ref2", "completed", "", 2, "30", "18", "EUR", "1.1", "", "", "", []byte(`{"invoice":"42"}`), now, now.Format(time.DateOnly)).
+			AddRow("txn1", "acc1", "deposit", "10", "USD", "ref1", "completed", "", 1, "10", nil, nil, nil, "", "", "", []byte(`{}`), now, now.Format(time.DateOnly)))
+
+	page, err := store.History(context.Background(), transaction.HistoryFilter{
+		AccountID: "acc1",
+		From:      &from,
+		Type:      transaction.TransactionTypeDeposit,
+		MinAmount: &minAmount,
+		Limit:     2,
+	}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, page.Transactions, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	assert.Equal(t, int64(3), page.Transactions[0].Sequence)
+	assert.Equal(t, "ACME", page.Transactions[0].Counterparty.Name)
+	assert.Nil(t, page.Transactions[0].Conversion)
+	assert.Nil(t, page.Transactions[0].Metadata)
+
+	assert.Equal(t, "EUR", page.Transactions[1].Conversion.OriginalCurrency)
+	assert.Nil(t, page.Transactions[1].Counterparty)
+	assert.Equal(t, map[string]string{"invoice": "42"}, page.Transactions[1].Metadata)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHistory_AfterCursorAscending(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := transaction.NewStore(&db.DB{DB: sqlDB})
+
+	mock.ExpectQuery(`SELECT .* FROM transactions WHERE account_id = \$1 AND description ILIKE '%' \|\| \$2 \|\| '%' AND metadata @> \$3::jsonb AND sequence > \$4 ORDER BY sequence ASC LIMIT \$5`).
+		WithArgs("acc1", `50\%`, `{"invoice":"42"}`, int64(7), 51).
+		WillReturnRows(sqlmock.NewRows(historyColumns))
+
+	page, err := store.History(context.Background(), transaction.HistoryFilter{
+		AccountID: "acc1",
+		Query:     "50%",
+		Metadata:  map[string]string{"invoice": "42"},
+		Ascending: true,
+		Limit:     transaction.DefaultHistoryLimit,
+	}, 7)
+	assert.NoError(t, err)
+	assert.Empty(t, page.Transactions)
+	assert.Empty(t, page.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,39 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/pkg/errors"
+)
+
+// suspenseReason returns why a deposit can't be booked to its account, given the result of locking
+// it, or an empty reason when it can be, or isn't a deposit. Other transactions on such accounts
+// fail, as there is nobody to hold the funds for.
+func suspenseReason(txn model.Transaction, account model.Account, lockErr error) model.SuspenseReason {
+	if txn.Type != TransactionTypeDeposit {
+		return ""
+	}
+
+	switch {
+	case errors.Is(lockErr, ErrAccountNotFound):
+		return model.SuspenseReasonAccountNotFound
+	case lockErr == nil && account.Status == model.AccountStatusClosed:
+		return model.SuspenseReasonAccountClosed
+	}
+	return ""
+}
+
+// insertSuspenseItem records the open suspense item of a deposit just booked to the suspense
+// account, with the account it was sent to.
+func insertSuspenseItem(ctx context.Context, tx *sql.Tx, txn model.Transaction, intendedAccountID string, reason model.SuspenseReason) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO suspense_items (id, transaction_id, suspense_account_id, intended_account_id, amount, currency, reason, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		txn.SuspenseItemID, txn.ID, txn.AccountID, intendedAccountID, txn.Amount.Unwrap(), txn.Currency, reason, model.SuspenseStatusOpen,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create suspense item")
+	}
+	return nil
+}
@@ -42,3 +42,41 @@ func MakeHandler(ms Service, logger *logging.Logger) http.Endpoint {
 
 	return http.Endpoint{Pattern: "/accounts/*", Handler: r}
 }
+
+func MakeBatchHandler(ms Service, logger *logging.Logger) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	batchHandler := kithttp.NewServer(
+		makeBatchEndpoint(ms, logger),
+		decodeBatchRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("POST", "/transactions/batch", batchHandler)
+
+	return http.Endpoint{Pattern: "/transactions/*", Handler: r}
+}
+
+func MakeBatchStatusHandler(ms Service) http.Endpoint {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(error.EncodeError),
+	}
+
+	batchStatusHandler := kithttp.NewServer(
+		makeBatchStatusEndpoint(ms),
+		decodeBatchStatusRequest,
+		kithttp.EncodeJSONResponse,
+		opts...,
+	)
+
+	r := chi.NewRouter()
+
+	r.Method("GET", "/batches/{id}", batchStatusHandler)
+
+	return http.Endpoint{Pattern: "/batches/*", Handler: r}
+}
@@ -0,0 +1,283 @@
+// Package verify checks the Postgres ledger for internal consistency.
+//
+// For every account it walks the entries in sequence order and checks that sequence numbers
+// are contiguous from 1, that each balance_after is the previous one plus the entry's movement,
+// and that the last one equals accounts.balance. The balance before the first entry is the
+// account's initial balance. Balances must not be negative, except on debit-normal accounts
+// such as the settlement account, whose type allows it. Accounts are read in batches, each
+// batch from its own snapshot, so the scan never holds a long transaction.
+package verify
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/mdshahjahanmiah/banking-ledger/model"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/transaction"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"log/slog"
+)
+
+type Kind string
+
+const (
+	KindBalanceMismatch  Kind = "balance_mismatch"  // accounts.balance differs from the last balance_after
+	KindBalanceChain     Kind = "balance_chain"     // balance_after is not the previous balance plus the movement
+	KindSequenceGap      Kind = "sequence_gap"      // sequence numbers are not contiguous from 1
+	KindNegativeBalance  Kind = "negative_balance"  // a balance, or the initial balance, is below zero
+	KindUnknownCurrency  Kind = "unknown_currency"  // transaction currency is not a known ISO 4217 code
+	KindCurrencyMismatch Kind = "currency_mismatch" // transaction currency differs from its account's
+	KindOrphan           Kind = "orphan"            // linked entry without its parent, or transfer without its credit
+)
+
+// Discrepancy is one problem found in the ledger.
+type Discrepancy struct {
+	Kind          Kind
+	AccountID     string
+	TransactionID string
+	Detail        string
+}
+
+func (d Discrepancy) String() string {
+	s := fmt.Sprintf("%s account=%s", d.Kind, d.AccountID)
+	if d.TransactionID != "" {
+		s += " transaction=" + d.TransactionID
+	}
+	return s + " " + d.Detail
+}
+
+// Summary counts what a run checked and found.
+type Summary struct {
+	Accounts      int
+	Transactions  int
+	Discrepancies int
+}
+
+type Verifier struct {
+	db        *db.DB
+	batchSize int
+}
+
+func NewVerifier(db *db.DB, batchSize int) *Verifier {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &Verifier{db: db, batchSize: batchSize}
+}
+
+// Run checks every account and the links between entries, calling report for each discrepancy as it is found.
+func (v *Verifier) Run(ctx context.Context, report func(Discrepancy)) (Summary, error) {
+	var summary Summary
+	found := func(d Discrepancy) {
+		summary.Discrepancies++
+		report(d)
+	}
+
+	after := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		last, accounts, transactions, err := v.verifyBatch(ctx, after, found)
+		if err != nil {
+			return summary, err
+		}
+		summary.Accounts += accounts
+		summary.Transactions += transactions
+
+		if accounts < v.batchSize {
+			break
+		}
+		after = last
+	}
+
+	if err := v.verifyLinks(ctx, found); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+type account struct {
+	id       string
+	currency string
+	balance  decimal.Decimal
+	typ      model.AccountType
+}
+
+type entry struct {
+	id           string
+	accountID    string
+	sequence     int64
+	movement     decimal.Decimal
+	currency     string
+	balanceAfter decimal.Decimal
+}
+
+// verifyBatch checks the next batch of accounts after the given id and returns the last id checked.
+func (v *Verifier) verifyBatch(ctx context.Context, after string, found func(Discrepancy)) (string, int, int, error) {
+	tx, err := v.db.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return "", 0, 0, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("rollback failed", "error", err)
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, currency, balance, type FROM accounts WHERE id::text > $1 ORDER BY id::text LIMIT $2`,
+		after, v.batchSize,
+	)
+	if err != nil {
+		return "", 0, 0, errors.Wrap(err, "failed to read accounts")
+	}
+
+	var accounts []account
+	var ids []string
+	for rows.Next() {
+		var a account
+		if err := rows.Scan(&a.id, &a.currency, &a.balance, &a.typ); err != nil {
+			rows.Close()
+			return "", 0, 0, errors.Wrap(err, "failed to scan account")
+		}
+		accounts = append(accounts, a)
+		ids = append(ids, a.id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", 0, 0, errors.Wrap(err, "failed to read accounts")
+	}
+	if len(accounts) == 0 {
+		return after, 0, 0, nil
+	}
+
+	rows, err = tx.QueryContext(ctx,
+		`SELECT id, account_id, sequence, `+transaction.SignedAmountSQL+`, currency, balance_after
+		FROM transactions
+		WHERE account_id::text = ANY($1) AND status = 'completed'
+		ORDER BY account_id::text, sequence`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return "", 0, 0, errors.Wrap(err, "failed to read transactions")
+	}
+	defer rows.Close()
+
+	// Entries arrive grouped by account in the same order as the accounts
+	transactions := 0
+	i := 0
+	var prev *entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.id, &e.accountID, &e.sequence, &e.movement, &e.currency, &e.balanceAfter); err != nil {
+			return "", 0, 0, errors.Wrap(err, "failed to scan transaction")
+		}
+		transactions++
+
+		if prev == nil || prev.accountID != e.accountID {
+			if prev != nil {
+				checkClosing(accounts[i], prev, found)
+			}
+			for accounts[i].id != e.accountID {
+				i++
+			}
+			prev = nil
+		}
+
+		checkEntry(accounts[i], prev, e, found)
+		prev = &e
+	}
+	if err := rows.Err(); err != nil {
+		return "", 0, 0, errors.Wrap(err, "failed to read transactions")
+	}
+	if prev != nil {
+		checkClosing(accounts[i], prev, found)
+	}
+
+	for _, a := range accounts {
+		if a.balance.IsNegative() && !a.typ.AllowsNegative() {
+			found(Discrepancy{Kind: KindNegativeBalance, AccountID: a.id, Detail: "balance=" + a.balance.String()})
+		}
+	}
+
+	return accounts[len(accounts)-1].id, len(accounts), transactions, nil
+}
+
+// checkEntry checks an entry against the previous entry on the same account, nil for the first.
+func checkEntry(a account, prev *entry, e entry, found func(Discrepancy)) {
+	if !model.IsKnownCurrency(e.currency) {
+		found(Discrepancy{Kind: KindUnknownCurrency, AccountID: a.id, TransactionID: e.id, Detail: "currency=" + e.currency})
+	} else if e.currency != a.currency {
+		found(Discrepancy{Kind: KindCurrencyMismatch, AccountID: a.id, TransactionID: e.id,
+			Detail: fmt.Sprintf("currency=%s account_currency=%s", e.currency, a.currency)})
+	}
+
+	if prev == nil {
+		if e.sequence != 1 {
+			found(Discrepancy{Kind: KindSequenceGap, AccountID: a.id, TransactionID: e.id, Detail: fmt.Sprintf("first sequence=%d", e.sequence)})
+		}
+		if initial := e.balanceAfter.Sub(e.movement); initial.IsNegative() && !a.typ.AllowsNegative() {
+			found(Discrepancy{Kind: KindNegativeBalance, AccountID: a.id, TransactionID: e.id, Detail: "initial balance=" + initial.String()})
+		}
+	} else {
+		if e.sequence != prev.sequence+1 {
+			found(Discrepancy{Kind: KindSequenceGap, AccountID: a.id, TransactionID: e.id,
+				Detail: fmt.Sprintf("sequence=%d previous=%d", e.sequence, prev.sequence)})
+		}
+		if expected := prev.balanceAfter.Add(e.movement); !expected.Equal(e.balanceAfter) {
+			found(Discrepancy{Kind: KindBalanceChain, AccountID: a.id, TransactionID: e.id,
+				Detail: fmt.Sprintf("balance_after=%s expected=%s", e.balanceAfter, expected)})
+		}
+	}
+
+	if e.balanceAfter.IsNegative() && !a.typ.AllowsNegative() {
+		found(Discrepancy{Kind: KindNegativeBalance, AccountID: a.id, TransactionID: e.id, Detail: "balance_after=" + e.balanceAfter.String()})
+	}
+}
+
+// checkClosing compares the account balance with the balance left by its last entry.
+func checkClosing(a account, last *entry, found func(Discrepancy)) {
+	if !a.balance.Equal(last.balanceAfter) {
+		found(Discrepancy{Kind: KindBalanceMismatch, AccountID: a.id,
+			Detail: fmt.Sprintf("balance=%s ledger=%s", a.balance, last.balanceAfter)})
+	}
+}
+
+// verifyLinks finds entries whose parent or account is missing, fees posted without a parent
+// and transfers without their credit leg.
+func (v *Verifier) verifyLinks(ctx context.Context, found func(Discrepancy)) error {
+	rows, err := v.db.DB.QueryContext(ctx,
+		`SELECT t.id, t.account_id, CASE
+			WHEN NOT EXISTS (SELECT 1 FROM accounts a WHERE a.id = t.account_id) THEN 'account missing'
+			WHEN t.parent_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM transactions p WHERE p.id = t.parent_id) THEN 'parent missing'
+			WHEN t.type = 'fee' AND t.parent_id IS NULL THEN 'fee without parent'
+			ELSE 'transfer without credit'
+		END
+		FROM transactions t
+		WHERE NOT EXISTS (SELECT 1 FROM accounts a WHERE a.id = t.account_id)
+			OR (t.parent_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM transactions p WHERE p.id = t.parent_id))
+			OR (t.type = 'fee' AND t.parent_id IS NULL)
+			OR (t.type = 'transfer' AND NOT EXISTS (
+				SELECT 1 FROM transactions c WHERE c.parent_id = t.id AND c.type = 'deposit'))
+		ORDER BY t.id`,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to check transaction links")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d Discrepancy
+		if err := rows.Scan(&d.TransactionID, &d.AccountID, &d.Detail); err != nil {
+			return errors.Wrap(err, "failed to scan transaction link")
+		}
+		d.Kind = KindOrphan
+		found(d)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,93 @@
+package verify_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/db"
+	"github.com/mdshahjahanmiah/banking-ledger/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+var entryColumns = []string{"id", "account_id", "sequence", "movement", "currency", "balance_after"}
+
+func TestVerifier_Run_Consistent(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, currency, balance, type FROM accounts`).
+		WithArgs("", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "currency", "balance", "type"}).
+			AddRow("acc1", "USD", "70", "liability").
+			AddRow("acc2", "EUR", "10", "liability"))
+	// acc2 has no entries, its balance is its initial balance
+	mock.ExpectQuery(`SELECT id, account_id, sequence, .* FROM transactions`).
+		WillReturnRows(sqlmock.NewRows(entryColumns).
+			AddRow("txn1", "acc1", 1, "50", "USD", "150").
+			AddRow("txn2", "acc1", 2, "-80", "USD", "70"))
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, currency, balance, type FROM accounts`).
+		WithArgs("acc2", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "currency", "balance", "type"}))
+	mock.ExpectRollback()
+
+	mock.ExpectQuery(`SELECT t.id, t.account_id, CASE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "problem"}))
+
+	var found []verify.Discrepancy
+	summary, err := verify.NewVerifier(&db.DB{DB: sqlDB}, 2).Run(context.Background(), func(d verify.Discrepancy) {
+		found = append(found, d)
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	assert.Equal(t, verify.Summary{Accounts: 2, Transactions: 2}, summary)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerifier_Run_Discrepancies(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, currency, balance, type FROM accounts`).
+		WithArgs("", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "currency", "balance", "type"}).
+			AddRow("acc1", "USD", "90", "liability").
+			AddRow("acc2", "USD", "5", "liability").
+			AddRow("acc3", "EUR", "20", "liability"))
+	mock.ExpectQuery(`SELECT id, account_id, sequence, .* FROM transactions`).
+		WillReturnRows(sqlmock.NewRows(entryColumns).
+			AddRow("txn1", "acc1", 1, "50", "USD", "50").
+			AddRow("txn2", "acc1", 3, "30", "USD", "85").
+			AddRow("txn3", "acc3", 1, "-30", "XYZ", "20").
+			AddRow("txn4", "acc3", 2, "0", "USD", "20"))
+	mock.ExpectRollback()
+
+	mock.ExpectQuery(`SELECT t.id, t.account_id, CASE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "problem"}).
+			AddRow("txn9", "acc2", "fee without parent"))
+
+	var kinds []verify.Kind
+	summary, err := verify.NewVerifier(&db.DB{DB: sqlDB}, 10).Run(context.Background(), func(d verify.Discrepancy) {
+		kinds = append(kinds, d.Kind)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []verify.Kind{
+		verify.KindSequenceGap,     // txn2 follows sequence 1 with 3
+		verify.KindBalanceChain,    // 50 + 30 is not 85
+		verify.KindBalanceMismatch, // acc1 balance 90, ledger 85
+		verify.KindUnknownCurrency, // txn3
+		verify.KindCurrencyMismatch,
+		verify.KindOrphan,
+	}, kinds)
+	assert.Equal(t, 3, summary.Accounts)
+	assert.Equal(t, 4, summary.Transactions)
+	assert.Equal(t, 6, summary.Discrepancies)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}